@@ -0,0 +1,177 @@
+// Command bot finds the fastest inputs that beat levels and can save them as a replay.
+// It exits with status 1 if a level can't be beaten, so it can check that a pack's
+// levels still work after physics changes:
+//
+//	go run ./cmd/bot                      # every level of the default pack
+//	go run ./cmd/bot -pack warmup
+//	go run ./cmd/bot -level 2 -route -o run.json
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"platform-game-one/internal/bot"
+	"platform-game-one/internal/input"
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/levelgen"
+	"platform-game-one/internal/pack"
+	"platform-game-one/internal/player"
+	"platform-game-one/internal/settings"
+)
+
+func main() {
+	fs := flag.NewFlagSet("bot", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: bot [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	packID := fs.String("pack", pack.Default, "solve the levels of the pack with this `ID`")
+	num := fs.Int("level", 0, "level of the pack to solve; 0 solves them all in order")
+	file := fs.String("level-file", "", "solve a level `file` instead")
+	seed := fs.Uint64("seed", 0, "solve the generated level for seed `N` instead")
+	difficulty := fs.Float64("difficulty", 0.5, "generated level difficulty, 0-1")
+	out := fs.String("o", "", "write the solution to a replay `file`")
+	showRoute := fs.Bool("route", false, "print the route")
+	maxStates := fs.Int("max-states", bot.DefaultMaxStates, "give up after searching `N` states per pass")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if err := run(*packID, *num, *file, set["seed"], levelgen.Params{Seed: *seed, Difficulty: *difficulty}, *out, *showRoute, *maxStates); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// target is one level to solve.
+type target struct {
+	lv  *level.Level
+	num int
+}
+
+func run(packID string, num int, file string, generated bool, gen levelgen.Params, out string, showRoute bool, maxStates int) error {
+	replay := &input.Replay{}
+	var targets []target
+	switch {
+	case file != "":
+		lv, err := level.Load(file)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target{lv: lv})
+		replay.LevelFile = file
+	case generated:
+		lv, err := levelgen.Generate(gen, player.Envelope(1.0/60.0), level.ScreenWidth, level.ScreenHeight)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target{lv: lv})
+		replay.Generate = &gen
+	default:
+		p, err := findPack(packID)
+		if err != nil {
+			return err
+		}
+		if num < 0 || num > p.Len() {
+			return fmt.Errorf("-level %d out of range 0-%d", num, p.Len())
+		}
+		first, last := num, num
+		if num == 0 {
+			first, last = 1, p.Len()
+		}
+		for n := first; n <= last; n++ {
+			lv, err := p.Level(n, level.ScreenWidth, level.ScreenHeight)
+			if err != nil {
+				return err
+			}
+			targets = append(targets, target{lv: lv, num: n})
+		}
+		replay.Pack, replay.Level = p.ID, first
+	}
+
+	// The game doesn't read input while celebrating between levels, so one replay can
+	// hold every level's frames back to back.
+	failed := 0
+	var held input.Buttons
+	for _, t := range targets {
+		name := t.lv.Name
+		if name == "" {
+			name = file
+		}
+		res, err := bot.Solve(t.lv, bot.Options{MaxStates: maxStates, Held: held})
+		if err != nil {
+			fmt.Printf("%s: %v\n", name, err)
+			failed++
+			continue
+		}
+		quality := "fastest"
+		if !res.Optimal {
+			quality = "not proven fastest"
+		}
+		fmt.Printf("%s: %.2fs (%d ticks, %s, %d states searched)\n", name, res.Seconds(), res.Ticks(), quality, res.Searched)
+		if showRoute {
+			printRoute(res.Frames)
+		}
+		replay.Frames = append(replay.Frames, res.Frames...)
+		if len(res.Frames) > 0 {
+			held = res.Frames[len(res.Frames)-1]
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d levels can't be beaten", failed, len(targets))
+	}
+	if out != "" {
+		return replay.Save(out)
+	}
+	return nil
+}
+
+// findPack looks for the pack among the built-in ones and the user's.
+func findPack(id string) (*pack.Pack, error) {
+	var userDir string
+	if dir, err := settings.Dir(); err == nil {
+		userDir = filepath.Join(dir, "packs")
+	}
+	packs, errs := pack.Discover(userDir)
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, "warning:", err)
+	}
+	return packs.Find(id)
+}
+
+// printRoute prints the frames as runs of the same buttons.
+func printRoute(frames []input.Buttons) {
+	for i := 0; i < len(frames); {
+		j := i
+		for j < len(frames) && frames[j] == frames[i] {
+			j++
+		}
+		fmt.Printf("  %6.2fs  %-12s %3d ticks\n", float64(i)/60, buttonNames(frames[i]), j-i)
+		i = j
+	}
+}
+
+func buttonNames(b input.Buttons) string {
+	var names []string
+	for _, n := range []struct {
+		b    input.Buttons
+		name string
+	}{{input.Left, "left"}, {input.Right, "right"}, {input.Jump, "jump"}, {input.Down, "down"}, {input.Up, "up"}} {
+		if b&n.b != 0 {
+			names = append(names, n.name)
+		}
+	}
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, "+")
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"platform-game-one/internal/game"
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/netplay"
+	"platform-game-one/internal/pack"
+	"platform-game-one/internal/player"
+	"platform-game-one/internal/settings"
+)
+
+type config struct {
+	pack       string
+	listPacks  bool
+	level      int
+	levelFile  string
+	edit       string
+	seed       uint64
+	difficulty float64
+	width      int
+	height     int
+	fullscreen bool
+	vsync      bool
+	shape      player.Shape
+	coop       bool
+	race       bool
+	split      game.Split
+	netListen  string
+	netPeer    string
+	netPlayer  int
+	netDelay   int
+	netLink    netplay.Link // simulated latency and loss
+	board      string
+	name       string
+	shape2     player.Shape
+	debug      bool
+	mute       bool
+	skin       string
+	record     string
+	replay     string
+	headless   int
+
+	assistArc        bool
+	assistSpeed      float64
+	assistJumps      bool
+	assistInvincible bool
+	set              map[string]bool // flags given on the command line
+}
+
+// parseFlags parses the command line. On error it has already printed the
+// problem and usage to stderr.
+func parseFlags(args []string, stderr io.Writer) (*config, error) {
+	cfg := &config{set: map[string]bool{}}
+	fs := flag.NewFlagSet("game", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: game [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	var shapeName, shape2Name, splitName string
+	fs.StringVar(&cfg.pack, "pack", pack.Default, "play the level pack with this `ID`")
+	fs.BoolVar(&cfg.listPacks, "list-packs", false, "list the level packs and your progress, then exit")
+	fs.IntVar(&cfg.level, "level", 1, "level of the pack to start at")
+	fs.StringVar(&cfg.levelFile, "level-file", "", "play a level `file` (JSON) instead of a pack")
+	fs.StringVar(&cfg.edit, "edit", "", "open a level `file` in the editor, creating it if it doesn't exist")
+	fs.Uint64Var(&cfg.seed, "seed", 0, "play endless generated levels, starting from seed `N`")
+	fs.Float64Var(&cfg.difficulty, "difficulty", 0.5, "generated level difficulty, 0-1")
+	fs.IntVar(&cfg.width, "width", level.ScreenWidth, "window width in pixels")
+	fs.IntVar(&cfg.height, "height", level.ScreenHeight, "window height in pixels")
+	fs.BoolVar(&cfg.fullscreen, "fullscreen", false, "start in fullscreen")
+	fs.BoolVar(&cfg.vsync, "vsync", true, "enable vsync")
+	fs.StringVar(&shapeName, "shape", "circle", "starting shape: circle, triangle or hexagon")
+	fs.BoolVar(&cfg.coop, "coop", false, "two players on one keyboard: W/A/D + Tab and the arrow keys + right Shift")
+	fs.BoolVar(&cfg.race, "race", false, "two players race on a split screen, with the same keys as -coop")
+	fs.StringVar(&splitName, "split", "vertical", "how -race divides the screen: vertical or horizontal")
+	fs.StringVar(&cfg.netListen, "net-listen", "", "play co-op over the network, listening on UDP `address` (e.g. :7000)")
+	fs.StringVar(&cfg.netPeer, "net-peer", "", "the other player's UDP `address` (e.g. 192.168.1.20:7000)")
+	fs.IntVar(&cfg.netPlayer, "net-player", 1, "which player you are over the network, 1 or 2")
+	fs.IntVar(&cfg.netDelay, "net-delay", 2, "ticks of input delay over the network; both players must match")
+	fs.DurationVar(&cfg.netLink.Latency, "net-latency", 0, "simulate this much extra one-way network latency")
+	fs.DurationVar(&cfg.netLink.Jitter, "net-jitter", 0, "simulate up to this much random extra latency")
+	fs.Float64Var(&cfg.netLink.Loss, "net-loss", 0, "simulate losing this fraction of packets, 0-1")
+	fs.StringVar(&cfg.board, "leaderboard", "", "submit your times to the leaderboard server at `URL`")
+	fs.StringVar(&cfg.name, "name", "", "your `name` on the leaderboard")
+	fs.StringVar(&shape2Name, "shape2", "triangle", "second player's starting shape in -coop or -race")
+	fs.BoolVar(&cfg.debug, "debug", false, "show the debug overlay")
+	fs.BoolVar(&cfg.mute, "mute", false, "turn off all sound")
+	fs.StringVar(&cfg.skin, "skin", "", "draw the starting shape with the sprite skin `name`")
+	fs.StringVar(&cfg.record, "record", "", "record input to a replay `file`")
+	fs.StringVar(&cfg.replay, "replay", "", "play back input from a replay `file`")
+	fs.IntVar(&cfg.headless, "headless", 0, "run `N` ticks without a window, print the result and exit")
+	fs.BoolVar(&cfg.assistArc, "assist-arc", false, "assist: show where a jump would land")
+	fs.Float64Var(&cfg.assistSpeed, "assist-speed", 1, "assist: game speed, 0.25-1")
+	fs.BoolVar(&cfg.assistJumps, "assist-jumps", false, "assist: allow jumping in mid-air")
+	fs.BoolVar(&cfg.assistInvincible, "assist-invincible", false, "assist: falling puts you back on the last safe ground")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	fail := func(err error) (*config, error) {
+		fmt.Fprintln(fs.Output(), "error:", err)
+		fs.Usage()
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return fail(fmt.Errorf("unexpected arguments: %v", fs.Args()))
+	}
+
+	set := cfg.set
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	shape, err := player.ParseShape(shapeName)
+	if err != nil {
+		return fail(err)
+	}
+	cfg.shape = shape
+	if cfg.shape2, err = player.ParseShape(shape2Name); err != nil {
+		return fail(err)
+	}
+	if cfg.split, err = game.ParseSplit(splitName); err != nil {
+		return fail(err)
+	}
+
+	switch {
+	case cfg.level < 1:
+		return fail(fmt.Errorf("-level %d must be at least 1", cfg.level))
+	case set["pack"] && (cfg.levelFile != "" || cfg.edit != "" || set["seed"] || cfg.replay != ""):
+		return fail(errors.New("-pack cannot be combined with -level-file, -edit, -seed or -replay"))
+	case set["level"] && cfg.levelFile != "":
+		return fail(errors.New("-level and -level-file cannot be used together"))
+	case cfg.edit != "" && (set["level"] || cfg.levelFile != "" || cfg.record != "" || cfg.replay != "" || cfg.headless > 0):
+		return fail(errors.New("-edit cannot be combined with -level, -level-file, -record, -replay or -headless"))
+	case set["seed"] && (set["level"] || cfg.levelFile != "" || cfg.edit != ""):
+		return fail(errors.New("-seed cannot be combined with -level, -level-file or -edit"))
+	case cfg.coop && cfg.race:
+		return fail(errors.New("-coop and -race cannot be used together"))
+	case (cfg.coop || cfg.race) && (cfg.edit != "" || cfg.record != "" || cfg.replay != ""):
+		return fail(errors.New("-coop and -race cannot be combined with -edit, -record or -replay"))
+	case set["shape2"] && !cfg.coop && !cfg.race && cfg.netListen == "":
+		return fail(errors.New("-shape2 only applies to -coop, -race and network play"))
+	case set["split"] && !cfg.race:
+		return fail(errors.New("-split only applies to -race"))
+	case (cfg.netListen == "") != (cfg.netPeer == ""):
+		return fail(errors.New("-net-listen and -net-peer must be used together"))
+	case cfg.netListen != "" && (cfg.race || cfg.edit != "" || cfg.record != "" || cfg.replay != ""):
+		return fail(errors.New("network play cannot be combined with -race, -edit, -record or -replay"))
+	case cfg.netListen == "" && (set["net-player"] || set["net-delay"] || set["net-latency"] || set["net-jitter"] || set["net-loss"]):
+		return fail(errors.New("-net-* flags need -net-listen and -net-peer"))
+	case cfg.netPlayer != 1 && cfg.netPlayer != 2:
+		return fail(fmt.Errorf("-net-player %d must be 1 or 2", cfg.netPlayer))
+	case cfg.netDelay < 0:
+		return fail(fmt.Errorf("-net-delay %d must not be negative", cfg.netDelay))
+	case cfg.netLink.Latency < 0 || cfg.netLink.Jitter < 0:
+		return fail(errors.New("-net-latency and -net-jitter must not be negative"))
+	case cfg.netLink.Loss < 0 || cfg.netLink.Loss > 1:
+		return fail(fmt.Errorf("-net-loss %g out of range 0-1", cfg.netLink.Loss))
+	case cfg.board != "" && cfg.name == "":
+		return fail(errors.New("-leaderboard needs your -name"))
+	case cfg.name != "" && cfg.board == "":
+		return fail(errors.New("-name only applies to -leaderboard"))
+	case cfg.board != "" && (cfg.replay != "" || cfg.headless > 0):
+		return fail(errors.New("-leaderboard cannot be combined with -replay or -headless"))
+	case set["difficulty"] && !set["seed"]:
+		return fail(errors.New("-difficulty only applies to generated levels; add -seed"))
+	case cfg.difficulty < 0 || cfg.difficulty > 1:
+		return fail(fmt.Errorf("-difficulty %g out of range 0-1", cfg.difficulty))
+	case cfg.width <= 0 || cfg.height <= 0:
+		return fail(fmt.Errorf("window size %dx%d must be positive", cfg.width, cfg.height))
+	case cfg.headless < 0:
+		return fail(fmt.Errorf("-headless %d must not be negative", cfg.headless))
+	case cfg.replay != "" && cfg.record == cfg.replay:
+		return fail(errors.New("-record and -replay must be different files"))
+	case cfg.replay != "" && (set["level"] || set["level-file"] || set["seed"] || set["shape"]):
+		return fail(errors.New("-replay sets the level and shape; don't combine it with -level, -level-file, -seed or -shape"))
+	case cfg.replay != "" && (set["assist-arc"] || set["assist-speed"] || set["assist-jumps"] || set["assist-invincible"]):
+		return fail(errors.New("-replay uses the assists it was recorded with; don't combine it with -assist-* flags"))
+	case cfg.assistSpeed < 0.25 || cfg.assistSpeed > 1:
+		return fail(fmt.Errorf("-assist-speed %g out of range 0.25-1", cfg.assistSpeed))
+	}
+	if cfg.levelFile != "" {
+		if _, err := os.Stat(cfg.levelFile); err != nil {
+			return fail(err)
+		}
+	}
+	if cfg.replay != "" {
+		if _, err := os.Stat(cfg.replay); err != nil {
+			return fail(err)
+		}
+	}
+	return cfg, nil
+}
+
+// applyAssist overrides the saved assists with any given on the command line.
+func (cfg *config) applyAssist(a settings.Assist) settings.Assist {
+	if cfg.set["assist-arc"] {
+		a.ShowArc = cfg.assistArc
+	}
+	if cfg.set["assist-speed"] {
+		a.Speed = cfg.assistSpeed
+	}
+	if cfg.set["assist-jumps"] {
+		a.InfiniteJumps = cfg.assistJumps
+	}
+	if cfg.set["assist-invincible"] {
+		a.Invincible = cfg.assistInvincible
+	}
+	return a
+}
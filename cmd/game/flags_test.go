@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"platform-game-one/internal/player"
+)
+
+func TestParseFlags(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "level.json")
+	if err := os.WriteFile(file, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		args []string
+		want string // in the error; empty means the flags are fine
+	}{
+		{"no flags", nil, ""},
+		{"a shape", []string{"-shape", "hexagon"}, ""},
+		{"a level file", []string{"-level-file", file}, ""},
+		{"headless", []string{"-headless", "600"}, ""},
+		{"an unknown shape", []string{"-shape", "square"}, `unknown shape "square"`},
+		{"an unknown second shape", []string{"-coop", "-shape2", "blob"}, `unknown shape "blob"`},
+		{"a level and a level file", []string{"-level", "2", "-level-file", file}, "-level and -level-file cannot be used together"},
+		{"a missing level file", []string{"-level-file", filepath.Join(t.TempDir(), "nope.json")}, "no such file"},
+		{"negative headless ticks", []string{"-headless", "-5"}, "-headless -5 must not be negative"},
+		{"level zero", []string{"-level", "0"}, "-level 0 must be at least 1"},
+		{"co-op and race", []string{"-coop", "-race"}, "-coop and -race cannot be used together"},
+		{"a split without a race", []string{"-split", "horizontal"}, "-split only applies to -race"},
+		{"a leaderboard without a name", []string{"-leaderboard", "http://localhost:8080"}, "-leaderboard needs your -name"},
+		{"a stray argument", []string{"level.json"}, "unexpected arguments"},
+		{"an unknown flag", []string{"-jump-higher"}, "flag provided but not defined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cfg, err := parseFlags(tt.args, &out)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v\n%s", err, out.String())
+				}
+				if out.Len() > 0 {
+					t.Errorf("printed %q", out.String())
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("parsed to %+v, want an error containing %q", cfg, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q, want %q", err, tt.want)
+			}
+			// the user sees the problem and how to fix it
+			if !strings.Contains(out.String(), tt.want) || !strings.Contains(out.String(), "Usage: game [flags]") {
+				t.Errorf("printed %q, want the error and usage", out.String())
+			}
+		})
+	}
+}
+
+func TestParseFlagsShape(t *testing.T) {
+	cfg, err := parseFlags([]string{"-shape", "triangle"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.shape != player.ShapeTriangle || !cfg.set["shape"] || cfg.set["level"] {
+		t.Errorf("shape %v, set %v; want triangle, with only -shape set", cfg.shape, cfg.set)
+	}
+}
+
+func TestParseFlagsHelp(t *testing.T) {
+	var out bytes.Buffer
+	if _, err := parseFlags([]string{"-h"}, &out); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("-h: error %v, want flag.ErrHelp", err)
+	}
+	if !strings.Contains(out.String(), "-headless N") {
+		t.Errorf("-h printed %q, want the flags", out.String())
+	}
+}
@@ -1,16 +1,261 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"platform-game-one/internal/audio"
 	"platform-game-one/internal/game"
+	"platform-game-one/internal/input"
+	"platform-game-one/internal/leaderboard"
+	"platform-game-one/internal/levelgen"
+	"platform-game-one/internal/netplay"
+	"platform-game-one/internal/pack"
+	"platform-game-one/internal/player"
+	"platform-game-one/internal/records"
+	"platform-game-one/internal/settings"
+	"platform-game-one/internal/skin"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 func main() {
-	g := game.New()
-	ebiten.SetWindowSize(game.ScreenWidth, game.ScreenHeight)
-	ebiten.SetWindowTitle("Platformer - Level One")
-	if err := ebiten.RunGame(g); err != nil {
-		panic(err)
+	cfg, err := parseFlags(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		// parseFlags already printed the problem and usage
+		os.Exit(2)
+	}
+	if err := run(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(cfg *config) error {
+	settingsPath, err := settings.Path()
+	if err != nil {
+		return err
+	}
+	prefs, err := settings.Load(settingsPath)
+	if err != nil {
+		return err
+	}
+
+	dir, err := settings.Dir()
+	if err != nil {
+		return err
+	}
+	packs, errs := pack.Discover(filepath.Join(dir, "packs"))
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, "warning:", err)
+	}
+	if cfg.listPacks {
+		return listPacks(packs, filepath.Join(dir, "records.json"))
+	}
+	packID := cfg.pack
+
+	opts := game.Options{
+		StartLevel: cfg.level,
+		LevelFile:  cfg.levelFile,
+		Shape:      cfg.shape,
+		Debug:      cfg.debug,
+		Assist:     cfg.applyAssist(prefs.Assist),
+	}
+	if cfg.edit != "" {
+		opts.LevelFile, opts.Edit = cfg.edit, true
+	}
+	if cfg.board != "" {
+		opts.Board, opts.PlayerName = leaderboard.NewClient(cfg.board), cfg.name
+	}
+	if cfg.set["seed"] {
+		opts.Generate = &levelgen.Params{Seed: cfg.seed, Difficulty: cfg.difficulty}
+	}
+
+	// Only real play sessions count toward records.
+	var recordsPath string
+	if cfg.replay == "" && cfg.headless == 0 {
+		recordsPath = filepath.Join(dir, "records.json")
+		if opts.Records, err = records.Load(recordsPath); err != nil {
+			return err
+		}
+		opts.RecordsAt = recordsPath
+	}
+
+	var rec *input.Recorder
+	switch {
+	case cfg.replay != "":
+		r, err := input.LoadReplay(cfg.replay)
+		if err != nil {
+			return err
+		}
+		opts.StartLevel, opts.LevelFile, opts.Generate, opts.Assist = r.Level, r.LevelFile, r.Generate, r.Assist
+		if packID = r.Pack; packID == "" {
+			packID = pack.Default
+		}
+		opts.Shape = player.Shape(r.Shape)
+		if !opts.Shape.Valid() {
+			return fmt.Errorf("replay %s: invalid shape %d", cfg.replay, r.Shape)
+		}
+		opts.Input = input.NewPlayer(r)
+	case cfg.headless > 0:
+		opts.Input = input.None{}
+	}
+	if opts.LevelFile == "" && opts.Generate == nil {
+		if opts.Pack, err = packs.Find(packID); err != nil {
+			return err
+		}
+	}
+	if cfg.netListen != "" {
+		var err error
+		if opts.Netplay, err = startNetplay(cfg); err != nil {
+			return err
+		}
+		defer opts.Netplay.Close()
+	}
+	if cfg.coop || cfg.race || opts.Netplay != nil {
+		opts.Partner = &game.PlayerOptions{Shape: cfg.shape2}
+		opts.Race, opts.Split = cfg.race, cfg.split
+		if cfg.headless > 0 {
+			opts.Partner.Input = input.None{}
+		}
+	}
+	if cfg.record != "" {
+		src := opts.Input
+		if src == nil {
+			src = input.DefaultKeyboard()
+		}
+		rec = input.NewRecorder(src, &input.Replay{
+			Level:     opts.StartLevel,
+			LevelFile: opts.LevelFile,
+			Generate:  opts.Generate,
+			Shape:     int(opts.Shape),
+			Assist:    opts.Assist,
+		})
+		if opts.Pack != nil {
+			rec.Replay.Pack = opts.Pack.ID
+			if rec.Replay.Level == 0 {
+				rec.Replay.Level = 1
+			}
+		}
+		opts.Input = rec
+	}
+
+	opts.Skins = loadSkins(prefs.Skins, opts.Shape, cfg.skin)
+
+	volume := prefs.Volume
+	if cfg.mute {
+		volume.Master = 0
+	}
+	var backend audio.Backend = &audio.Fake{}
+	if cfg.headless == 0 {
+		backend = audio.NewEbiten()
+	}
+	if opts.Audio, err = audio.NewManager(backend, volume); err != nil {
+		return err
+	}
+
+	g, err := game.New(opts)
+	if err != nil {
+		return err
+	}
+
+	if cfg.headless > 0 {
+		res, err := g.RunHeadless(cfg.headless)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("ticks=%d level=%d won=%v x=%.2f y=%.2f grounded=%v\n",
+			res.Ticks, res.Level, res.Won, res.X, res.Y, res.Grounded)
+	} else {
+		ebiten.SetWindowSize(cfg.width, cfg.height)
+		ebiten.SetWindowTitle(g.Title())
+		ebiten.SetFullscreen(cfg.fullscreen)
+		ebiten.SetVsyncEnabled(cfg.vsync)
+		if err := ebiten.RunGame(g); err != nil {
+			return err
+		}
+	}
+
+	if rec != nil {
+		if err := rec.Replay.Save(cfg.record); err != nil {
+			return fmt.Errorf("saving recording: %w", err)
+		}
+	}
+	return nil
+}
+
+// listPacks prints every level pack with how far the records at recordsPath get through it.
+func listPacks(packs *pack.Registry, recordsPath string) error {
+	book, err := records.Load(recordsPath)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tAUTHOR\tDIFFICULTY\tLEVELS\tDONE")
+	for _, p := range packs.Packs {
+		id := p.ID
+		if p.User {
+			id += " (yours)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\n", id, p.Name, p.Author, p.Difficulty, p.Len(), book.Completed(p.ID))
+	}
+	return w.Flush()
+}
+
+// startNetplay opens the UDP socket for network play, with any simulated bad network.
+func startNetplay(cfg *config) (*netplay.Session, error) {
+	udp, err := netplay.ListenUDP(cfg.netListen, cfg.netPeer)
+	if err != nil {
+		return nil, err
+	}
+	var t netplay.Transport = udp
+	if link := cfg.netLink; link.Latency > 0 || link.Jitter > 0 || link.Loss > 0 {
+		link.Seed = uint64(cfg.netPlayer)
+		t = netplay.Impair(udp, link)
+	}
+	s, err := netplay.New(t, netplay.Config{Local: cfg.netPlayer - 1, Delay: cfg.netDelay})
+	if err != nil {
+		udp.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadSkins loads the skins chosen in settings, plus override for the starting shape.
+// A skin that fails to load is reported and that shape keeps its vector look.
+func loadSkins(chosen map[string]string, start player.Shape, override string) map[player.Shape]*skin.Skin {
+	names := map[player.Shape]string{}
+	for shapeName, skinName := range chosen {
+		s, err := player.ParseShape(shapeName)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "warning: settings skins:", err)
+			continue
+		}
+		names[s] = skinName
+	}
+	if override != "" {
+		names[start] = override
+	}
+
+	var userDir string
+	if dir, err := settings.Dir(); err == nil {
+		userDir = filepath.Join(dir, "skins")
+	}
+	skins := map[player.Shape]*skin.Skin{}
+	for s, name := range names {
+		sk, err := skin.Find(userDir, name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v; drawing the %s without a skin\n", err, s)
+			continue
+		}
+		skins[s] = sk
 	}
+	return skins
 }
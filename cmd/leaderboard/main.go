@@ -0,0 +1,55 @@
+// Command leaderboard serves shared level times. Every submitted run is replayed
+// before it is accepted:
+//
+//	go run ./cmd/leaderboard -addr :8080 -store times.json
+//	go run ./cmd/game -leaderboard http://localhost:8080 -name Sam
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"platform-game-one/internal/leaderboard"
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/pack"
+)
+
+func main() {
+	fs := flag.NewFlagSet("leaderboard", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: leaderboard [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	addr := fs.String("addr", ":8080", "listen on `address`")
+	path := fs.String("store", "", "keep runs in a JSON `file`; without it they're forgotten on exit")
+	packDir := fs.String("packs", "", "also accept runs on the level packs in `dir`")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	}
+
+	var store leaderboard.Store = leaderboard.NewMemoryStore()
+	if *path != "" {
+		fstore, err := leaderboard.OpenFileStore(*path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		store = fstore
+	}
+	packs, errs := pack.Discover(*packDir)
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, "warning:", err)
+	}
+	log.Printf("leaderboard listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, leaderboard.NewServer(store, packs, level.ScreenWidth, level.ScreenHeight)); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
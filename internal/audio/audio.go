@@ -0,0 +1,200 @@
+package audio
+
+import (
+	"fmt"
+
+	"platform-game-one/internal/event"
+	"platform-game-one/internal/player"
+	"platform-game-one/internal/settings"
+)
+
+const (
+	crossfadeTime = 1.0 // seconds to fade between level tracks
+	minLandSpeed  = 150 // quieter landings than this make no sound
+)
+
+// Voice is one playable sound.
+type Voice interface {
+	Play()
+	Pause()
+	Rewind() error
+	SetVolume(v float64)
+	IsPlaying() bool
+}
+
+// Backend creates voices from 16-bit stereo PCM at SampleRate.
+type Backend interface {
+	Sound(name string, pcm []byte) (Voice, error)
+	Loop(name string, pcm []byte) (Voice, error)
+}
+
+// Manager plays sound effects for gameplay events and crossfades music between levels.
+type Manager struct {
+	backend Backend
+	volume  settings.Volume
+	sfx     map[event.Kind]Voice
+	ground  map[string]Voice       // landing sounds for each material, by kind
+	states  map[player.State]Voice // sounds for entering some player states
+	music   []Voice                // created on first use, indexed like tracks
+	current int                    // playing track, -1 for none
+	fading  int                    // track fading out, -1 for none
+	fade    float64                // crossfade progress, 0-1
+}
+
+// NewManager synthesizes the default sounds on b.
+func NewManager(b Backend, v settings.Volume) (*Manager, error) {
+	m := &Manager{
+		backend: b,
+		volume:  v,
+		sfx:     map[event.Kind]Voice{},
+		ground:  map[string]Voice{},
+		states:  map[player.State]Voice{},
+		music:   make([]Voice, len(tracks)),
+		current: -1,
+		fading:  -1,
+	}
+	sounds := []struct {
+		kind event.Kind
+		name string
+		pcm  []byte
+	}{
+		{event.Jump, "jump", jumpSound()},
+		{event.Land, "land", landSound()},
+		{event.Death, "death", deathSound()},
+		{event.Goal, "goal", goalSound()},
+		{event.ShapeChange, "shape", shapeSound()},
+		{event.Launch, "launch", launchSound()},
+		{event.Break, "break", breakSound()},
+		{event.Trigger, "trigger", triggerSound()},
+		{event.Splash, "splash", splashSound()},
+	}
+	for _, s := range sounds {
+		voice, err := b.Sound(s.name, s.pcm)
+		if err != nil {
+			return nil, fmt.Errorf("creating %s sound: %w", s.name, err)
+		}
+		m.sfx[s.kind] = voice
+	}
+	grounds := []struct {
+		material string
+		pcm      []byte
+	}{
+		{"ice", iceSound()},
+		{"conveyor", conveyorSound()},
+		{"sticky", stickySound()},
+	}
+	for _, s := range grounds {
+		voice, err := b.Sound("land-"+s.material, s.pcm)
+		if err != nil {
+			return nil, fmt.Errorf("creating %s landing sound: %w", s.material, err)
+		}
+		m.ground[s.material] = voice
+	}
+	states := []struct {
+		state player.State
+		pcm   []byte
+	}{
+		{player.StateClimbing, grabSound()},
+		{player.StateRespawning, appearSound()},
+	}
+	for _, s := range states {
+		voice, err := b.Sound("state-"+s.state.String(), s.pcm)
+		if err != nil {
+			return nil, fmt.Errorf("creating %s sound: %w", s.state, err)
+		}
+		m.states[s.state] = voice
+	}
+	return m, nil
+}
+
+// SetVolume changes the bus levels.
+func (m *Manager) SetVolume(v settings.Volume) {
+	m.volume = v
+	m.applyMusicVolume()
+}
+
+// Handle reacts to a gameplay event. Subscribe it to the game's event bus.
+func (m *Manager) Handle(e event.Event) {
+	if e.Kind == event.LevelStart {
+		m.playTrack((e.Level - 1) % len(tracks))
+		return
+	}
+	voice, ok := m.sfx[e.Kind]
+	if e.Kind == event.StateChange {
+		voice, ok = m.states[e.To]
+	}
+	if !ok {
+		return
+	}
+	gain := 1.0
+	if e.Kind == event.Land {
+		if e.Speed < minLandSpeed {
+			return
+		}
+		if v, ok := m.ground[e.Material]; ok {
+			voice = v
+		}
+		gain = e.Speed / 600
+		if gain > 1 {
+			gain = 1
+		}
+	}
+	voice.SetVolume(m.volume.Master * m.volume.SFX * gain)
+	if err := voice.Rewind(); err != nil {
+		return
+	}
+	voice.Play()
+}
+
+// playTrack starts crossfading to track i.
+func (m *Manager) playTrack(i int) {
+	if i == m.current {
+		return
+	}
+	if m.music[i] == nil {
+		voice, err := m.backend.Loop(fmt.Sprintf("music-%d", i+1), music(tracks[i]))
+		if err != nil {
+			return // play on without music
+		}
+		m.music[i] = voice
+	}
+	if m.fading >= 0 && m.fading != i {
+		m.music[m.fading].Pause()
+	}
+	back := i == m.fading // turning around mid-fade picks up from the same volumes
+	m.fading, m.current = m.current, i
+	switch {
+	case back:
+		m.fade = 1 - m.fade
+	case m.fading < 0:
+		m.fade = 1
+	default:
+		m.fade = 0
+	}
+	m.applyMusicVolume()
+	m.music[i].Play()
+}
+
+// Update advances the crossfade by dt seconds.
+func (m *Manager) Update(dt float64) {
+	if m.fading < 0 {
+		return
+	}
+	m.fade += dt / crossfadeTime
+	if m.fade >= 1 {
+		m.fade = 1
+		m.music[m.fading].Pause()
+		m.fading = -1
+	}
+	m.applyMusicVolume()
+}
+
+func (m *Manager) applyMusicVolume() {
+	bus := m.volume.Master * m.volume.Music
+	if m.current >= 0 {
+		m.music[m.current].SetVolume(bus * m.fade)
+	}
+	if m.fading >= 0 {
+		m.music[m.fading].SetVolume(bus * (1 - m.fade))
+	}
+}
@@ -0,0 +1,144 @@
+package audio
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"platform-game-one/internal/event"
+	"platform-game-one/internal/player"
+	"platform-game-one/internal/settings"
+)
+
+var fullVolume = settings.Volume{Master: 1, Music: 1, SFX: 1}
+
+func newTestManager(t *testing.T, v settings.Volume) (*Manager, *Fake) {
+	t.Helper()
+	f := &Fake{Record: true}
+	m, err := NewManager(f, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, f
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestHandlePlaysVoiceForEvent(t *testing.T) {
+	tests := []struct {
+		name string
+		e    event.Event
+		want []string
+	}{
+		{"jump", event.Event{Kind: event.Jump}, []string{"jump"}},
+		{"death", event.Event{Kind: event.Death}, []string{"death"}},
+		{"hard landing", event.Event{Kind: event.Land, Speed: 600}, []string{"land"}},
+		{"soft landing", event.Event{Kind: event.Land, Speed: minLandSpeed - 1}, nil},
+		{"landing on ice", event.Event{Kind: event.Land, Speed: 600, Material: "ice"}, []string{"land-ice"}},
+		{"jumping off ice", event.Event{Kind: event.Jump, Material: "ice"}, []string{"jump"}},
+		{"splash", event.Event{Kind: event.Splash}, []string{"splash"}},
+		{"grabbing a ladder", event.Event{Kind: event.StateChange, From: player.StateJumping, To: player.StateClimbing}, []string{"state-climbing"}},
+		{"respawning", event.Event{Kind: event.StateChange, From: player.StateDead, To: player.StateRespawning}, []string{"state-respawning"}},
+		{"a state with no sound", event.Event{Kind: event.StateChange, From: player.StateIdle, To: player.StateRunning}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, f := newTestManager(t, fullVolume)
+			m.Handle(tt.e)
+			if !slices.Equal(f.Played, tt.want) {
+				t.Errorf("played %q, want %q", f.Played, tt.want)
+			}
+		})
+	}
+}
+
+func TestLandingVolumeFollowsSpeed(t *testing.T) {
+	m, _ := newTestManager(t, fullVolume)
+	m.Handle(event.Event{Kind: event.Land, Speed: 300})
+	if v := m.sfx[event.Land].(*FakeVoice).Volume; !near(v, 0.5) {
+		t.Errorf("landing at 300 played at %v, want 0.5", v)
+	}
+	m.Handle(event.Event{Kind: event.Land, Speed: 6000})
+	if v := m.sfx[event.Land].(*FakeVoice).Volume; !near(v, 1) {
+		t.Errorf("landing at 6000 played at %v, want 1", v)
+	}
+}
+
+func TestVolumeBuses(t *testing.T) {
+	m, _ := newTestManager(t, settings.Volume{Master: 0.8, Music: 0.25, SFX: 0.5})
+	m.Handle(event.Event{Kind: event.Jump})
+	if v := m.sfx[event.Jump].(*FakeVoice).Volume; !near(v, 0.4) {
+		t.Errorf("jump volume %v, want master*sfx 0.4", v)
+	}
+	m.Handle(event.Event{Kind: event.LevelStart, Level: 1})
+	music := m.music[0].(*FakeVoice)
+	if !near(music.Volume, 0.2) {
+		t.Errorf("music volume %v, want master*music 0.2", music.Volume)
+	}
+	m.SetVolume(settings.Volume{Master: 0.5, Music: 1, SFX: 1})
+	if !near(music.Volume, 0.5) {
+		t.Errorf("music volume after SetVolume %v, want 0.5", music.Volume)
+	}
+}
+
+func TestMusicCrossfade(t *testing.T) {
+	m, f := newTestManager(t, fullVolume)
+	m.Handle(event.Event{Kind: event.LevelStart, Level: 1})
+	first := m.music[0].(*FakeVoice)
+	if !first.IsPlaying() || !near(first.Volume, 1) {
+		t.Fatalf("first track playing %v at %v, want playing at full volume", first.IsPlaying(), first.Volume)
+	}
+
+	m.Handle(event.Event{Kind: event.LevelStart, Level: 2})
+	second := m.music[1].(*FakeVoice)
+	if !near(first.Volume, 1) || !near(second.Volume, 0) {
+		t.Errorf("at the start of the fade: volumes %v and %v, want 1 and 0", first.Volume, second.Volume)
+	}
+	m.Update(crossfadeTime / 2)
+	if !near(first.Volume, 0.5) || !near(second.Volume, 0.5) {
+		t.Errorf("halfway: volumes %v and %v, want 0.5 and 0.5", first.Volume, second.Volume)
+	}
+	m.Update(crossfadeTime)
+	if first.IsPlaying() || !second.IsPlaying() || !near(second.Volume, 1) {
+		t.Errorf("after the fade: first playing %v, second playing %v at %v; want only the second, at 1",
+			first.IsPlaying(), second.IsPlaying(), second.Volume)
+	}
+
+	// the same level again doesn't restart its track
+	m.Handle(event.Event{Kind: event.LevelStart, Level: 2})
+	if want := []string{"music-1", "music-2"}; !slices.Equal(f.Played, want) {
+		t.Errorf("played %q, want %q", f.Played, want)
+	}
+}
+
+func TestMusicCrossfadeTurnsAround(t *testing.T) {
+	m, _ := newTestManager(t, fullVolume)
+	m.Handle(event.Event{Kind: event.LevelStart, Level: 1})
+	m.Handle(event.Event{Kind: event.LevelStart, Level: 2})
+	m.Update(crossfadeTime / 4)
+	first, second := m.music[0].(*FakeVoice), m.music[1].(*FakeVoice)
+
+	// back to the first track while it's still fading out
+	m.Handle(event.Event{Kind: event.LevelStart, Level: 1})
+	if !near(first.Volume, 0.75) || !near(second.Volume, 0.25) {
+		t.Errorf("on turning around: volumes %v and %v, want 0.75 and 0.25", first.Volume, second.Volume)
+	}
+	m.Update(crossfadeTime / 4)
+	if !near(first.Volume, 1) || second.IsPlaying() {
+		t.Errorf("after the rest of the fade: first at %v, second playing %v; want only the first, at 1", first.Volume, second.IsPlaying())
+	}
+}
+
+func TestFakeRecordsOnlyWhenAsked(t *testing.T) {
+	f := &Fake{}
+	m, err := NewManager(f, fullVolume)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 1000 {
+		m.Handle(event.Event{Kind: event.Jump})
+	}
+	if len(f.Played) != 0 {
+		t.Errorf("recorded %d voices without Record", len(f.Played))
+	}
+}
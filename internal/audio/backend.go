@@ -0,0 +1,27 @@
+package audio
+
+import (
+	"bytes"
+
+	eaudio "github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+// Ebiten plays voices through Ebitengine's audio context.
+type Ebiten struct {
+	ctx *eaudio.Context
+}
+
+// NewEbiten creates the audio context. Only one may exist per process.
+func NewEbiten() *Ebiten {
+	return &Ebiten{ctx: eaudio.NewContext(SampleRate)}
+}
+
+// Sound returns a one-shot voice.
+func (e *Ebiten) Sound(name string, pcm []byte) (Voice, error) {
+	return e.ctx.NewPlayerFromBytes(pcm), nil
+}
+
+// Loop returns a voice that repeats forever.
+func (e *Ebiten) Loop(name string, pcm []byte) (Voice, error) {
+	return e.ctx.NewPlayer(eaudio.NewInfiniteLoop(bytes.NewReader(pcm), int64(len(pcm))))
+}
@@ -0,0 +1,37 @@
+package audio
+
+// Fake is a Backend that makes no sound. Use it for headless runs and tests.
+type Fake struct {
+	Record bool     // log voices to Played; off, so long headless runs don't grow it
+	Played []string // voice names, in the order Play was called, while Record is on
+}
+
+// Sound returns a silent voice.
+func (f *Fake) Sound(name string, pcm []byte) (Voice, error) {
+	return &FakeVoice{Name: name, fake: f}, nil
+}
+
+// Loop returns a silent voice.
+func (f *Fake) Loop(name string, pcm []byte) (Voice, error) {
+	return &FakeVoice{Name: name, fake: f}, nil
+}
+
+// FakeVoice is a silent voice created by Fake.
+type FakeVoice struct {
+	Name    string
+	Volume  float64
+	playing bool
+	fake    *Fake
+}
+
+func (v *FakeVoice) Play() {
+	v.playing = true
+	if v.fake.Record {
+		v.fake.Played = append(v.fake.Played, v.Name)
+	}
+}
+
+func (v *FakeVoice) Pause()              { v.playing = false }
+func (v *FakeVoice) Rewind() error       { return nil }
+func (v *FakeVoice) SetVolume(x float64) { v.Volume = x }
+func (v *FakeVoice) IsPlaying() bool     { return v.playing }
@@ -0,0 +1,187 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// SampleRate is the rate of all synthesized audio. Samples are 16-bit little-endian stereo.
+const SampleRate = 44100
+
+const bytesPerFrame = 4
+
+// render samples f (returning -1..1) for the given duration into stereo PCM.
+func render(seconds float64, f func(t float64) float64) []byte {
+	n := int(seconds * SampleRate)
+	buf := make([]byte, n*bytesPerFrame)
+	for i := 0; i < n; i++ {
+		v := f(float64(i) / SampleRate)
+		if v > 1 {
+			v = 1
+		} else if v < -1 {
+			v = -1
+		}
+		s := uint16(int16(v * math.MaxInt16))
+		binary.LittleEndian.PutUint16(buf[i*4:], s)
+		binary.LittleEndian.PutUint16(buf[i*4+2:], s)
+	}
+	return buf
+}
+
+func square(phase float64) float64 {
+	if math.Mod(phase, 1) < 0.5 {
+		return 1
+	}
+	return -1
+}
+
+func sine(phase float64) float64 { return math.Sin(2 * math.Pi * phase) }
+
+func triangle(phase float64) float64 {
+	p := math.Mod(phase, 1)
+	return 4*math.Abs(p-0.5) - 1
+}
+
+// noise is a deterministic white noise source so sounds are the same every run.
+type noise struct{ state uint32 }
+
+func (n *noise) next() float64 {
+	n.state = n.state*1664525 + 1013904223
+	return float64(n.state>>8)/float64(1<<23) - 1
+}
+
+// sweep renders a wave whose frequency glides from f0 to f1, fading out linearly.
+func sweep(seconds, f0, f1, gain float64, wave func(float64) float64) []byte {
+	phase := 0.0
+	return render(seconds, func(t float64) float64 {
+		k := t / seconds
+		phase += (f0 + (f1-f0)*k) / SampleRate
+		return wave(phase) * gain * (1 - k)
+	})
+}
+
+func jumpSound() []byte   { return sweep(0.15, 320, 640, 0.25, square) }
+func deathSound() []byte  { return sweep(0.5, 660, 110, 0.3, square) }
+func shapeSound() []byte  { return sweep(0.07, 880, 1320, 0.35, sine) }
+func launchSound() []byte { return sweep(0.25, 180, 960, 0.3, sine) }
+func grabSound() []byte   { return sweep(0.05, 500, 350, 0.25, triangle) }
+func appearSound() []byte { return sweep(0.3, 220, 880, 0.2, triangle) }
+
+func landSound() []byte {
+	n := noise{state: 1}
+	lp := 0.0
+	return render(0.08, func(t float64) float64 {
+		lp += (n.next() - lp) * 0.15 // one-pole low-pass for a dull thump
+		return lp * 1.5 * math.Exp(-t*40)
+	})
+}
+
+func breakSound() []byte {
+	n := noise{state: 7}
+	lp := 0.0
+	return render(0.25, func(t float64) float64 {
+		lp += (n.next() - lp) * 0.4 // brighter than a landing, and it rattles
+		return lp * 1.2 * math.Exp(-t*14) * (0.6 + 0.4*math.Abs(math.Sin(t*90)))
+	})
+}
+
+func splashSound() []byte {
+	n := noise{state: 11}
+	lp := 0.0
+	return render(0.4, func(t float64) float64 {
+		lp += (n.next() - lp) * (0.5 - t) // a hiss that gets duller as it fades
+		return lp * 0.9 * math.Exp(-t*8)
+	})
+}
+
+// iceSound is a glassy ping.
+func iceSound() []byte {
+	return render(0.18, func(t float64) float64 {
+		return (sine(t*2600) + 0.5*sine(t*3900)) * 0.2 * math.Exp(-t*25)
+	})
+}
+
+// conveyorSound is a metal clank with a rattle of rollers.
+func conveyorSound() []byte {
+	n := noise{state: 13}
+	return render(0.15, func(t float64) float64 {
+		return (square(t*190)*0.5 + n.next()*0.3*math.Abs(math.Sin(t*300))) * 0.4 * math.Exp(-t*22)
+	})
+}
+
+// stickySound is a squelch: a dull thump that wobbles down.
+func stickySound() []byte {
+	n := noise{state: 17}
+	lp := 0.0
+	return render(0.2, func(t float64) float64 {
+		lp += (n.next() - lp) * 0.08
+		return (lp*1.5 + sine(t*(160-300*t))*0.3) * math.Exp(-t*15)
+	})
+}
+
+// triggerSound is two quick clicks, like a latch.
+func triggerSound() []byte {
+	return render(0.12, func(t float64) float64 {
+		click := math.Mod(t, 0.06)
+		return square(t*1200) * 0.25 * math.Exp(-click*120)
+	})
+}
+
+func goalSound() []byte {
+	notes := []float64{523.25, 659.25, 783.99, 1046.5}
+	const step = 0.1
+	phase := 0.0
+	return render(step*float64(len(notes)-1)+0.3, func(t float64) float64 {
+		i := int(t / step)
+		if i >= len(notes) {
+			i = len(notes) - 1
+		}
+		local := t - float64(i)*step
+		phase += notes[i] / SampleRate
+		return (0.6*sine(phase) + 0.2*square(phase)) * 0.4 * math.Exp(-local*6)
+	})
+}
+
+// track describes a looping tune: a 16-step melody and one bass root per 4 steps,
+// as semitones from root. rest marks a silent melody step.
+type track struct {
+	bpm    float64
+	root   float64
+	melody [16]int
+	bass   [4]int
+}
+
+const rest = 99
+
+var tracks = []track{
+	{bpm: 120, root: 261.63, // bright C major
+		melody: [16]int{0, 4, 7, 12, 7, 4, 0, 4, 5, 9, 12, 9, 7, 4, 2, rest},
+		bass:   [4]int{0, 0, 5, 7}},
+	{bpm: 110, root: 220.00, // A minor
+		melody: [16]int{0, 3, 7, 10, 12, 10, 7, 3, 5, 8, 12, 8, 7, 3, 2, 3},
+		bass:   [4]int{0, -4, -2, -5}},
+	{bpm: 140, root: 293.66, // tense D dorian
+		melody: [16]int{0, 2, 3, 7, 9, 7, 3, 2, 0, 3, 5, 10, 9, 5, rest, 2},
+		bass:   [4]int{0, 0, -2, -2}},
+}
+
+func semitone(root float64, n int) float64 { return root * math.Pow(2, float64(n)/12) }
+
+// music renders two passes of tr's pattern, which loops seamlessly.
+func music(tr track) []byte {
+	stepLen := 60 / tr.bpm / 2 // eighth notes
+	const steps = 32
+	var mphase, bphase float64
+	return render(stepLen*steps, func(t float64) float64 {
+		step := int(t/stepLen) % steps
+		local := math.Mod(t, stepLen)
+		v := 0.0
+		if n := tr.melody[step%16]; n != rest {
+			mphase += semitone(tr.root, n) / SampleRate
+			v += 0.18 * square(mphase) * math.Exp(-local*5)
+		}
+		bphase += semitone(tr.root/4, tr.bass[step%16/4]) / SampleRate
+		v += 0.3 * triangle(bphase)
+		return v
+	})
+}
@@ -0,0 +1,443 @@
+// Package bot finds inputs that beat a level by searching the deterministic simulation.
+package bot
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"slices"
+
+	"platform-game-one/internal/input"
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/player"
+)
+
+const (
+	// DefaultMaxStates bounds the search when Options.MaxStates is 0.
+	DefaultMaxStates = 3_000_000
+
+	dt = 1.0 / 60.0 // the game's unassisted tick
+
+	// States closer than this are treated as the same.
+	cellX  = 2  // pixels
+	cellY  = 2  // pixels
+	cellVY = 20 // pixels/second
+	cellT  = 6  // ticks, for block and trigger timers
+)
+
+// actions are the button combinations tried each tick. Shape is left out: it
+// doesn't change the physics, Down only matters with pound blocks to break, levers
+// to pull or ladders to climb down, and Up only with ladders and vines.
+var actions = []input.Buttons{
+	0,
+	input.Left,
+	input.Right,
+	input.Jump,
+	input.Left | input.Jump,
+	input.Right | input.Jump,
+}
+
+// ErrNoRoute is returned when the search runs out of states without reaching the goal.
+var ErrNoRoute = errors.New("no route to the goal")
+
+// Options configures Solve.
+type Options struct {
+	MaxStates int           // give up after searching this many states; 0 means DefaultMaxStates
+	Held      input.Buttons // buttons held on the tick before the level starts
+}
+
+// Result is a route from the start to the goal.
+type Result struct {
+	Frames   []input.Buttons // buttons held each tick, ready for input.Replay
+	Searched int             // states taken off the queue, over all passes
+	Optimal  bool            // no faster route exists on the search grid
+}
+
+// Ticks returns how long the route takes.
+func (r *Result) Ticks() int { return len(r.Frames) }
+
+// Seconds returns how long the route takes in seconds.
+func (r *Result) Seconds() float64 { return float64(len(r.Frames)) * dt }
+
+// state is the part of the player that carries over between ticks. VX is worked out
+// from the input, run and carry every tick, and everything else only affects drawing.
+type state struct {
+	x, y, vy   float64
+	coyote     float64
+	jumpBuffer float64
+	grounded   bool
+	slope      float64 // grade underfoot, which scales the next tick's speed
+	ground     level.Material
+	run        float64 // walking speed, which carries over on ice
+	carry      float64 // sideways velocity from a cannon
+	drift      float64 // and from wind
+	padTime    float64
+	pounding   bool
+	climbing   bool
+	grabTime   float64
+	held       input.Buttons // buttons held on the tick before
+	live       *level.Live   // if the level has blocks or triggers; shared, so never changed
+}
+
+// key is a state rounded onto the search grid.
+type key struct {
+	x, y, vy, carry  int32
+	run              int32
+	ground           string
+	drift            int32
+	coyote, buffered bool
+	padReady         bool
+	grounded         bool
+	pounding         bool
+	climbing         bool
+	grabReady        bool
+	held             input.Buttons
+	live             string // each block's and trigger's state, one byte each
+}
+
+func (s *state) key() key {
+	run := 0.0
+	if s.ground.Kind == level.MaterialIce {
+		run = s.run // anywhere else the input sets it afresh
+	}
+	return key{
+		x:         int32(s.x / cellX),
+		y:         int32(s.y / cellY),
+		vy:        int32(s.vy / cellVY),
+		carry:     int32(s.carry / cellVY),
+		run:       int32(run / cellVY),
+		ground:    s.ground.Kind,
+		drift:     int32(s.drift / cellVY),
+		coyote:    s.coyote > 0,
+		padReady:  s.padTime == 0,
+		buffered:  s.jumpBuffer > 0,
+		grounded:  s.grounded,
+		pounding:  s.pounding,
+		climbing:  s.climbing,
+		grabReady: s.grabTime == 0,
+		held:      s.held & (input.Jump | input.Down), // only these change what the next tick can do
+		live:      liveKey(s.live),
+	}
+}
+
+// liveKey packs the blocks' and triggers' states into a string. A block is 0 for
+// whole, 1 for broken, and from 2 up the time left crumbling in cellT ticks; how long
+// a broken block has left is ignored, as the route rarely comes back to it. A
+// trigger is 0 for off, 1 for on for good, and from 2 up the time it has left on.
+func liveKey(live *level.Live) string {
+	if live == nil {
+		return ""
+	}
+	ticks := func(t float64) byte { return 2 + byte(min(250, t/(cellT*dt))) }
+	k := make([]byte, 0, len(live.Blocks)+len(live.Triggers))
+	for _, b := range live.Blocks {
+		switch {
+		case b.Crumbling:
+			k = append(k, ticks(b.Timer))
+		case b.Broken:
+			k = append(k, 1)
+		default:
+			k = append(k, 0)
+		}
+	}
+	for _, t := range live.Triggers {
+		switch {
+		case t.On && t.Timer > 0:
+			k = append(k, ticks(t.Timer))
+		case t.On:
+			k = append(k, 1)
+		default:
+			k = append(k, 0)
+		}
+	}
+	return string(k)
+}
+
+// node is a searched state. Nodes live in one slice and link to their parent by index.
+type node struct {
+	s      state
+	g      int32 // ticks from the start
+	parent int32
+	held   input.Buttons // buttons held on the tick into this state
+	goal   bool
+}
+
+// weights scale the heuristic in successive passes. The early, greedy passes find
+// a route quickly; each later pass only looks for routes faster than the best so
+// far, and the last one is plain A*, which makes the route it ends with the fastest.
+var weights = []int32{4, 2, 1}
+
+// errBudget is returned by search when it searches MaxStates states.
+var errBudget = errors.New("search budget used up")
+
+// Solve searches for the fastest inputs that take the player from lv's start to its
+// goal without assists. It is A* over ticks with the horizontal distance to the goal
+// at full speed as the heuristic. If the last pass runs out of states, the best route
+// found so far is returned with Optimal false.
+func Solve(lv *level.Level, opts Options) (*Result, error) {
+	maxStates := opts.MaxStates
+	if maxStates == 0 {
+		maxStates = DefaultMaxStates
+	}
+	var best *Result
+	searched := 0
+	for i, w := range weights {
+		bound := int32(math.MaxInt32)
+		if best != nil {
+			bound = int32(best.Ticks())
+		}
+		frames, n, err := search(lv, opts.Held, w, bound, maxStates)
+		searched += n
+		last := i == len(weights)-1
+		switch {
+		case err == nil:
+			best = &Result{Frames: frames, Optimal: last}
+		case errors.Is(err, ErrNoRoute) && best == nil:
+			// every reachable state was searched
+			return nil, fmt.Errorf("%w after searching %d states", ErrNoRoute, searched)
+		case errors.Is(err, ErrNoRoute):
+			best.Optimal = true // nothing is faster
+		}
+		if best != nil && best.Optimal {
+			break
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("%w in %d states", ErrNoRoute, searched)
+	}
+	best.Searched = searched
+	return best, nil
+}
+
+// search runs A* with the heuristic scaled by w, looking only for routes faster than
+// bound ticks. prev is held on the tick before the start. It returns the route and
+// how many states it searched.
+func search(lv *level.Level, prev input.Buttons, w, bound int32, maxStates int) ([]input.Buttons, int, error) {
+	// step swaps each state's blocks and triggers into the level; put the level's
+	// own back after
+	defer func(live level.Live) { lv.Live = live }(lv.Live)
+	var live *level.Live
+	if len(lv.Blocks) > 0 || len(lv.Triggers) > 0 {
+		lv.Reset()
+		fresh := lv.Live
+		live = &fresh
+	}
+	tried := actions
+	if slices.ContainsFunc(lv.Blocks, func(b level.Block) bool { return b.Kind == level.BlockPound }) ||
+		slices.ContainsFunc(lv.Triggers, func(t level.Trigger) bool { return t.Kind == level.TriggerLever }) {
+		tried = append(slices.Clip(actions), input.Down)
+	}
+	if len(lv.Climbables) > 0 {
+		tried = append(slices.Clip(tried), input.Up|input.Jump, input.Left|input.Up|input.Jump, input.Right|input.Up|input.Jump)
+		if !slices.Contains(tried, input.Down) {
+			tried = append(tried, input.Down)
+		}
+	}
+
+	start := player.New(lv.StartX, lv.StartY)
+	nodes := []node{{s: state{x: start.X, y: start.Y, held: prev, live: live}, parent: -1}}
+	best := map[key]int32{nodes[0].s.key(): 0}
+	q := &queue{}
+	heap.Push(q, item{node: 0, f: w * h(lv, &nodes[0].s)})
+
+	searched := 0
+	for ; q.Len() > 0; searched++ {
+		if searched >= maxStates {
+			return nil, searched, errBudget
+		}
+		it := heap.Pop(q).(item)
+		n := nodes[it.node]
+		if n.goal {
+			return route(nodes, it.node), searched, nil
+		}
+		if best[n.s.key()] < n.g {
+			continue // reached more quickly some other way
+		}
+		for _, held := range tried {
+			s, ok := step(lv, n.s, held)
+			if !ok {
+				continue
+			}
+			nodes = append(nodes, node{s: s.state, g: n.g + 1, parent: it.node, held: held, goal: s.goal})
+			id := int32(len(nodes) - 1)
+			if s.goal {
+				if n.g+1 < bound {
+					heap.Push(q, item{node: id, f: n.g + 1, g: n.g + 1})
+				}
+				continue
+			}
+			est := h(lv, &s.state)
+			if n.g+1+est >= bound {
+				nodes = nodes[:id]
+				continue // can't beat the best route so far
+			}
+			k := s.state.key()
+			if g, seen := best[k]; seen && g <= n.g+1 {
+				nodes = nodes[:id]
+				continue
+			}
+			best[k] = n.g + 1
+			heap.Push(q, item{node: id, f: n.g + 1 + w*est, g: n.g + 1})
+		}
+	}
+	return nil, searched, ErrNoRoute
+}
+
+// stepped is a state after one tick, and whether the player reached the goal.
+type stepped struct {
+	state
+	goal bool
+}
+
+// step runs one game tick from s holding held. ok is false if the player fell out.
+func step(lv *level.Level, s state, held input.Buttons) (next stepped, ok bool) {
+	if s.live != nil {
+		lv.Live = s.live.Clone()
+	}
+	p := player.Player{
+		X: s.x, Y: s.y, VY: s.vy,
+		Grounded:   s.grounded,
+		Slope:      s.slope,
+		Ground:     s.ground,
+		Run:        s.run,
+		Carry:      s.carry,
+		Drift:      s.drift,
+		PadTime:    s.padTime,
+		Pounding:   s.pounding,
+		Climbing:   s.climbing,
+		GrabTime:   s.grabTime,
+		CoyoteTime: s.coyote,
+		JumpBuffer: s.jumpBuffer,
+	}
+	p.Step(dt, input.Next(s.held, held), lv)
+	live := s.live // most ticks change nothing, so keep sharing the last copy
+	if live != nil {
+		lv.Update(dt, []image.Rectangle{p.Rect()})
+		if !lv.Live.Equal(*live) {
+			changed := lv.Live
+			live = &changed
+		}
+	}
+	if p.Y > lv.DeathY {
+		return stepped{}, false
+	}
+	return stepped{
+		state: state{
+			x: p.X, y: p.Y, vy: p.VY,
+			coyote:     p.CoyoteTime,
+			jumpBuffer: p.JumpBuffer,
+			grounded:   p.Grounded,
+			slope:      p.Slope,
+			ground:     p.Ground,
+			run:        p.Run,
+			carry:      p.Carry,
+			drift:      p.Drift,
+			padTime:    p.PadTime,
+			pounding:   p.Pounding,
+			climbing:   p.Climbing,
+			grabTime:   p.GrabTime,
+			held:       held,
+			live:       live,
+		},
+		goal: lv.InGoal(p.Rect()),
+	}, true
+}
+
+// h is the fewest ticks the goal could be from s: the horizontal distance at top
+// speed, or the height up to it at the fastest the player rises, whichever is more.
+func h(lv *level.Level, s *state) int32 {
+	var dx float64
+	switch {
+	case s.x+player.Width <= float64(lv.Goal.Min.X):
+		dx = float64(lv.Goal.Min.X) - (s.x + player.Width)
+	case s.x >= float64(lv.Goal.Max.X):
+		dx = s.x - float64(lv.Goal.Max.X)
+	}
+	ticks := dx / (topSpeed(lv) * dt)
+	if dy := s.y - float64(lv.Goal.Max.Y); dy > 0 {
+		if rise := topRise(lv); rise > 0 {
+			ticks = math.Max(ticks, dy/(rise*dt))
+		}
+	}
+	return int32(ticks)
+}
+
+// topRise is the fastest the player moves up on lv: jumping, swimming, climbing or
+// off its strongest spring. It's 0 if there's no limit, as bounce pads throw the
+// player back up as fast as they fell and wind can blow them up ever faster.
+func topRise(lv *level.Level) float64 {
+	rise := math.Max(-player.JumpVelocity, math.Max(-player.SwimVelocity, player.ClimbSpeed))
+	for _, p := range lv.Pads {
+		if p.Kind == level.PadBounce {
+			return 0
+		}
+		rise = math.Max(rise, -p.VY)
+	}
+	for _, z := range lv.Zones {
+		if z.WindY < 0 {
+			return 0
+		}
+	}
+	return rise
+}
+
+// topSpeed is the fastest the player moves sideways on lv: rolling down its steepest
+// slope, or flying from its fastest cannon, with all its wind behind and on its
+// fastest conveyor.
+func topSpeed(lv *level.Level) float64 {
+	grade, carry, wind, belt := 0.0, 0.0, 0.0, 0.0
+	for _, sl := range lv.Slopes {
+		grade = math.Max(grade, math.Min(math.Abs(sl.Grade()), player.MaxSnapGrade))
+	}
+	for _, p := range lv.Pads {
+		carry = math.Max(carry, math.Abs(p.VX))
+	}
+	for _, z := range lv.Zones {
+		wind += math.Abs(z.WindX) / player.WindDrag // zones add up where they overlap
+	}
+	for _, m := range lv.Materials {
+		belt = math.Max(belt, math.Abs(m.Speed))
+	}
+	return math.Max(player.MoveSpeed*(1+player.RollBoost*grade), player.MoveSpeed+carry) + wind + belt
+}
+
+// route follows parents back from id and returns the buttons held on each tick.
+func route(nodes []node, id int32) []input.Buttons {
+	var frames []input.Buttons
+	for ; nodes[id].parent >= 0; id = nodes[id].parent {
+		frames = append(frames, nodes[id].held)
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return frames
+}
+
+// item is a queued node; f is its estimated total ticks.
+type item struct {
+	node int32
+	f, g int32
+}
+
+// queue is a min-heap on f. Ties go to the deeper node, which reaches the goal sooner.
+type queue struct {
+	items []item
+}
+
+func (q *queue) Len() int { return len(q.items) }
+func (q *queue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	if a.f != b.f {
+		return a.f < b.f
+	}
+	return a.g > b.g
+}
+func (q *queue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *queue) Push(x any)    { q.items = append(q.items, x.(item)) }
+func (q *queue) Pop() any {
+	it := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return it
+}
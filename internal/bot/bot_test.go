@@ -0,0 +1,48 @@
+package bot_test
+
+import (
+	"testing"
+
+	"platform-game-one/internal/bot"
+	"platform-game-one/internal/input"
+	"platform-game-one/internal/leaderboard"
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/pack"
+)
+
+// TestBuiltInLevelsAreBeatable solves every level of every built-in pack, so a
+// physics or level change that makes one impossible fails here. The leaderboard
+// must accept each solution in the same time, or it isn't playing levels the way
+// the bot and the game do.
+func TestBuiltInLevelsAreBeatable(t *testing.T) {
+	if testing.Short() {
+		t.Skip("solving every level takes minutes")
+	}
+	packs, errs := pack.Discover("")
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+	for _, p := range packs.Packs {
+		for n := 1; n <= p.Len(); n++ {
+			lv, err := p.Level(n, level.ScreenWidth, level.ScreenHeight)
+			if err != nil {
+				t.Fatalf("%s level %d: %v", p.ID, n, err)
+			}
+			t.Run(p.ID+"/"+lv.Name, func(t *testing.T) {
+				res, err := bot.Solve(lv, bot.Options{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Logf("%.2fs, %d states searched", res.Seconds(), res.Searched)
+				run := &input.Replay{Pack: p.ID, Level: n, Frames: res.Frames}
+				ticks, err := leaderboard.Verify(run, packs, level.ScreenWidth, level.ScreenHeight)
+				if err != nil {
+					t.Fatalf("leaderboard rejected the solution: %v", err)
+				}
+				if ticks != res.Ticks() {
+					t.Errorf("leaderboard timed the solution at %d ticks, the bot at %d", ticks, res.Ticks())
+				}
+			})
+		}
+	}
+}
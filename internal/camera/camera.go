@@ -1,40 +1,99 @@
 package camera
 
+import (
+	"image"
+	"math"
+)
+
+// Framing several targets zooms out no further than MinZoom, keeping FramePad
+// pixels of world around them.
+const (
+	MinZoom  = 0.6
+	FramePad = 160
+)
+
 // Camera holds the top-left position of the visible window in world coordinates.
 type Camera struct {
 	X, Y float64
+	Zoom float64 // screen pixels per world pixel; below 1 shows more of the level
+
+	// TargetX, TargetY is the world point the camera is easing toward centering,
+	// moved off the target by the level's edges.
+	TargetX, TargetY float64
 }
 
 // New creates a camera at (0,0).
 func New() *Camera {
-	return &Camera{}
+	return &Camera{Zoom: 1}
+}
+
+// View returns the size of the visible window in world pixels.
+func (c *Camera) View(screenW, screenH int) (w, h int) {
+	return int(math.Ceil(float64(screenW) / c.Zoom)), int(math.Ceil(float64(screenH) / c.Zoom))
+}
+
+// Frame zooms and moves the camera to keep every rect in view, zooming out no further
+// than MinZoom. lead is the rect to keep in view when they don't all fit.
+func (c *Camera) Frame(rects []image.Rectangle, lead int, levelW, levelH, screenW, screenH int) {
+	var box image.Rectangle
+	for i, r := range rects {
+		if i == 0 {
+			box = r
+		} else {
+			box = box.Union(r)
+		}
+	}
+	box = box.Inset(-FramePad)
+	want := math.Min(float64(screenW)/float64(box.Dx()), float64(screenH)/float64(box.Dy()))
+	want = math.Max(MinZoom, math.Min(1, want))
+	const zoomSpeed = 0.05
+	c.Zoom += (want - c.Zoom) * zoomSpeed
+
+	viewW, viewH := c.View(screenW, screenH)
+	cx := float64(box.Min.X+box.Max.X) / 2
+	cy := float64(box.Min.Y+box.Max.Y) / 2
+	// if they don't fit, slide toward the lead so it stays in view
+	l := rects[lead].Inset(-FramePad)
+	if half := float64(viewW) / 2; box.Dx() > viewW {
+		cx = math.Max(float64(l.Max.X)-half, math.Min(cx, float64(l.Min.X)+half))
+	}
+	if half := float64(viewH) / 2; box.Dy() > viewH {
+		cy = math.Max(float64(l.Max.Y)-half, math.Min(cy, float64(l.Min.Y)+half))
+	}
+	c.Update(cx, cy, levelW, levelH, viewW, viewH)
 }
 
 // Update moves the camera toward the target (e.g. player center) and clamps to level bounds.
 // targetX, targetY is the world position to center on (e.g. player center).
 func (c *Camera) Update(targetX, targetY float64, levelW, levelH, screenW, screenH int) {
-	// Center the target on screen
-	goalX := targetX - float64(screenW)/2
-	goalY := targetY - float64(screenH)/2
+	// Center the target on screen, as far as the level's edges allow
+	goalX, goalY := clamp(targetX-float64(screenW)/2, targetY-float64(screenH)/2, levelW, levelH, screenW, screenH)
+	c.TargetX, c.TargetY = goalX+float64(screenW)/2, goalY+float64(screenH)/2
 	// Smooth follow (lerp)
 	const speed = 0.12
 	c.X += (goalX - c.X) * speed
 	c.Y += (goalY - c.Y) * speed
-	// Clamp to level
-	if c.X < 0 {
-		c.X = 0
+	// a zoom change can leave the camera outside the level
+	c.X, c.Y = clamp(c.X, c.Y, levelW, levelH, screenW, screenH)
+}
+
+// clamp keeps a camera at x, y showing screenW by screenH of the level.
+func clamp(x, y float64, levelW, levelH, screenW, screenH int) (float64, float64) {
+	if x < 0 {
+		x = 0
 	}
-	if c.Y < 0 {
-		c.Y = 0
+	if y < 0 {
+		y = 0
 	}
 	maxX := float64(levelW - screenW)
-	if maxX > 0 && c.X > maxX {
-		c.X = maxX
+	if maxX > 0 && x > maxX {
+		x = maxX
 	}
 	maxY := float64(levelH - screenH)
-	if maxY > 0 && c.Y > maxY {
-		c.Y = maxY
+	if maxY > 0 && y > maxY {
+		y = maxY
 	}
+	return x, y
 }
 
 // WorldToScreen converts world coordinates to screen coordinates.
@@ -0,0 +1,192 @@
+package debug
+
+import (
+	"cmp"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+
+	"platform-game-one/internal/camera"
+	"platform-game-one/internal/input"
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/player"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	historyLen = 120 // samples kept for the FPS/TPS graphs
+	arcTicks   = 120 // how far ahead the jump arc is predicted
+	shownMoves = 8   // player state transitions listed under the graphs
+	graphW     = historyLen * 2
+	graphH     = 48
+)
+
+var (
+	platformColor = color.RGBA{R: 0x40, G: 0xff, B: 0x80, A: 0xff}
+	goalColor     = color.RGBA{R: 0xff, G: 0xe0, B: 0x40, A: 0xff}
+	colliderColor = color.RGBA{R: 0xff, G: 0x40, B: 0x40, A: 0xff}
+	velocityColor = color.RGBA{R: 0x40, G: 0xc0, B: 0xff, A: 0xff}
+	arcColor      = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xa0}
+	cameraColor   = color.RGBA{R: 0xff, G: 0x80, B: 0xff, A: 0x80}
+	zoneColor     = color.RGBA{R: 0x80, G: 0xa0, B: 0xff, A: 0xa0}
+	climbColor    = color.RGBA{R: 0xc0, G: 0x90, B: 0x50, A: 0xa0}
+	materialColor = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xa0}
+	graphBG       = color.RGBA{A: 0xa0}
+	fpsColor      = color.RGBA{R: 0x40, G: 0xff, B: 0x40, A: 0xff}
+	tpsColor      = color.RGBA{R: 0xff, G: 0xa0, B: 0x40, A: 0xff}
+)
+
+// Overlay draws collision, physics and timing information over the game.
+// It is off unless enabled with the -debug flag or toggled at runtime.
+type Overlay struct {
+	Enabled bool
+	fps     [historyLen]float64
+	tps     [historyLen]float64
+	head    int
+	arc     []player.Point
+}
+
+// New creates an overlay.
+func New(enabled bool) *Overlay {
+	return &Overlay{Enabled: enabled}
+}
+
+// Toggle switches the overlay on or off.
+func (o *Overlay) Toggle() { o.Enabled = !o.Enabled }
+
+// Update samples timing and predicts the jump arc. Call once per tick after the player moves.
+func (o *Overlay) Update(p *player.Player, lv *level.Level, dt float64, held input.Buttons) {
+	if !o.Enabled {
+		return
+	}
+	o.fps[o.head] = ebiten.ActualFPS()
+	o.tps[o.head] = ebiten.ActualTPS()
+	o.head = (o.head + 1) % historyLen
+	o.arc = p.PredictJump(lv, dt, held, arcTicks)
+}
+
+// Draw renders the overlay. screenW and screenH are the logical screen size.
+func (o *Overlay) Draw(screen *ebiten.Image, cam *camera.Camera, lv *level.Level, p *player.Player, screenW, screenH int) {
+	if !o.Enabled {
+		return
+	}
+
+	for _, plat := range lv.Platforms {
+		strokeWorldRect(screen, cam, plat, platformColor)
+	}
+	for _, sl := range lv.Slopes {
+		c := sl.Corners()
+		for i := range c {
+			x0, y0 := cam.WorldToScreen(float64(c[i].X), float64(c[i].Y))
+			x1, y1 := cam.WorldToScreen(float64(c[(i+1)%3].X), float64(c[(i+1)%3].Y))
+			vector.StrokeLine(screen, float32(x0), float32(y0), float32(x1), float32(y1), 1, platformColor, false)
+		}
+	}
+	for _, pad := range lv.Pads {
+		strokeWorldRect(screen, cam, pad.Rect, platformColor)
+	}
+	for i, b := range lv.Blocks {
+		if lv.BlockSolid(i) {
+			strokeWorldRect(screen, cam, b.Rect, platformColor)
+		}
+	}
+	for i, g := range lv.Gates {
+		if lv.GateSolid(i) {
+			for _, r := range g.Rects {
+				strokeWorldRect(screen, cam, r, platformColor)
+			}
+		}
+	}
+	for _, z := range lv.Zones {
+		strokeWorldRect(screen, cam, z.Rect, zoneColor)
+	}
+	for _, c := range lv.Climbables {
+		strokeWorldRect(screen, cam, c.Rect, climbColor)
+	}
+	for _, m := range lv.Materials {
+		strokeWorldRect(screen, cam, m.Rect, materialColor)
+	}
+	strokeWorldRect(screen, cam, lv.Goal, goalColor)
+	strokeWorldRect(screen, cam, p.Rect(), colliderColor)
+
+	// Velocity vector, scaled so MoveSpeed is about 3 player widths
+	cx, cy := cam.WorldToScreen(p.CenterX(), p.CenterY())
+	const velScale = 0.3
+	vector.StrokeLine(screen, float32(cx), float32(cy),
+		float32(float64(cx)+p.VX*velScale), float32(float64(cy)+p.VY*velScale), 2, velocityColor, true)
+
+	// Predicted jump arc
+	for i := 1; i < len(o.arc); i++ {
+		x0, y0 := cam.WorldToScreen(o.arc[i-1].X, o.arc[i-1].Y)
+		x1, y1 := cam.WorldToScreen(o.arc[i].X, o.arc[i].Y)
+		vector.StrokeLine(screen, float32(x0), float32(y0), float32(x1), float32(y1), 1, arcColor, true)
+	}
+
+	// Where the camera is easing toward centering: the player, or the nearest point
+	// it can center without showing past the level's edges
+	const mark = 12
+	tx, ty := cam.WorldToScreen(cam.TargetX, cam.TargetY)
+	mx, my := float32(tx), float32(ty)
+	vector.StrokeLine(screen, mx-mark, my, mx+mark, my, 1, cameraColor, false)
+	vector.StrokeLine(screen, mx, my-mark, mx, my+mark, 1, cameraColor, false)
+
+	// State and timers
+	x := screenW - graphW - 16
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf(
+		"pos %7.1f,%7.1f\nvel %7.1f,%7.1f\ngrounded %v\ncoyote %.3f\njump buf %.3f\nslope %.2f ground %s run %.1f\ncarry %.1f drift %.1f\npounding %v climbing %v\nwater %.2f gravity %.2f\nstate %s %.2fs",
+		p.X, p.Y, p.VX, p.VY, p.Grounded, p.CoyoteTime, p.JumpBuffer, p.Slope, cmp.Or(p.Ground.Kind, "plain"), p.Run, p.Carry, p.Drift, p.Pounding, p.Climbing,
+		p.Physics.Water, p.Physics.Gravity, p.State(), p.StateTime()), x, 16)
+	drawTimerBar(screen, x+120, 16+3*16+4, p.CoyoteTime/player.CoyoteTimeMax, fpsColor)
+	drawTimerBar(screen, x+120, 16+4*16+4, p.JumpBuffer/player.JumpBufferMax, tpsColor)
+
+	// FPS/TPS graphs
+	gy := 16 + 10*16
+	vector.FillRect(screen, float32(x), float32(gy), graphW, graphH, graphBG, false)
+	o.drawGraph(screen, x, gy, o.fps[:], fpsColor)
+	o.drawGraph(screen, x, gy, o.tps[:], tpsColor)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("FPS %.1f  TPS %.1f", ebiten.ActualFPS(), ebiten.ActualTPS()), x, gy+graphH)
+
+	// Latest state transitions, newest first
+	h := p.History()
+	var moves strings.Builder
+	for i := len(h) - 1; i >= max(len(h)-shownMoves, 0); i-- {
+		fmt.Fprintf(&moves, "%8.2f %s > %s\n", h[i].At, h[i].From, h[i].To)
+	}
+	ebitenutil.DebugPrintAt(screen, moves.String(), x, gy+graphH+2*16)
+}
+
+// drawGraph plots samples oldest to newest; the graph's top is 2x the target TPS.
+func (o *Overlay) drawGraph(screen *ebiten.Image, x, y int, samples []float64, clr color.Color) {
+	top := 2 * float64(ebiten.TPS())
+	px, py := float32(0), float32(0)
+	for i := 0; i < historyLen; i++ {
+		v := samples[(o.head+i)%historyLen]
+		if v > top {
+			v = top
+		}
+		sx := float32(x + i*graphW/historyLen)
+		sy := float32(float64(y+graphH) - v/top*graphH)
+		if i > 0 {
+			vector.StrokeLine(screen, px, py, sx, sy, 1, clr, false)
+		}
+		px, py = sx, sy
+	}
+}
+
+func drawTimerBar(screen *ebiten.Image, x, y int, frac float64, clr color.Color) {
+	const w, h = 100, 8
+	if frac < 0 {
+		frac = 0
+	}
+	vector.StrokeRect(screen, float32(x), float32(y), w, h, 1, clr, false)
+	vector.FillRect(screen, float32(x), float32(y), float32(w*frac), h, clr, false)
+}
+
+func strokeWorldRect(screen *ebiten.Image, cam *camera.Camera, r image.Rectangle, clr color.Color) {
+	sx, sy := cam.WorldToScreen(float64(r.Min.X), float64(r.Min.Y))
+	vector.StrokeRect(screen, float32(sx), float32(sy), float32(r.Dx()), float32(r.Dy()), 1, clr, false)
+}
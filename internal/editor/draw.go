@@ -0,0 +1,209 @@
+package editor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"platform-game-one/internal/camera"
+	"platform-game-one/internal/level"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+var (
+	gridColor        = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0x18}
+	outlineColor     = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0x80}
+	selectColor      = color.RGBA{R: 0xff, G: 0xe0, B: 0x40, A: 0xff}
+	unreachableColor = color.RGBA{R: 0xc0, G: 0x10, B: 0x10, A: 0x60}
+	missColor        = color.RGBA{R: 0xff, G: 0x40, B: 0x40, A: 0xff}
+	startColor       = color.RGBA{R: 0x40, G: 0xff, B: 0x80, A: 0xff}
+	goalColor        = color.RGBA{R: 0xff, G: 0xe0, B: 0x40, A: 0xff}
+	cursorColor      = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xc0}
+	wireColor        = color.RGBA{R: 0x40, G: 0xc0, B: 0xff, A: 0xa0}
+	zoneColor        = color.RGBA{R: 0x80, G: 0xa0, B: 0xff, A: 0x80}
+	climbColor       = color.RGBA{R: 0xc0, G: 0x90, B: 0x50, A: 0x80}
+	materialColor    = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0x80}
+	hudBG            = color.RGBA{A: 0xa0}
+)
+
+const help = "drag: new platform / move / resize   right click, Del: delete   S: start   G: goal\n" +
+	"[ ]: grid   arrows, middle drag: pan   Ctrl+Z / Ctrl+Y: undo / redo   Enter: play from cursor   F2: play   Ctrl+S: save"
+
+// Draw renders the editing overlay on top of the level. screenW and screenH are the
+// logical screen size.
+func (e *Editor) Draw(screen *ebiten.Image, cam *camera.Camera, screenW, screenH int) {
+	lv := e.level
+	if g := e.step(); g >= 8 {
+		e.drawGrid(screen, cam, g, screenW, screenH)
+	}
+
+	for i, p := range lv.Platforms {
+		if !e.reach.Reachable[i] {
+			fillWorldRect(screen, cam, p, unreachableColor)
+		}
+		strokeWorldRect(screen, cam, p, outlineColor, 1)
+	}
+	for i, sl := range lv.Slopes {
+		if !e.reach.Reachable[len(lv.Platforms)+i] {
+			drawWorldSlope(screen, cam, sl, unreachableColor, true)
+		}
+		drawWorldSlope(screen, cam, sl, outlineColor, false)
+	}
+	for i, pad := range lv.Pads {
+		if !e.reach.Reachable[len(lv.Platforms)+len(lv.Slopes)+i] {
+			fillWorldRect(screen, cam, pad.Rect, unreachableColor)
+		}
+		strokeWorldRect(screen, cam, pad.Rect, outlineColor, 1)
+	}
+	for i, b := range lv.Blocks {
+		if !e.reach.Reachable[len(lv.Platforms)+len(lv.Slopes)+len(lv.Pads)+i] {
+			fillWorldRect(screen, cam, b.Rect, unreachableColor)
+		}
+		strokeWorldRect(screen, cam, b.Rect, outlineColor, 1)
+	}
+	n := len(lv.Platforms) + len(lv.Slopes) + len(lv.Pads) + len(lv.Blocks)
+	for _, g := range lv.Gates {
+		for _, r := range g.Rects {
+			if g.Kind == level.GateBridge {
+				if !e.reach.Reachable[n] {
+					fillWorldRect(screen, cam, r, unreachableColor)
+				}
+				n++
+			}
+			strokeWorldRect(screen, cam, r, outlineColor, 1)
+		}
+	}
+	for _, t := range lv.Triggers {
+		if !e.reach.Reachable[n] {
+			fillWorldRect(screen, cam, t.Rect, unreachableColor)
+		}
+		strokeWorldRect(screen, cam, t.Rect, outlineColor, 1)
+		n++
+		// wire each trigger to the gates it works
+		tx, ty := cam.WorldToScreen(float64(t.Rect.Min.X+t.Rect.Max.X)/2, float64(t.Rect.Min.Y+t.Rect.Max.Y)/2)
+		for _, id := range t.Targets {
+			if i := lv.GateIndex(id); i >= 0 {
+				r := lv.Gates[i].Rects[0]
+				gx, gy := cam.WorldToScreen(float64(r.Min.X+r.Max.X)/2, float64(r.Min.Y+r.Max.Y)/2)
+				vector.StrokeLine(screen, float32(tx), float32(ty), float32(gx), float32(gy), 1, wireColor, true)
+			}
+		}
+	}
+	for _, z := range lv.Zones {
+		strokeWorldRect(screen, cam, z.Rect, zoneColor, 1)
+	}
+	for _, c := range lv.Climbables {
+		strokeWorldRect(screen, cam, c.Rect, climbColor, 1)
+	}
+	for _, m := range lv.Materials {
+		strokeWorldRect(screen, cam, m.Rect, materialColor, 1)
+	}
+	if !e.reach.Goal {
+		fillWorldRect(screen, cam, lv.Goal, unreachableColor)
+	}
+	strokeWorldRect(screen, cam, lv.Goal, goalColor, 2)
+	start := image.Rect(int(lv.StartX), int(lv.StartY), int(lv.StartX+e.env.Width), int(lv.StartY+e.env.Height))
+	strokeWorldRect(screen, cam, start, startColor, 2)
+
+	for _, m := range e.reach.Misses {
+		e.drawMiss(screen, cam, m.From, m.To, m.Short, m.High)
+	}
+
+	if e.sel >= 0 {
+		r := lv.Platforms[e.sel]
+		strokeWorldRect(screen, cam, r, selectColor, 2)
+		sx, sy := cam.WorldToScreen(float64(r.Min.X), float64(r.Min.Y))
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("#%d %dx%d", e.sel, r.Dx(), r.Dy()), sx, sy-16)
+	}
+	if e.drag.kind == dragCreate {
+		strokeWorldRect(screen, cam, image.Rectangle{e.drag.anchor, e.cursor}.Canon(), selectColor, 1)
+	}
+
+	cx, cy := cam.WorldToScreen(float64(e.cursor.X), float64(e.cursor.Y))
+	vector.StrokeLine(screen, float32(cx-8), float32(cy), float32(cx+8), float32(cy), 1, cursorColor, false)
+	vector.StrokeLine(screen, float32(cx), float32(cy-8), float32(cx), float32(cy+8), 1, cursorColor, false)
+
+	e.drawHUD(screen, screenW)
+}
+
+func (e *Editor) drawGrid(screen *ebiten.Image, cam *camera.Camera, g, screenW, screenH int) {
+	x0 := -int(cam.X) % g
+	y0 := -int(cam.Y) % g
+	for x := x0; x < screenW; x += g {
+		vector.StrokeLine(screen, float32(x), 0, float32(x), float32(screenH), 1, gridColor, false)
+	}
+	for y := y0; y < screenH; y += g {
+		vector.StrokeLine(screen, 0, float32(y), float32(screenW), float32(y), 1, gridColor, false)
+	}
+}
+
+// drawMiss draws a line from the surface a failed jump starts on to its target.
+func (e *Editor) drawMiss(screen *ebiten.Image, cam *camera.Camera, from, to int, short float64, high bool) {
+	lv := e.level
+	a := lv.Surface(from)
+	target := lv.Goal
+	if to >= 0 {
+		target = lv.Surface(to)
+	}
+	ax, ay := cam.WorldToScreen(float64(a.Min.X+a.Max.X)/2, float64(a.Min.Y))
+	bx, by := cam.WorldToScreen(float64(target.Min.X+target.Max.X)/2, float64(target.Min.Y))
+	vector.StrokeLine(screen, float32(ax), float32(ay), float32(bx), float32(by), 2, missColor, true)
+	what := "far"
+	if high {
+		what = "high"
+	}
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%.0fpx too %s", short, what), (ax+bx)/2, (ay+by)/2)
+}
+
+func (e *Editor) drawHUD(screen *ebiten.Image, screenW int) {
+	vector.FillRect(screen, 0, 0, float32(screenW), 52, hudBG, false)
+	grid := "off"
+	if g := e.step(); g > 1 {
+		grid = fmt.Sprint(g)
+	}
+	status := "goal reachable"
+	if err := e.reach.Err(); err != nil {
+		status = err.Error()
+	}
+	dirty := ""
+	if e.Dirty {
+		dirty = "  (unsaved)"
+	}
+	msg := fmt.Sprintf("EDITOR  %s%s   %d platforms   grid %s   cursor %d,%d   %s\n%s",
+		e.level.Name, dirty, len(e.level.Platforms), grid, e.cursor.X, e.cursor.Y, status, help)
+	ebitenutil.DebugPrintAt(screen, msg, 4, 2)
+}
+
+func strokeWorldRect(screen *ebiten.Image, cam *camera.Camera, r image.Rectangle, clr color.Color, width float32) {
+	sx, sy := cam.WorldToScreen(float64(r.Min.X), float64(r.Min.Y))
+	vector.StrokeRect(screen, float32(sx), float32(sy), float32(r.Dx()), float32(r.Dy()), width, clr, false)
+}
+
+func fillWorldRect(screen *ebiten.Image, cam *camera.Camera, r image.Rectangle, clr color.Color) {
+	sx, sy := cam.WorldToScreen(float64(r.Min.X), float64(r.Min.Y))
+	vector.FillRect(screen, float32(sx), float32(sy), float32(r.Dx()), float32(r.Dy()), clr, false)
+}
+
+// drawWorldSlope outlines a slope's triangle, or fills it.
+func drawWorldSlope(screen *ebiten.Image, cam *camera.Camera, s level.Slope, clr color.Color, fill bool) {
+	var path vector.Path
+	for i, c := range s.Corners() {
+		sx, sy := cam.WorldToScreen(float64(c.X), float64(c.Y))
+		if i == 0 {
+			path.MoveTo(float32(sx), float32(sy))
+		} else {
+			path.LineTo(float32(sx), float32(sy))
+		}
+	}
+	path.Close()
+	op := &vector.DrawPathOptions{AntiAlias: true}
+	op.ColorScale.ScaleWithColor(clr)
+	if fill {
+		vector.FillPath(screen, &path, nil, op)
+	} else {
+		vector.StrokePath(screen, &path, &vector.StrokeOptions{Width: 1}, op)
+	}
+}
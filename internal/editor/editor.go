@@ -0,0 +1,384 @@
+// Package editor is the in-game level editor.
+package editor
+
+import (
+	"image"
+	"math"
+	"slices"
+
+	"platform-game-one/internal/camera"
+	"platform-game-one/internal/level"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	handleSize = 8  // how close to an edge of the selection a drag resizes it
+	panSpeed   = 12 // pixels per tick with the arrow keys
+	maxUndo    = 200
+	deathDepth = 100 // DeathY is kept this far below the bottom of the level
+)
+
+// gridSizes are the snap steps cycled with [ and ]; 1 is no snapping.
+var gridSizes = []int{1, 8, 16, 32, 64}
+
+// Action is something the editor asks the game to do.
+type Action int
+
+const (
+	None     Action = iota
+	Playtest        // play the level from Cursor
+	Save            // write the level to its file
+)
+
+// edges is which sides of a platform a drag moves.
+type edges struct{ left, right, top, bottom bool }
+
+func (e edges) any() bool { return e.left || e.right || e.top || e.bottom }
+
+type dragKind int
+
+const (
+	dragNone dragKind = iota
+	dragCreate
+	dragMove
+	dragResize
+	dragPan
+)
+
+type drag struct {
+	kind   dragKind
+	anchor image.Point // create: the snapped corner where the drag started
+	grab   image.Point // move: cursor offset from the platform's top-left
+	edges  edges       // resize: sides being moved
+	mouseX int         // pan: last mouse position
+	mouseY int
+	before snapshot // level before the drag, for undo
+}
+
+// snapshot is the editable part of a level.
+type snapshot struct {
+	platforms      []image.Rectangle
+	goal           image.Rectangle
+	startX, startY float64
+	width, height  int
+	deathY         float64
+}
+
+// Editor edits a level in place with the mouse and keyboard.
+type Editor struct {
+	level *level.Level
+	env   level.Envelope
+	reach *level.Reach
+	grid  int // index into gridSizes
+	sel   int // selected platform, or -1
+	drag  drag
+	undo  []snapshot
+	redo  []snapshot
+
+	cursor image.Point // snapped cursor in world coordinates
+	raw    image.Point // unsnapped cursor in world coordinates
+
+	// Dirty is set by every change and cleared by the game after saving.
+	Dirty bool
+}
+
+// New creates an editor for lv. env is the player movement the reachability check uses.
+func New(lv *level.Level, env level.Envelope) *Editor {
+	e := &Editor{level: lv, env: env, grid: 1, sel: -1}
+	e.reach = lv.CheckReach(env)
+	return e
+}
+
+// Reach returns the reachability of the level as it is now.
+func (e *Editor) Reach() *level.Reach { return e.reach }
+
+// Cursor returns the snapped cursor position in world coordinates.
+func (e *Editor) Cursor() (x, y float64) {
+	return float64(e.cursor.X), float64(e.cursor.Y)
+}
+
+func (e *Editor) step() int { return gridSizes[e.grid] }
+
+func (e *Editor) snap(v int) int {
+	g := e.step()
+	return int(math.Round(float64(v)/float64(g))) * g
+}
+
+func (e *Editor) snapPoint(p image.Point) image.Point {
+	return image.Pt(max(0, e.snap(p.X)), max(0, e.snap(p.Y)))
+}
+
+// Update handles one tick of input. cam is moved directly; the game's camera follow
+// is not used while editing.
+func (e *Editor) Update(cam *camera.Camera, screenW, screenH int) Action {
+	mx, my := ebiten.CursorPosition()
+	e.raw = image.Pt(mx+int(cam.X), my+int(cam.Y))
+	e.cursor = e.snapPoint(e.raw)
+
+	ctrl := ebiten.IsKeyPressed(ebiten.KeyControl) || ebiten.IsKeyPressed(ebiten.KeyMeta)
+	shift := ebiten.IsKeyPressed(ebiten.KeyShift)
+	pressed := func(k ebiten.Key) bool { return inpututil.IsKeyJustPressed(k) }
+
+	switch {
+	case ctrl && pressed(ebiten.KeyS):
+		return Save
+	case ctrl && (pressed(ebiten.KeyY) || shift && pressed(ebiten.KeyZ)):
+		e.Redo()
+	case ctrl && pressed(ebiten.KeyZ):
+		e.Undo()
+	case pressed(ebiten.KeyEnter) || pressed(ebiten.KeyP):
+		return Playtest
+	case pressed(ebiten.KeyDelete) || pressed(ebiten.KeyBackspace):
+		if e.sel >= 0 {
+			e.edit(func() { e.remove(e.sel) })
+		}
+	case !ctrl && pressed(ebiten.KeyS):
+		e.edit(func() {
+			e.level.StartX = float64(e.cursor.X) - e.env.Width/2
+			e.level.StartY = float64(e.cursor.Y) - e.env.Height
+		})
+	case pressed(ebiten.KeyG):
+		e.edit(func() {
+			g := e.level.Goal
+			e.level.Goal = g.Sub(g.Min).Add(image.Pt(e.cursor.X-g.Dx()/2, e.cursor.Y-g.Dy()))
+		})
+	case pressed(ebiten.KeyBracketLeft):
+		e.grid = max(0, e.grid-1)
+	case pressed(ebiten.KeyBracketRight):
+		e.grid = min(len(gridSizes)-1, e.grid+1)
+	}
+
+	speed := float64(panSpeed)
+	if shift {
+		speed *= 3
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyArrowLeft) {
+		cam.X -= speed
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyArrowRight) {
+		cam.X += speed
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyArrowUp) {
+		cam.Y -= speed
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyArrowDown) {
+		cam.Y += speed
+	}
+
+	e.updateMouse(cam, mx, my)
+
+	// the level grows as platforms are added past its edges, so allow a screen of room
+	cam.X = math.Max(0, math.Min(cam.X, float64(e.level.Width)))
+	cam.Y = math.Max(0, math.Min(cam.Y, float64(e.level.Height)))
+	return None
+}
+
+func (e *Editor) updateMouse(cam *camera.Camera, mx, my int) {
+	switch {
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonMiddle):
+		e.drag = drag{kind: dragPan, mouseX: mx, mouseY: my}
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonRight):
+		if i := e.platformAt(e.raw); i >= 0 {
+			e.edit(func() { e.remove(i) })
+		}
+	case inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft):
+		e.drag = drag{before: e.save()}
+		if ed := e.handlesAt(e.raw); ed.any() {
+			e.drag.kind, e.drag.edges = dragResize, ed
+			break
+		}
+		e.sel = e.platformAt(e.raw)
+		if e.sel >= 0 {
+			e.drag.kind = dragMove
+			e.drag.grab = e.raw.Sub(e.level.Platforms[e.sel].Min)
+			break
+		}
+		e.drag.kind, e.drag.anchor = dragCreate, e.cursor
+	}
+
+	switch e.drag.kind {
+	case dragPan:
+		cam.X -= float64(mx - e.drag.mouseX)
+		cam.Y -= float64(my - e.drag.mouseY)
+		e.drag.mouseX, e.drag.mouseY = mx, my
+		if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle) {
+			e.drag.kind = dragNone
+		}
+		return
+	case dragMove:
+		if e.sel >= 0 {
+			r := e.level.Platforms[e.sel]
+			e.level.Platforms[e.sel] = r.Sub(r.Min).Add(e.snapPoint(e.raw.Sub(e.drag.grab)))
+		}
+	case dragResize:
+		if e.sel >= 0 {
+			e.level.Platforms[e.sel] = e.resized(e.level.Platforms[e.sel])
+		}
+	}
+	if e.drag.kind == dragNone || ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		return
+	}
+
+	// released
+	if e.drag.kind == dragCreate {
+		r := image.Rectangle{e.drag.anchor, e.cursor}.Canon()
+		if r.Dx() > 0 && r.Dy() > 0 {
+			e.level.Platforms = append(e.level.Platforms, r)
+			e.sel = len(e.level.Platforms) - 1
+		}
+	}
+	e.drag.kind = dragNone
+	if !e.drag.before.equal(e.save()) {
+		e.push(e.drag.before)
+		e.changed()
+	}
+}
+
+// resized moves the dragged edges of r to the cursor, keeping r at least one grid step.
+func (e *Editor) resized(r image.Rectangle) image.Rectangle {
+	c, g := e.cursor, e.step()
+	ed := e.drag.edges
+	if ed.left {
+		r.Min.X = min(c.X, r.Max.X-g)
+	}
+	if ed.right {
+		r.Max.X = max(c.X, r.Min.X+g)
+	}
+	if ed.top {
+		r.Min.Y = min(c.Y, r.Max.Y-g)
+	}
+	if ed.bottom {
+		r.Max.Y = max(c.Y, r.Min.Y+g)
+	}
+	return r
+}
+
+// platformAt returns the topmost platform under p, or -1.
+func (e *Editor) platformAt(p image.Point) int {
+	for i := len(e.level.Platforms) - 1; i >= 0; i-- {
+		if p.In(e.level.Platforms[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// handlesAt returns the edges of the selected platform that p is close to.
+func (e *Editor) handlesAt(p image.Point) edges {
+	if e.sel < 0 {
+		return edges{}
+	}
+	r := e.level.Platforms[e.sel]
+	if !p.In(r.Inset(-handleSize)) {
+		return edges{}
+	}
+	near := func(a, b int) bool { return a-b < handleSize && b-a < handleSize }
+	return edges{
+		left:   near(p.X, r.Min.X),
+		right:  near(p.X, r.Max.X),
+		top:    near(p.Y, r.Min.Y),
+		bottom: near(p.Y, r.Max.Y),
+	}
+}
+
+func (e *Editor) remove(i int) {
+	e.level.Platforms = slices.Delete(e.level.Platforms, i, i+1)
+	e.sel = -1
+	e.dropDrag()
+}
+
+// dropDrag ends a move or resize, whose platform may have been removed or
+// renumbered under it.
+func (e *Editor) dropDrag() {
+	if e.drag.kind == dragMove || e.drag.kind == dragResize {
+		e.drag.kind = dragNone
+	}
+}
+
+// edit applies f as one undoable change, if it changes anything.
+func (e *Editor) edit(f func()) {
+	before := e.save()
+	f()
+	if before.equal(e.save()) {
+		return
+	}
+	e.push(before)
+	e.changed()
+}
+
+func (e *Editor) push(s snapshot) {
+	e.undo = append(e.undo, s)
+	if len(e.undo) > maxUndo {
+		e.undo = e.undo[1:]
+	}
+	e.redo = e.redo[:0]
+}
+
+// Undo reverts the last change.
+func (e *Editor) Undo() {
+	if len(e.undo) == 0 {
+		return
+	}
+	e.redo = append(e.redo, e.save())
+	e.restore(e.undo[len(e.undo)-1])
+	e.undo = e.undo[:len(e.undo)-1]
+	e.dropDrag()
+	e.changed()
+}
+
+// Redo reapplies the last undone change.
+func (e *Editor) Redo() {
+	if len(e.redo) == 0 {
+		return
+	}
+	e.undo = append(e.undo, e.save())
+	e.restore(e.redo[len(e.redo)-1])
+	e.redo = e.redo[:len(e.redo)-1]
+	e.dropDrag()
+	e.changed()
+}
+
+// changed grows the level to fit everything in it and rechecks reachability.
+func (e *Editor) changed() {
+	lv := e.level
+	for _, r := range append([]image.Rectangle{lv.Goal}, lv.Platforms...) {
+		lv.Width = max(lv.Width, r.Max.X)
+		lv.Height = max(lv.Height, r.Max.Y)
+	}
+	lv.DeathY = math.Max(lv.DeathY, float64(lv.Height+deathDepth))
+	if e.sel >= len(lv.Platforms) {
+		e.sel = -1
+	}
+	e.reach = lv.CheckReach(e.env)
+	e.Dirty = true
+}
+
+func (e *Editor) save() snapshot {
+	lv := e.level
+	return snapshot{
+		platforms: slices.Clone(lv.Platforms),
+		goal:      lv.Goal,
+		startX:    lv.StartX,
+		startY:    lv.StartY,
+		width:     lv.Width,
+		height:    lv.Height,
+		deathY:    lv.DeathY,
+	}
+}
+
+func (e *Editor) restore(s snapshot) {
+	lv := e.level
+	lv.Platforms = slices.Clone(s.platforms)
+	lv.Goal = s.goal
+	lv.StartX, lv.StartY = s.startX, s.startY
+	lv.Width, lv.Height = s.width, s.height
+	lv.DeathY = s.deathY
+}
+
+func (s snapshot) equal(o snapshot) bool {
+	return slices.Equal(s.platforms, o.platforms) && s.goal == o.goal &&
+		s.startX == o.startX && s.startY == o.startY &&
+		s.width == o.width && s.height == o.height && s.deathY == o.deathY
+}
@@ -0,0 +1,53 @@
+package event
+
+import "platform-game-one/internal/player"
+
+// Kind identifies what happened in gameplay.
+type Kind int
+
+const (
+	Jump Kind = iota
+	Land
+	Death
+	Goal
+	ShapeChange
+	LevelStart
+	Launch      // a spring, cannon or bounce pad threw the player
+	Break       // a block broke or crumbled; X and Y are its center
+	Trigger     // a key was picked up or a switch or lever turned on or off; X and Y are its center
+	Splash      // the player fell into water
+	StateChange // the player's state changed, say from falling to landing
+)
+
+// Event is something that happened during one tick. X and Y are the player's
+// center in world coordinates.
+type Event struct {
+	Kind     Kind
+	X, Y     float64
+	Speed    float64      // Land: downward speed at impact
+	Material string       // Land, Jump: what the ground is made of; empty for plain ground
+	Shape    int          // ShapeChange, Death: the player's shape
+	Level    int          // LevelStart: 1-based level number
+	From, To player.State // StateChange: the player states left and entered
+}
+
+// Bus delivers events to subscribers synchronously, in subscription order.
+type Bus struct {
+	handlers []func(Event)
+	Muted    bool // drop published events, e.g. while re-simulating ticks
+}
+
+// Subscribe registers h to receive every published event.
+func (b *Bus) Subscribe(h func(Event)) {
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish sends e to all subscribers, unless the bus is muted.
+func (b *Bus) Publish(e Event) {
+	if b.Muted {
+		return
+	}
+	for _, h := range b.handlers {
+		h(e)
+	}
+}
@@ -0,0 +1,154 @@
+package game
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"platform-game-one/internal/camera"
+	"platform-game-one/internal/input"
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/player"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// partnerGap is how far apart the players start, and how far above the partner a
+// fallen player comes back.
+const partnerGap = 40
+
+// startPos returns where player i starts the level.
+func (g *Game) startPos(i int) (x, y float64) {
+	return g.level.StartX + float64(i*partnerGap), g.level.StartY
+}
+
+// step moves st's player one tick. In co-op the other players are solid while it
+// moves, so one can stand on another. In a race it plays its own blocks and triggers.
+func (g *Game) step(st *seat, dt float64, in input.State) {
+	if g.race {
+		g.swapLive(st)
+		defer g.swapLive(st)
+	} else if len(g.seats) > 1 {
+		others := g.others[:0] // reuse last tick's slice
+		for _, o := range g.seats {
+			if o != st {
+				others = append(others, o.player.Rect())
+			}
+		}
+		g.others = others
+		g.level.Extra = others
+		defer func() { g.level.Extra = nil }()
+	}
+	st.player.Step(dt, in, g.level)
+}
+
+// respawn brings back st's player after a fall: to its last safe ground with the
+// invincibility assist, next to the partner in co-op, or else to the start. all is
+// true when every player fell at once.
+func (g *Game) respawn(st *seat, all bool) {
+	p := st.player
+	switch {
+	case g.assist.Invincible:
+		p.Respawn(st.safeX, st.safeY)
+	case g.playtest:
+		p.Respawn(g.playX, g.playY)
+	case len(g.seats) > 1 && !all && !g.race:
+		partner := g.partner(st)
+		p.Respawn(partner.safeX, partner.safeY-player.Height-partnerGap)
+	default:
+		for i, o := range g.seats {
+			if o == st {
+				p.Respawn(g.startPos(i))
+			}
+		}
+	}
+}
+
+// partner returns another player than st.
+func (g *Game) partner(st *seat) *seat {
+	for _, o := range g.seats {
+		if o != st {
+			return o
+		}
+	}
+	return st
+}
+
+// allDone reports whether every player has reached the goal.
+func (g *Game) allDone() bool {
+	for _, st := range g.seats {
+		if !st.done {
+			return false
+		}
+	}
+	return true
+}
+
+// leader returns the index of the player closest to the goal.
+func (g *Game) leader() int {
+	goalX := float64(g.level.Goal.Min.X+g.level.Goal.Max.X) / 2
+	lead, best := 0, math.Inf(1)
+	for i, st := range g.seats {
+		if d := math.Abs(st.player.CenterX() - goalX); d < best {
+			lead, best = i, d
+		}
+	}
+	return lead
+}
+
+// maxSpan is the farthest apart horizontally the players can be and still both fit
+// in the camera zoomed all the way out.
+func maxSpan() float64 {
+	return level.ScreenWidth/camera.MinZoom - 2*camera.FramePad - player.Width
+}
+
+// pullLagging drags a player who falls too far behind the leader along with them.
+// If that would put the player inside a wall, they come back next to the leader.
+func (g *Game) pullLagging() {
+	if len(g.seats) == 1 || g.race {
+		return
+	}
+	lead := g.seats[g.leader()]
+	for _, st := range g.seats {
+		p := st.player
+		dx := p.X - lead.player.X
+		if math.Abs(dx) <= maxSpan() {
+			continue
+		}
+		p.X = lead.player.X + math.Copysign(maxSpan(), dx)
+		if g.level.Solid(p.Rect()) {
+			p.Respawn(lead.safeX, lead.safeY-player.Height-partnerGap)
+		}
+	}
+}
+
+// frameSeats moves the camera to keep every player in view.
+func (g *Game) frameSeats() {
+	rects := make([]image.Rectangle, len(g.seats))
+	for i, st := range g.seats {
+		rects[i] = st.player.Rect()
+	}
+	g.camera.Frame(rects, g.leader(), g.level.Width, g.level.Height, level.ScreenWidth, level.ScreenHeight)
+}
+
+// shapeHelp names the keys that switch shape.
+func (g *Game) shapeHelp() string {
+	if len(g.seats) > 1 {
+		return "[Tab] / [Right Shift] switch shape"
+	}
+	return "[Tab] switch shape"
+}
+
+// drawWaiting tells co-op players who is still on the way to the goal.
+func (g *Game) drawWaiting(screen *ebiten.Image) {
+	if len(g.seats) == 1 || g.race || g.state != statePlaying {
+		return
+	}
+	for i, st := range g.seats {
+		if st.done {
+			ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Player %d is at the goal - waiting for player %d", i+1, 2-i), 0, level.ScreenHeight-32)
+			return
+		}
+	}
+}
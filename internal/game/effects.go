@@ -0,0 +1,148 @@
+package game
+
+import (
+	"math"
+
+	"platform-game-one/internal/event"
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/particles"
+	"platform-game-one/internal/player"
+)
+
+const (
+	minDustSpeed   = 200 // softer landings kick up no dust
+	celebrateTicks = 90  // pause on reaching the goal before the next level
+	fireworkEvery  = 20
+)
+
+var (
+	dustEmitter = particles.Emitter{
+		Life: 0.4, Speed: 90, Angle: -math.Pi / 2, Spread: math.Pi * 0.9,
+		Gravity: 200, Drag: 3, Size: 6, Shape: particles.Circle,
+		Ramp: particles.Ramp{{R: 0xc8, G: 0xb8, B: 0xa0, A: 0xc0}, {R: 0x90, G: 0x80, B: 0x70, A: 0}},
+	}
+	// surfaceDust replaces dust on ground made of something other than dirt
+	surfaceDust = map[string]particles.Emitter{
+		level.MaterialIce: {
+			Life: 0.35, Speed: 120, Angle: -math.Pi / 2, Spread: math.Pi * 0.8,
+			Gravity: 300, Drag: 2, Size: 4, Shape: particles.Square,
+			Ramp: particles.Ramp{{R: 0xf0, G: 0xff, B: 0xff, A: 0xff}, {R: 0x90, G: 0xd0, B: 0xff, A: 0}},
+		},
+		level.MaterialConveyor: {
+			Life: 0.3, Speed: 100, Angle: -math.Pi / 2, Spread: math.Pi * 0.9,
+			Gravity: 400, Drag: 2, Size: 3, Shape: particles.Square,
+			Ramp: particles.Ramp{{R: 0xff, G: 0xd0, B: 0x60, A: 0xff}, {R: 0x80, G: 0x80, B: 0x80, A: 0}},
+		},
+		level.MaterialSticky: {
+			Life: 0.5, Speed: 70, Angle: -math.Pi / 2, Spread: math.Pi * 0.7,
+			Gravity: 600, Drag: 1, Size: 7, Shape: particles.Circle,
+			Ramp: particles.Ramp{{R: 0x90, G: 0xd0, B: 0x40, A: 0xe0}, {R: 0x50, G: 0x90, B: 0x20, A: 0}},
+		},
+	}
+	debrisEmitter = particles.Emitter{
+		Count: 18, Life: 0.7, Speed: 220, Angle: -math.Pi / 2, Spread: math.Pi * 1.2,
+		Gravity: 900, Drag: 0.5, Size: 6, Shape: particles.Square,
+		Ramp: particles.Ramp{{R: 0xa0, G: 0x80, B: 0x60, A: 0xff}, {R: 0x60, G: 0x48, B: 0x30, A: 0}},
+	}
+	splashEmitter = particles.Emitter{
+		Count: 16, Life: 0.6, Speed: 200, Angle: -math.Pi / 2, Spread: math.Pi * 0.6,
+		Gravity: 700, Drag: 0.5, Size: 5, Shape: particles.Circle,
+		Ramp: particles.Ramp{{R: 0xe0, G: 0xf0, B: 0xff, A: 0xe0}, {R: 0x60, G: 0xa0, B: 0xe0, A: 0}},
+	}
+	sparkEmitter = particles.Emitter{
+		Count: 12, Life: 0.5, Speed: 140, Angle: -math.Pi / 2, Spread: 2 * math.Pi,
+		Gravity: 100, Drag: 2, Size: 4, Shape: particles.Circle,
+		Ramp: particles.Ramp{{R: 0xff, G: 0xff, B: 0xc0, A: 0xff}, {R: 0xff, G: 0xd0, B: 0x40, A: 0}},
+	}
+	// appearEmitter rings a player put back after a fall
+	appearEmitter = particles.Emitter{
+		Count: 20, Life: 0.45, Speed: 110, Angle: 0, Spread: 2 * math.Pi,
+		Gravity: 0, Drag: 3, Size: 4, Shape: particles.Circle,
+		Ramp: particles.Ramp{{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, {R: 0xa0, G: 0xd0, B: 0xff, A: 0}},
+	}
+	fireworkEmitter = particles.Emitter{
+		Count: 48, Life: 1.1, Speed: 260, Angle: 0, Spread: 2 * math.Pi,
+		Gravity: 160, Drag: 1.5, Size: 5, Shape: particles.Circle,
+	}
+	fireworkRamps = []particles.Ramp{
+		{{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, {R: 0xff, G: 0xe0, B: 0x40, A: 0xff}, {R: 0xff, G: 0x40, B: 0x20, A: 0}},
+		{{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, {R: 0x40, G: 0xc0, B: 0xff, A: 0xff}, {R: 0x80, G: 0x40, B: 0xff, A: 0}},
+		{{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, {R: 0x60, G: 0xff, B: 0x80, A: 0xff}, {R: 0x20, G: 0x80, B: 0x40, A: 0}},
+	}
+	// where each firework bursts relative to the goal's top center
+	fireworkOffsets = [][2]float64{{0, -160}, {-140, -220}, {150, -200}, {-60, -300}, {90, -280}}
+)
+
+// effects turns gameplay events into particles.
+type effects struct {
+	sys   *particles.System
+	death []particles.Emitter // indexed by player shape
+	fired int                 // fireworks launched this celebration
+}
+
+func newEffects() *effects {
+	fx := &effects{sys: particles.New()}
+	for s := player.ShapeCircle; s.Valid(); s++ {
+		c := player.ShapeColor(s)
+		fade := c
+		fade.A = 0
+		fx.death = append(fx.death, particles.Emitter{
+			Count: 40, Life: 0.8, Speed: 320, Angle: -math.Pi / 2, Spread: math.Pi,
+			Gravity: 600, Drag: 0.5, Size: 7, Shape: particles.Square,
+			Ramp: particles.Ramp{{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, c, fade},
+		})
+	}
+	return fx
+}
+
+// handle is subscribed to the game's event bus.
+func (fx *effects) handle(e event.Event) {
+	switch e.Kind {
+	case event.Land:
+		if e.Speed < minDustSpeed {
+			return
+		}
+		dust, ok := surfaceDust[e.Material]
+		if !ok {
+			dust = dustEmitter
+		}
+		dust.Count = int(e.Speed / 60)
+		fx.sys.Emit(&dust, e.X, e.Y+player.Radius)
+	case event.Jump:
+		if dust, ok := surfaceDust[e.Material]; ok {
+			dust.Count = 5 // a little kicked up taking off from it
+			fx.sys.Emit(&dust, e.X, e.Y+player.Radius)
+		}
+	case event.Launch:
+		dust := dustEmitter
+		dust.Count = 10
+		fx.sys.Emit(&dust, e.X, e.Y+player.Radius)
+	case event.Break:
+		fx.sys.Emit(&debrisEmitter, e.X, e.Y)
+	case event.Splash:
+		fx.sys.Emit(&splashEmitter, e.X, e.Y+player.Radius)
+	case event.Trigger:
+		fx.sys.Emit(&sparkEmitter, e.X, e.Y)
+	case event.StateChange:
+		if e.From == player.StateDead && e.To == player.StateRespawning {
+			fx.sys.Emit(&appearEmitter, e.X, e.Y)
+		}
+	case event.Death:
+		if e.Shape >= 0 && e.Shape < len(fx.death) {
+			fx.sys.Emit(&fx.death[e.Shape], e.X, e.Y)
+		}
+	case event.Goal:
+		fx.fired = 0
+	case event.LevelStart:
+		fx.sys.Clear()
+	}
+}
+
+// firework launches the next firework over goal.
+func (fx *effects) firework(goalX, goalY float64) {
+	off := fireworkOffsets[fx.fired%len(fireworkOffsets)]
+	fw := fireworkEmitter
+	fw.Ramp = fireworkRamps[fx.fired%len(fireworkRamps)]
+	fx.sys.Emit(&fw, goalX+off[0], goalY+off[1])
+	fx.fired++
+}
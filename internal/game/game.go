@@ -1,142 +1,962 @@
 package game
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"image"
 	"image/color"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
 
+	"platform-game-one/internal/audio"
 	"platform-game-one/internal/camera"
+	"platform-game-one/internal/debug"
+	"platform-game-one/internal/editor"
+	"platform-game-one/internal/event"
+	"platform-game-one/internal/input"
+	"platform-game-one/internal/leaderboard"
 	"platform-game-one/internal/level"
+	"platform-game-one/internal/levelgen"
+	"platform-game-one/internal/netplay"
+	"platform-game-one/internal/pack"
 	"platform-game-one/internal/player"
+	"platform-game-one/internal/records"
+	"platform-game-one/internal/settings"
+	"platform-game-one/internal/skin"
+	"platform-game-one/internal/theme"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
 const (
-	ScreenWidth  = 1280
-	ScreenHeight = 720
-	TotalLevels  = 3
+	arcTicks = 120 // how far ahead the assist jump preview looks
+	propCull = 200 // props this far off screen may still reach into view
 )
 
 type gameState int
 
 const (
-	statePlaying gameState = iota
+	statePlaying     gameState = iota
+	stateCelebrating           // reached the goal, fireworks before moving on
 	stateWon
+	stateFailed // the next level wouldn't load; loadErr says why
+	stateEditing
+	stateResults // race over, showing the finish order
 )
 
+// Options configures a new Game. The zero value starts the default pack's level 1
+// with the keyboard.
+type Options struct {
+	Pack       *pack.Pack // nil means the built-in pack.Default
+	StartLevel int        // 1-based level in Pack; 0 means 1
+	LevelFile  string     // play this level file instead of a pack
+	Shape      player.Shape
+	Debug      bool         // show the debug overlay from the start
+	Input      input.Source // nil means the keyboard
+	Assist     settings.Assist
+	Records    *records.Book  // nil means runs aren't recorded
+	RecordsAt  string         // save Records here whenever a run changes them; empty doesn't
+	Audio      *audio.Manager // nil means silent
+	Skins      map[player.Shape]*skin.Skin
+	Edit       bool                // start in the level editor; a missing LevelFile starts a blank level
+	Generate   *levelgen.Params    // play generated levels from these params instead
+	Partner    *PlayerOptions      // a second player for local co-op; nil plays alone
+	Race       bool                // the partner races instead, each in their own half of the screen
+	Split      Split               // how the screen is divided for a race
+	Netplay    *netplay.Session    // play co-op with a partner over the network; needs Partner
+	Board      *leaderboard.Client // submit clean single-player runs here; nil doesn't
+	PlayerName string              // the name runs are submitted under
+}
+
+// PlayerOptions configures the second player in co-op.
+type PlayerOptions struct {
+	Shape player.Shape
+	Input input.Source // nil means input.SecondKeyboard
+}
+
+// seat is one player and the input driving it.
+type seat struct {
+	player       *player.Player
+	camera       *camera.Camera // shared in co-op, one each in a race
+	input        input.Source
+	held         input.Buttons  // buttons held on the previous tick
+	safeX, safeY float64        // last position on firm ground, used by the invincibility assist and co-op respawns
+	arc          []player.Point // assist jump preview
+	done         bool           // reached the goal; in co-op, waiting for the partner
+	finish       int            // race ticks when the player reached the goal
+	seen         int            // player state transitions already published
+	live         level.Live     // a racer's own blocks and triggers, while the level holds someone else's
+}
+
 // Game implements ebiten.Game.
 type Game struct {
-	player   *player.Player
-	level    *level.Level
-	camera   *camera.Camera
-	state    gameState
-	levelNum int // 1-based
-}
-
-// New creates a new Game starting at level 1.
-func New() *Game {
-	lv := level.FirstLevel(ScreenWidth, ScreenHeight)
-	pl := player.New(lv.StartX, lv.StartY)
-	cam := camera.New()
-	return &Game{
-		player:   pl,
-		level:    lv,
-		camera:   cam,
-		state:    statePlaying,
-		levelNum: 1,
-	}
-}
-
-func (g *Game) loadLevel(num int) {
-	switch num {
-	case 1:
-		g.level = level.FirstLevel(ScreenWidth, ScreenHeight)
-	case 2:
-		g.level = level.SecondLevel(ScreenWidth, ScreenHeight)
-	case 3:
-		g.level = level.ThirdLevel(ScreenWidth, ScreenHeight)
-	}
-	g.player.Respawn(g.level.StartX, g.level.StartY)
+	seats     []*seat // the first is the only one in single player
+	level     *level.Level
+	camera    *camera.Camera
+	state     gameState
+	levelNum  int        // 1-based
+	pack      *pack.Pack // the pack being played, unless playing a level file or generated levels
+	levelFile string
+	gen       *levelgen.Params // params of the current generated level
+	overlay   *debug.Overlay
+	ticks     int
+	assist    settings.Assist
+	records   *records.Book
+	recordsAt string
+	lastRun   records.Run
+	newBest   bool
+	events    event.Bus
+	audio     *audio.Manager
+	effects   *effects
+	theme     *theme.Renderer // created on first draw of each level
+	notice    string          // shown on screen, e.g. a theme that failed to load
+	loadErr   error           // why the next level failed to load, in stateFailed
+	celebrate int             // ticks left in stateCelebrating
+	editor    *editor.Editor  // created when the editor is first opened on a level
+	editable  bool            // the editor can be opened; not while recording, replaying or in co-op
+	playtest  bool            // playing from the editor: the goal returns to it
+
+	levelTicks   int
+	playX, playY float64 // where the playtest started, used for respawning
+
+	watch      bool      // reload levelFile when it changes
+	stamp      fileStamp // levelFile as last loaded
+	reloadErr  string    // why the last reload failed; cleared by one that works
+	watchTicks int
+
+	world  *ebiten.Image     // co-op draws the world here, then scales it to the zoom
+	others []image.Rectangle // the other players, solid to the one stepping in co-op
+	race   bool
+	split  Split
+	views  []*ebiten.Image // one per racer, drawn into their part of the screen
+
+	net         *netplay.Session
+	resim       bool            // replaying ticks after a netplay rollback: no sounds or effects
+	heldBuf     []input.Buttons // each player's buttons this tick
+	netFrame    int             // the session tick being simulated
+	unconfirmed []pendingRun    // netplay runs finished on ticks a rollback may still undo
+
+	board     *leaderboard.Client
+	name      string
+	run       []input.Buttons // buttons held each tick of this level, for the leaderboard
+	runShape  player.Shape    // shape at the start of the level
+	submitted chan string     // leaderboard results, shown as notices
+}
+
+// New creates a new Game from opts.
+func New(opts Options) (*Game, error) {
+	g := &Game{
+		camera:    camera.New(),
+		levelFile: opts.LevelFile,
+		overlay:   debug.New(opts.Debug),
+		assist:    opts.Assist,
+		records:   opts.Records,
+		recordsAt: opts.RecordsAt,
+		audio:     opts.Audio,
+		effects:   newEffects(),
+		editable:  opts.Input == nil && opts.Partner == nil,
+		race:      opts.Race && opts.Partner != nil,
+		split:     opts.Split,
+		net:       opts.Netplay,
+		board:     opts.Board,
+		name:      opts.PlayerName,
+		submitted: make(chan string, 4),
+	}
+	g.events.Subscribe(g.effects.handle)
+	if g.audio != nil {
+		g.events.Subscribe(g.audio.Handle)
+	}
+	if err := g.assist.Validate(); err != nil {
+		return nil, err
+	}
+	first := &seat{input: opts.Input, player: player.New(0, 0)}
+	first.player.Shape = opts.Shape
+	if first.input == nil {
+		first.input = input.DefaultKeyboard()
+	}
+	g.seats = append(g.seats, first)
+	if opts.Partner != nil {
+		second := &seat{input: opts.Partner.Input, player: player.New(0, 0)}
+		second.player.Shape = opts.Partner.Shape
+		if second.input == nil {
+			second.input = input.SecondKeyboard()
+		}
+		g.seats = append(g.seats, second)
+	}
+	if g.net != nil {
+		if len(g.seats) != netplay.Players || g.race {
+			return nil, errors.New("netplay needs a co-op partner")
+		}
+		// each peer reads its own player; the other's input arrives over the network
+		local := g.net.Local()
+		g.seats[local].input, g.seats[1-local].input = first.input, input.None{}
+	}
+	for _, s := range g.seats {
+		for shape, sk := range opts.Skins {
+			s.player.SetSkin(shape, sk)
+		}
+		s.player.InfiniteJumps = g.assist.InfiniteJumps
+	}
+	if g.levelFile != "" {
+		lv, err := level.Load(g.levelFile)
+		if opts.Edit && errors.Is(err, fs.ErrNotExist) {
+			lv, err = level.Blank(level.ScreenWidth, level.ScreenHeight), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if lv.Name == "" {
+			lv.Name = filepath.Base(g.levelFile)
+		}
+		g.setLevel(lv, 1)
+		// live play follows the file as it's edited; a replay or netplay must not change under it
+		g.watch = opts.Input == nil && g.net == nil
+		g.stamp, _ = stampOf(g.levelFile)
+	} else if opts.Generate != nil {
+		if err := g.generate(*opts.Generate, 1); err != nil {
+			return nil, err
+		}
+	} else {
+		g.pack = opts.Pack
+		if g.pack == nil {
+			reg, _ := pack.Discover("") // the built-in packs always load
+			g.pack, _ = reg.Find(pack.Default)
+		}
+		num := opts.StartLevel
+		if num == 0 {
+			num = 1
+		}
+		if err := g.loadLevel(num); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Edit {
+		g.startEditing()
+	}
+	return g, nil
+}
+
+// loadLevel plays level num of the pack.
+func (g *Game) loadLevel(num int) error {
+	lv, err := g.pack.Level(num, level.ScreenWidth, level.ScreenHeight)
+	if err != nil {
+		return err
+	}
+	g.setLevel(lv, num)
+	return nil
+}
+
+// generate builds the level for p and plays it as level number num.
+func (g *Game) generate(p levelgen.Params, num int) error {
+	lv, err := levelgen.Generate(p, player.Envelope(1.0/60.0), level.ScreenWidth, level.ScreenHeight)
+	if err != nil {
+		return err
+	}
+	g.gen = &p
+	g.setLevel(lv, num)
+	return nil
+}
+
+// setLevel starts playing lv as level number num.
+func (g *Game) setLevel(lv *level.Level, num int) {
+	g.level = lv
+	lv.Reset()
+	for i, s := range g.seats {
+		x, y := g.startPos(i)
+		s.player.Respawn(x, y)
+		s.safeX, s.safeY = x, y
+		s.done = false
+		s.finish = 0
+		s.live = lv.Live.Clone()
+	}
 	g.camera = camera.New()
+	for _, s := range g.seats {
+		s.camera = g.camera
+		if g.race {
+			s.camera = camera.New()
+		}
+	}
 	g.levelNum = num
+	g.theme = nil
+	g.editor = nil
+	g.levelTicks = 0
+	g.run = g.run[:0]
+	g.runShape = g.seats[0].player.Shape
 	g.state = statePlaying
+	g.events.Publish(event.Event{Kind: event.LevelStart, Level: num})
+	ebiten.SetWindowTitle(g.Title())
+}
+
+// Title returns the window title for the current level.
+func (g *Game) Title() string {
+	return "Platformer - " + g.level.Name
 }
 
 // Update runs each tick.
 func (g *Game) Update() error {
-	if g.state == stateWon {
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		g.overlay.Toggle()
+	}
+	select {
+	case msg := <-g.submitted:
+		g.notice = msg
+	default:
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF4) && g.race {
+		g.toggleSplit()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF2) && g.editable {
+		switch g.state {
+		case stateEditing:
+			g.startPlaytest(g.level.StartX, g.level.StartY)
+		case statePlaying:
+			g.startEditing()
+			return nil
+		}
+	}
+	if g.audio != nil {
+		g.audio.Update(1.0 / 60.0)
+	}
+	g.effects.sys.Update(1.0 / 60.0)
+	g.watchLevel()
+	if g.net != nil {
+		return g.updateNet()
+	}
+	switch g.state {
+	case stateWon, stateFailed:
 		return nil
+	case stateCelebrating:
+		g.updateCelebration()
+		return nil
+	case stateEditing:
+		g.updateEditor()
+		return nil
+	case stateResults:
+		g.updateResults()
+		return nil
+	}
+	held := g.heldBuf[:0]
+	for _, st := range g.seats {
+		held = append(held, st.input.Poll())
+	}
+	g.heldBuf = held
+	if g.tick(held) {
+		g.follow()
+	}
+	return nil
+}
+
+// tick advances play one tick with the buttons each player holds. It returns false
+// if the tick left play for the editor.
+func (g *Game) tick(held []input.Buttons) bool {
+	dt := 1.0 / 60.0 * g.assist.TimeScale()
+	g.ticks++
+	g.levelTicks++
+	if g.board != nil && len(g.seats) == 1 {
+		g.run = append(g.run, held[0])
 	}
-	dt := 1.0 / 60.0
 
-	g.player.Update(dt)
-	rect := g.player.Rect()
-	nx, ny, nvx, nvy, grounded := g.level.ResolveCollision(rect, g.player.VX, g.player.VY)
-	g.player.X, g.player.Y = nx, ny
-	g.player.VX, g.player.VY = nvx, nvy
-	g.player.Grounded = grounded
-	g.player.TryJump()
+	for i, st := range g.seats {
+		if g.race && st.done {
+			continue // finished racers wait at the goal
+		}
+		in := input.Next(st.held, held[i])
+		st.held = held[i]
+		g.step(st, dt, in)
+		g.publishPlayerEvents(st)
+		if st.player.Grounded && g.level.Firm(st.player.Rect()) {
+			st.safeX, st.safeY = st.player.X, st.player.Y
+		}
+	}
+	g.pullLagging()
+	g.updateLevel(dt)
 
 	// Death: fell below level
-	if g.player.Y > g.level.DeathY {
-		g.player.Respawn(g.level.StartX, g.level.StartY)
+	var fallen []*seat
+	for _, st := range g.seats {
+		if st.player.Y > g.level.DeathY {
+			fallen = append(fallen, st)
+		}
+	}
+	for _, st := range fallen {
+		g.events.Publish(event.Event{
+			Kind:  event.Death,
+			X:     st.player.CenterX(),
+			Y:     math.Min(st.player.CenterY(), float64(g.level.Height)), // where it left the screen
+			Shape: int(st.player.Shape),
+		})
 	}
+	for _, st := range fallen {
+		g.respawn(st, len(fallen) == len(g.seats))
+	}
+	// a fresh try, with nothing broken or opened for good
+	switch {
+	case g.assist.Invincible:
+		// nothing is lost by falling
+	case g.race:
+		for _, st := range fallen {
+			g.swapLive(st)
+			g.level.Reset()
+			g.swapLive(st)
+		}
+	case len(fallen) == len(g.seats):
+		g.level.Reset()
+	}
+
 	// Win: reached goal
-	if g.level.InGoal(g.player.Rect()) {
-		if g.levelNum < TotalLevels {
-			g.loadLevel(g.levelNum + 1)
+	for _, st := range g.seats {
+		if !st.done && g.level.InGoal(st.player.Rect()) {
+			st.done = true
+			st.finish = g.levelTicks
+		}
+	}
+	if g.seats[0].done && g.playtest {
+		g.startEditing()
+		g.notice = fmt.Sprintf("Reached the goal in %.2fs", float64(g.levelTicks)/60)
+		return false
+	}
+	if g.race && g.raceOver() {
+		g.publish(g.seats[0], event.Goal)
+		g.state = stateResults
+	} else if g.allDone() {
+		g.publish(g.seats[len(g.seats)-1], event.Goal)
+		if g.net != nil {
+			// a rollback may yet undo this; record it once the session confirms it
+			g.unconfirmed = append(g.unconfirmed, pendingRun{frame: g.netFrame, run: g.finishedRun(), levelNum: g.levelNum})
 		} else {
-			g.state = stateWon
+			g.recordRun(g.finishedRun(), g.levelNum)
+			g.submitRun()
 		}
+		g.state = stateCelebrating
+		g.celebrate = celebrateTicks
 	}
+	return true
+}
 
+// follow moves the camera after a tick and updates what's drawn over the players.
+func (g *Game) follow() {
+	dt := 1.0 / 60.0 * g.assist.TimeScale()
 	// Camera follow
-	centerX := g.player.CenterX()
-	centerY := g.player.CenterY()
-	g.camera.Update(centerX, centerY, g.level.Width, g.level.Height, ScreenWidth, ScreenHeight)
+	if g.race {
+		g.followRacers()
+	} else if len(g.seats) == 1 {
+		p := g.seats[0].player
+		g.camera.Update(p.CenterX(), p.CenterY(), g.level.Width, g.level.Height, level.ScreenWidth, level.ScreenHeight)
+	} else {
+		g.frameSeats()
+	}
 
-	return nil
+	for _, st := range g.seats {
+		if g.assist.ShowArc {
+			g.swapLive(st)
+			st.arc = st.player.PredictJump(g.level, dt, st.held, arcTicks)
+			g.swapLive(st)
+		}
+	}
+	g.swapLive(g.seats[0])
+	g.overlay.Update(g.seats[0].player, g.level, dt, g.seats[0].held)
+	g.swapLive(g.seats[0])
 }
 
 // Draw renders the game.
 func (g *Game) Draw(screen *ebiten.Image) {
-	screen.Fill(color.RGBA{R: 0x1a, G: 0x1a, B: 0x2e, A: 0xff})
+	if g.race {
+		g.drawRace(screen)
+		return
+	}
+	if g.theme == nil {
+		g.theme = theme.NewRenderer(g.loadTheme(), level.ScreenWidth, level.ScreenHeight)
+	}
+	g.drawView(screen, g.camera, level.ScreenWidth, level.ScreenHeight)
+
+	if g.state == stateEditing {
+		g.editor.Draw(screen, g.camera, level.ScreenWidth, level.ScreenHeight)
+		if g.notice != "" {
+			ebitenutil.DebugPrintAt(screen, g.notice, 0, level.ScreenHeight-16)
+		}
+		return
+	}
+
+	// HUD
+	if g.playtest {
+		ebitenutil.DebugPrint(screen, fmt.Sprintf("Playtest: %s   [F2] back to the editor", g.level.Name))
+	} else if g.gen != nil {
+		ebitenutil.DebugPrint(screen, fmt.Sprintf("%s   difficulty %.2f   %s", g.level.Name, g.gen.Difficulty, g.shapeHelp()))
+	} else if g.levelFile != "" {
+		ebitenutil.DebugPrint(screen, fmt.Sprintf("%s   %s", g.level.Name, g.shapeHelp()))
+	} else {
+		ebitenutil.DebugPrint(screen, fmt.Sprintf("%s: level %d / %d   %s", g.pack.Name, g.levelNum, g.pack.Len(), g.shapeHelp()))
+	}
+	g.drawTimer(screen)
+	g.drawWaiting(screen)
+	g.drawNet(screen)
+	g.drawReloadError(screen)
+	if g.notice != "" {
+		ebitenutil.DebugPrintAt(screen, g.notice, 0, level.ScreenHeight-16)
+	}
+
+	switch g.state {
+	case stateWon:
+		ebitenutil.DebugPrint(screen, "\n\n\n\n  You beat all the levels! Congratulations!")
+	case stateFailed:
+		ebitenutil.DebugPrint(screen, fmt.Sprintf("\n\n\n\n  Level %d failed to load:\n  %v", g.levelNum+1, g.loadErr))
+	}
+}
+
+// drawView draws the level as cam sees it onto a dst of size w, h. A camera zoomed
+// out draws the world into an offscreen image first, then scales it down.
+func (g *Game) drawView(dst *ebiten.Image, cam *camera.Camera, w, h int) {
+	viewW, viewH := cam.View(w, h)
+	// zoomed out, the view's bottom edge is what lines up with the backgrounds
+	g.theme.DrawBackground(dst, cam.X, cam.Y+float64(viewH-h), g.level.Height)
+
+	world := dst
+	if cam.Zoom != 1 {
+		if g.world == nil {
+			g.world = ebiten.NewImage(int(math.Ceil(level.ScreenWidth/camera.MinZoom)), int(math.Ceil(level.ScreenHeight/camera.MinZoom)))
+		}
+		g.world.Clear()
+		world = g.world.SubImage(image.Rect(0, 0, viewW, viewH)).(*ebiten.Image)
+	}
+
+	for _, prop := range g.level.Props {
+		sx, sy := cam.WorldToScreen(prop.X, prop.Y)
+		if sx < -propCull || sx > viewW+propCull || sy < 0 || sy > viewH+propCull {
+			continue
+		}
+		g.theme.DrawProp(world, prop.Kind, float64(sx), float64(sy), prop.Scale)
+	}
+
+	t := float64(g.ticks) / 60
+	g.drawZones(world, cam, viewW, viewH, t, false)
 
 	for _, plat := range g.level.Platforms {
-		sx, sy := g.camera.WorldToScreen(float64(plat.Min.X), float64(plat.Min.Y))
-		if sx+plat.Dx() < 0 || sy+plat.Dy() < 0 || sx > ScreenWidth || sy > ScreenHeight {
+		sx, sy := cam.WorldToScreen(float64(plat.Min.X), float64(plat.Min.Y))
+		if sx+plat.Dx() < 0 || sy+plat.Dy() < 0 || sx > viewW || sy > viewH {
 			continue
 		}
-		img := ebiten.NewImage(plat.Dx(), plat.Dy())
-		img.Fill(color.RGBA{R: 0x4a, G: 0x7c, B: 0x59, A: 0xff})
-		op := &ebiten.DrawImageOptions{}
-		op.GeoM.Translate(float64(sx), float64(sy))
-		screen.DrawImage(img, op)
+		g.theme.DrawPlatform(world, plat, sx, sy)
+	}
+	for _, sl := range g.level.Slopes {
+		r := sl.Rect
+		sx, sy := cam.WorldToScreen(float64(r.Min.X), float64(r.Min.Y))
+		if sx+r.Dx() < 0 || sy+r.Dy() < 0 || sx > viewW || sy > viewH {
+			continue
+		}
+		g.theme.DrawSlope(world, r, sl.Rising, sx, sy)
+	}
+	for _, m := range g.level.Materials {
+		r := m.Rect
+		sx, sy := cam.WorldToScreen(float64(r.Min.X), float64(r.Min.Y))
+		if sx+r.Dx() < 0 || sy+r.Dy() < 0 || sx > viewW || sy > viewH {
+			continue
+		}
+		g.theme.DrawMaterial(world, m.Kind, r, m.Speed, t, sx, sy)
+	}
+	for _, pad := range g.level.Pads {
+		r := pad.Rect
+		sx, sy := cam.WorldToScreen(float64(r.Min.X), float64(r.Min.Y))
+		if sx+r.Dx() < 0 || sy+r.Dy() < 0 || sx > viewW || sy > viewH {
+			continue
+		}
+		g.theme.DrawPad(world, pad.Kind, r, pad.VX, pad.VY, sx, sy)
+	}
+	for i, b := range g.level.Blocks {
+		r := b.Rect
+		sx, sy := cam.WorldToScreen(float64(r.Min.X), float64(r.Min.Y))
+		if !g.level.BlockSolid(i) || sx+r.Dx() < 0 || sy+r.Dy() < 0 || sx > viewW || sy > viewH {
+			continue
+		}
+		crumble := 0.0
+		if st := g.level.Live.Blocks[i]; st.Crumbling {
+			crumble = 1 - st.Timer/level.CrumbleTime
+			sx += int(2 * math.Sin(st.Timer*70)) // shake as it goes
+		}
+		g.theme.DrawBlock(world, b.Kind, r, crumble, sx, sy)
+	}
+	for i, gate := range g.level.Gates {
+		solid := g.level.GateSolid(i)
+		for _, r := range gate.Rects {
+			sx, sy := cam.WorldToScreen(float64(r.Min.X), float64(r.Min.Y))
+			if sx+r.Dx() < 0 || sy+r.Dy() < 0 || sx > viewW || sy > viewH {
+				continue
+			}
+			g.theme.DrawGate(world, gate.Kind, r, i, solid, sx, sy)
+		}
+	}
+	for i, t := range g.level.Triggers {
+		r := t.Rect
+		on := g.level.Live.Triggers[i].On
+		sx, sy := cam.WorldToScreen(float64(r.Min.X), float64(r.Min.Y))
+		if t.Kind == level.TriggerKey && on || sx+r.Dx() < 0 || sy+r.Dy() < 0 || sx > viewW || sy > viewH {
+			continue // a key picked up is gone
+		}
+		g.theme.DrawTrigger(world, t.Kind, r, g.level.GateIndex(t.Targets[0]), on, sx, sy)
+	}
+	for _, c := range g.level.Climbables {
+		r := c.Rect
+		sx, sy := cam.WorldToScreen(float64(r.Min.X), float64(r.Min.Y))
+		if sx+r.Dx() < 0 || sy+r.Dy() < 0 || sx > viewW || sy > viewH {
+			continue
+		}
+		g.theme.DrawClimbable(world, c.Kind, r, sx, sy)
 	}
 
 	// Goal
 	goal := g.level.Goal
-	sgx, sgy := g.camera.WorldToScreen(float64(goal.Min.X), float64(goal.Min.Y))
-	goalImg := ebiten.NewImage(goal.Dx(), goal.Dy())
-	goalImg.Fill(color.RGBA{R: 0xea, G: 0xc5, B: 0x4f, A: 0xff})
-	opGoal := &ebiten.DrawImageOptions{}
-	opGoal.GeoM.Translate(float64(sgx), float64(sgy))
-	screen.DrawImage(goalImg, opGoal)
+	sgx, sgy := cam.WorldToScreen(float64(goal.Min.X), float64(goal.Min.Y))
+	g.theme.DrawGoal(world, goal, sgx, sgy)
 
-	// Player
-	px, py := g.camera.WorldToScreen(g.player.X, g.player.Y)
-	g.player.Draw(screen, px, py)
+	// Assist jump preview
+	if g.assist.ShowArc {
+		for _, st := range g.seats {
+			for i := 0; i < len(st.arc); i += 4 {
+				ax, ay := cam.WorldToScreen(st.arc[i].X, st.arc[i].Y)
+				vector.FillCircle(world, float32(ax), float32(ay), 3, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xc0}, true)
+			}
+		}
+	}
 
-	// HUD
-	ebitenutil.DebugPrint(screen, fmt.Sprintf("Level %d / %d   [Tab] switch shape", g.levelNum, TotalLevels))
+	if g.state != stateEditing {
+		// Players
+		for _, st := range g.seats {
+			px, py := cam.WorldToScreen(st.player.X, st.player.Y)
+			st.player.Draw(world, px, py)
+		}
+	}
+	g.drawZones(world, cam, viewW, viewH, t, true) // players show through the water
+	if g.state != stateEditing {
+		g.effects.sys.Draw(world, cam.X, cam.Y)
+		if cam == g.seats[0].camera {
+			g.overlay.Draw(world, cam, g.level, g.seats[0].player, viewW, viewH)
+		}
+	}
+
+	if world != dst {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(cam.Zoom, cam.Zoom)
+		op.Filter = ebiten.FilterLinear
+		dst.DrawImage(world, op)
+	}
+}
+
+// drawZones draws the level's water zones, or the rest.
+func (g *Game) drawZones(world *ebiten.Image, cam *camera.Camera, viewW, viewH int, t float64, water bool) {
+	for _, z := range g.level.Zones {
+		r := z.Rect
+		sx, sy := cam.WorldToScreen(float64(r.Min.X), float64(r.Min.Y))
+		if (z.Kind == level.ZoneWater) != water || sx+r.Dx() < 0 || sy+r.Dy() < 0 || sx > viewW || sy > viewH {
+			continue
+		}
+		g.theme.DrawZone(world, z.Kind, r, z.WindX, z.WindY, t, sx, sy)
+	}
+}
+
+// updateCelebration sets off fireworks over the goal, then moves to the next level.
+func (g *Game) updateCelebration() {
+	if (celebrateTicks-g.celebrate)%fireworkEvery == 0 && !g.resim {
+		goal := g.level.Goal
+		g.effects.firework(float64(goal.Min.X+goal.Max.X)/2, float64(goal.Min.Y))
+	}
+	g.celebrate--
+	if g.celebrate == 0 {
+		g.nextLevel()
+	}
+}
+
+// nextLevel moves on from a finished level.
+func (g *Game) nextLevel() {
+	switch {
+	case g.gen != nil:
+		// generated levels go on forever
+		if err := g.generate(g.gen.Next(), g.levelNum+1); err != nil {
+			g.state, g.loadErr = stateFailed, err
+		}
+	case g.pack != nil && g.levelNum < g.pack.Len():
+		if err := g.loadLevel(g.levelNum + 1); err != nil {
+			g.state, g.loadErr = stateFailed, err
+		}
+	default:
+		g.state = stateWon
+	}
+}
+
+// startEditing opens the level editor on the current level.
+func (g *Game) startEditing() {
+	if g.editor == nil {
+		g.editor = editor.New(g.level, player.Envelope(1.0/60.0))
+	}
+	g.state = stateEditing
+	g.playtest = false
+}
+
+// startPlaytest leaves the editor and plays the level from x, y.
+func (g *Game) startPlaytest(x, y float64) {
+	st := g.seats[0]
+	st.player.Respawn(x, y)
+	st.done = false
+	g.level.Reset()
+	g.playX, g.playY = x, y
+	st.safeX, st.safeY = x, y
+	g.levelTicks = 0
+	g.state = statePlaying
+	g.playtest = true
+}
+
+func (g *Game) updateEditor() {
+	switch g.editor.Update(g.camera, level.ScreenWidth, level.ScreenHeight) {
+	case editor.Playtest:
+		x, y := g.editor.Cursor()
+		g.startPlaytest(x-player.Width/2, y-player.Height)
+	case editor.Save:
+		path, err := g.levelPath()
+		if err == nil {
+			err = g.level.Save(path)
+		}
+		if err != nil {
+			g.notice = "Save failed: " + err.Error()
+			return
+		}
+		g.editor.Dirty = false
+		g.notice = "Saved " + path
+		if path == g.levelFile {
+			g.stamp, _ = stampOf(path) // don't reload our own save
+		}
+	}
+}
+
+// levelPath returns where the editor saves the current level: its file, or for a
+// built-in level, a file in the user's levels directory.
+func (g *Game) levelPath() (string, error) {
+	if g.levelFile != "" {
+		return g.levelFile, nil
+	}
+	dir, err := settings.Dir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "levels")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	if g.gen != nil {
+		return filepath.Join(dir, fmt.Sprintf("seed%d.json", g.gen.Seed)), nil
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s-level%d.json", g.pack.ID, g.levelNum)), nil
+}
+
+// loadTheme finds the current level's theme, falling back to the default theme.
+func (g *Game) loadTheme() *theme.Theme {
+	var userDir string
+	if dir, err := settings.Dir(); err == nil {
+		userDir = filepath.Join(dir, "themes")
+	}
+	t, err := theme.Find(userDir, g.level.Theme)
+	if err == nil {
+		return t
+	}
+	g.notice = err.Error()
+	t, err = theme.Find("", theme.DefaultName)
+	if err != nil {
+		panic(err) // the default theme is embedded
+	}
+	return t
+}
+
+// publish sends an event of kind k at st's player's position.
+func (g *Game) publish(st *seat, k event.Kind) {
+	p := st.player
+	g.events.Publish(event.Event{
+		Kind:     k,
+		X:        p.CenterX(),
+		Y:        p.CenterY(),
+		Shape:    int(p.Shape),
+		Level:    g.levelNum,
+		Material: p.Ground.Kind,
+	})
+}
+
+// updateLevel runs the level's crumbling and respawn timers and its triggers.
+func (g *Game) updateLevel(dt float64) {
+	if g.race {
+		for _, st := range g.seats {
+			g.swapLive(st)
+			g.updateLevelFor(dt, []*seat{st})
+			g.swapLive(st)
+		}
+		return
+	}
+	g.updateLevelFor(dt, g.seats)
+}
+
+// updateLevelFor runs the level for a tick with the players in seats.
+func (g *Game) updateLevelFor(dt float64, seats []*seat) {
+	var players []image.Rectangle
+	for _, st := range seats {
+		players = append(players, st.player.Rect())
+	}
+	fell, changed := g.level.Update(dt, players)
+	for _, r := range fell {
+		g.publishAt(event.Break, r)
+	}
+	for _, r := range changed {
+		g.publishAt(event.Trigger, r)
+	}
+}
+
+// publishAt publishes an event of kind k at the center of r.
+func (g *Game) publishAt(k event.Kind, r image.Rectangle) {
+	g.events.Publish(event.Event{
+		Kind: k,
+		X:    float64(r.Min.X+r.Max.X) / 2,
+		Y:    float64(r.Min.Y+r.Max.Y) / 2,
+	})
+}
+
+// publishPlayerEvents reports what st's player did during the last step.
+func (g *Game) publishPlayerEvents(st *seat) {
+	p := st.player
+	if p.Landed {
+		g.events.Publish(event.Event{
+			Kind:     event.Land,
+			X:        p.CenterX(),
+			Y:        p.CenterY(),
+			Speed:    p.LandSpeed,
+			Material: p.Ground.Kind,
+		})
+	}
+	if p.Jumped {
+		g.publish(st, event.Jump)
+	}
+	if p.Launched {
+		g.publish(st, event.Launch)
+	}
+	if p.Splashed {
+		g.publish(st, event.Splash)
+	}
+	if !p.Broke.Empty() {
+		g.publishAt(event.Break, p.Broke)
+	}
+	if !p.Pulled.Empty() {
+		g.publishAt(event.Trigger, p.Pulled)
+	}
+	if p.ShapeChanged {
+		g.publish(st, event.ShapeChange)
+	}
+	for _, t := range p.TransitionsSince(st.seen) {
+		g.events.Publish(event.Event{
+			Kind:  event.StateChange,
+			X:     p.CenterX(),
+			Y:     p.CenterY(),
+			Shape: int(p.Shape),
+			From:  t.From,
+			To:    t.To,
+		})
+	}
+	st.seen = p.Transitions()
+}
+
+// levelKey names the current level in the records book. Co-op times are kept apart.
+func (g *Game) levelKey() string {
+	var key string
+	switch {
+	case g.gen != nil:
+		key = fmt.Sprintf("seed:%d:%g", g.gen.Seed, g.gen.Difficulty)
+	case g.levelFile != "":
+		key = "file:" + filepath.Base(g.levelFile)
+	default:
+		key = records.LevelKey(g.pack.ID, g.levelNum)
+	}
+	if len(g.seats) > 1 {
+		key += " coop"
+	}
+	return key
+}
+
+// finishedRun returns the run on the level just finished.
+func (g *Game) finishedRun() records.Run {
+	return records.Run{
+		Level:    g.levelKey(),
+		Ticks:    g.levelTicks,
+		Assisted: g.assist.Active(),
+		Date:     time.Now(),
+	}
+}
 
-	if g.state == stateWon {
-		ebitenutil.DebugPrint(screen, "\n\n  You beat all three levels! Congratulations!")
+// recordRun stores run, a finish of level levelNum of the pack being played.
+func (g *Game) recordRun(run records.Run, levelNum int) {
+	g.lastRun = run
+	g.newBest = false
+	if g.records == nil {
+		return
+	}
+	g.newBest = g.records.Add(g.lastRun)
+	progressed := false
+	if g.pack != nil {
+		done := g.records.Completed(g.pack.ID)
+		g.records.Complete(g.pack.ID, levelNum)
+		progressed = g.records.Completed(g.pack.ID) > done
+	}
+	// save straight away, so a crash or a kill doesn't lose the run
+	if (g.newBest || progressed) && g.recordsAt != "" {
+		if err := g.records.Save(g.recordsAt); err != nil {
+			g.notice = fmt.Sprintf("saving records: %v", err)
+		}
 	}
 }
 
+func (g *Game) drawTimer(screen *ebiten.Image) {
+	msg := fmt.Sprintf("Time %.2f", float64(g.levelTicks)/60)
+	if g.records != nil {
+		if best, ok := g.records.BestFor(g.levelKey(), g.assist.Active()); ok {
+			msg += fmt.Sprintf("  Best %.2f", best.Seconds())
+		}
+	}
+	if g.assist.Active() {
+		msg += "  [assisted]"
+	}
+	if g.lastRun.Ticks > 0 {
+		msg += fmt.Sprintf("\nLast %s: %.2f", g.lastRun.Level, g.lastRun.Seconds())
+		if g.newBest {
+			msg += "  New best!"
+		}
+	}
+	ebitenutil.DebugPrintAt(screen, msg, 0, 16)
+}
+
 // Layout returns the logical screen size.
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return ScreenWidth, ScreenHeight
+	return level.ScreenWidth, level.ScreenHeight
+}
+
+// submitRun sends the level just finished to the leaderboard in the background.
+// Assisted runs and level files don't go on the leaderboard.
+func (g *Game) submitRun() {
+	if g.board == nil || len(g.seats) > 1 || g.assist.Active() || g.levelFile != "" {
+		return
+	}
+	r := &input.Replay{Generate: g.gen, Shape: int(g.runShape), Frames: slices.Clone(g.run)}
+	if g.gen == nil {
+		r.Pack, r.Level = g.pack.ID, g.levelNum
+	}
+	ticks, board, name := g.levelTicks, g.board, g.name
+	go func() {
+		res, err := board.Submit(context.Background(), name, ticks, r)
+		msg := "Leaderboard: " + fmt.Sprint(err)
+		if err == nil {
+			msg = fmt.Sprintf("Leaderboard: %.2fs, you're #%d on this level", res.Entry.Seconds(), res.Rank)
+		}
+		select {
+		case g.submitted <- msg:
+		default: // too many at once to show anyway
+		}
+	}()
 }
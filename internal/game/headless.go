@@ -0,0 +1,31 @@
+package game
+
+// Result summarizes a headless run.
+type Result struct {
+	Ticks    int
+	Level    int
+	Won      bool
+	X, Y     float64
+	Grounded bool
+}
+
+// RunHeadless advances the game n ticks without a window or drawing. The result
+// reports the first player.
+func (g *Game) RunHeadless(n int) (Result, error) {
+	for i := 0; i < n && g.state != stateWon; i++ {
+		if err := g.Update(); err != nil {
+			return Result{}, err
+		}
+		if g.state == stateFailed {
+			return Result{}, g.loadErr
+		}
+	}
+	return Result{
+		Ticks:    g.ticks,
+		Level:    g.levelNum,
+		Won:      g.state == stateWon,
+		X:        g.seats[0].player.X,
+		Y:        g.seats[0].player.Y,
+		Grounded: g.seats[0].player.Grounded,
+	}, nil
+}
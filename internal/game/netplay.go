@@ -0,0 +1,215 @@
+package game
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"slices"
+
+	"platform-game-one/internal/input"
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/levelgen"
+	"platform-game-one/internal/netplay"
+	"platform-game-one/internal/player"
+	"platform-game-one/internal/records"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// updateNet runs a netplay tick. The session steps the game through netSim, maybe
+// several times after a rollback; the camera then follows the result once.
+func (g *Game) updateNet() error {
+	if g.state == stateWon || g.state == stateFailed {
+		return nil
+	}
+	local := g.seats[g.net.Local()]
+	if _, err := g.net.Advance(netSim{g}, local.input.Poll()); err != nil {
+		return err
+	}
+	g.recordConfirmed()
+	if g.state == statePlaying {
+		g.follow()
+	}
+	return nil
+}
+
+// pendingRun is a netplay finish waiting for the session to confirm its tick.
+type pendingRun struct {
+	frame    int // the session tick the goal was reached on
+	run      records.Run
+	levelNum int
+}
+
+// recordConfirmed records the finishes on ticks no rollback can undo any more.
+func (g *Game) recordConfirmed() {
+	confirmed := g.net.Confirmed()
+	n := 0
+	for _, p := range g.unconfirmed {
+		if p.frame >= confirmed {
+			break
+		}
+		g.recordRun(p.run, p.levelNum)
+		n++
+	}
+	g.unconfirmed = slices.Delete(g.unconfirmed, 0, n)
+}
+
+// drawNet shows how the connection is doing.
+func (g *Game) drawNet(screen *ebiten.Image) {
+	if g.net == nil {
+		return
+	}
+	st := g.net.Stats()
+	msg := fmt.Sprintf("Online as player %d   rollbacks %d   ahead %d", g.net.Local()+1, st.Rollbacks, st.Ahead)
+	if g.net.Waiting() {
+		msg = "Waiting for the other player..."
+	}
+	ebitenutil.DebugPrintAt(screen, msg, 0, level.ScreenHeight-48)
+}
+
+// netSim is the part of the game the netplay session simulates: everything from
+// the players' input to finishing the level, but not the camera, sound or effects.
+type netSim struct{ g *Game }
+
+// Step runs one tick of play or of the celebration that follows it.
+func (s netSim) Step(in [netplay.Players]input.Buttons, resim bool) {
+	g := s.g
+	g.resim, g.events.Muted = resim, resim
+	switch g.state {
+	case statePlaying:
+		g.tick(in[:])
+	case stateCelebrating:
+		g.updateCelebration()
+	}
+	g.resim, g.events.Muted = false, false
+	g.netFrame++
+}
+
+// netState is a saved netSim.
+type netState struct {
+	seats      []seatState
+	level      *level.Level // only its Live part changes during play, so the rest is shared
+	live       level.Live
+	levelNum   int
+	gen        *levelgen.Params
+	state      gameState
+	ticks      int
+	levelTicks int
+	celebrate  int
+	frame      int
+}
+
+type seatState struct {
+	player       player.Player
+	held         input.Buttons
+	safeX, safeY float64
+	done         bool
+	finish       int
+}
+
+// Save copies the simulated part of the game.
+func (s netSim) Save() netplay.State {
+	g := s.g
+	st := &netState{
+		level:      g.level,
+		live:       g.level.Live.Clone(),
+		levelNum:   g.levelNum,
+		gen:        g.gen,
+		state:      g.state,
+		ticks:      g.ticks,
+		levelTicks: g.levelTicks,
+		celebrate:  g.celebrate,
+		frame:      g.netFrame,
+	}
+	for _, seat := range g.seats {
+		st.seats = append(st.seats, seatState{
+			player: *seat.player,
+			held:   seat.held,
+			safeX:  seat.safeX,
+			safeY:  seat.safeY,
+			done:   seat.done,
+			finish: seat.finish,
+		})
+	}
+	return st
+}
+
+// Load puts back a state from Save.
+func (s netSim) Load(state netplay.State) {
+	g, st := s.g, state.(*netState)
+	if g.level != st.level {
+		g.theme = nil
+	}
+	g.level, g.levelNum, g.gen = st.level, st.levelNum, st.gen
+	g.level.Live = st.live.Clone()
+	g.state, g.ticks, g.levelTicks, g.celebrate = st.state, st.ticks, st.levelTicks, st.celebrate
+	// finishes from the ticks being rewound are dropped; resimulating them finds
+	// them again if the real input still gets there
+	g.netFrame = st.frame
+	g.unconfirmed = slices.DeleteFunc(g.unconfirmed, func(p pendingRun) bool { return p.frame >= st.frame })
+	for i, seat := range g.seats {
+		ss := st.seats[i]
+		*seat.player = ss.player
+		seat.held, seat.safeX, seat.safeY = ss.held, ss.safeX, ss.safeY
+		seat.done, seat.finish = ss.done, ss.finish
+	}
+}
+
+// Checksum hashes the state that affects play.
+func (st *netState) Checksum() uint64 {
+	h := fnv.New64a()
+	var buf []byte
+	put := func(v uint64) { buf = binary.LittleEndian.AppendUint64(buf, v) }
+	putF := func(v float64) { put(math.Float64bits(v)) }
+	putB := func(v bool) {
+		if v {
+			put(1)
+		} else {
+			put(0)
+		}
+	}
+	put(uint64(st.levelNum))
+	put(uint64(st.state))
+	put(uint64(st.ticks))
+	put(uint64(st.levelTicks))
+	put(uint64(st.celebrate))
+	for _, b := range st.live.Blocks {
+		putB(b.Crumbling)
+		putB(b.Broken)
+		putF(b.Timer)
+	}
+	for _, t := range st.live.Triggers {
+		putB(t.On)
+		putF(t.Timer)
+	}
+	for _, ss := range st.seats {
+		p := &ss.player
+		putF(p.X)
+		putF(p.Y)
+		putF(p.VX)
+		putF(p.VY)
+		putF(p.Carry)
+		putF(p.Drift)
+		putF(p.Run)
+		putF(p.Ground.Speed)
+		put(uint64(slices.Index(level.MaterialKinds, p.Ground.Kind) + 1))
+		putF(p.PadTime)
+		putB(p.Pounding)
+		putB(p.Climbing)
+		putF(p.GrabTime)
+		putF(p.CoyoteTime)
+		putF(p.JumpBuffer)
+		putB(p.Grounded)
+		put(uint64(p.State()))
+		put(uint64(p.Shape))
+		put(uint64(ss.held))
+		putF(ss.safeX)
+		putF(ss.safeY)
+		putB(ss.done)
+		put(uint64(ss.finish))
+	}
+	h.Write(buf)
+	return h.Sum64()
+}
@@ -0,0 +1,195 @@
+package game
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/theme"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Split is how the screen is divided between racers.
+type Split int
+
+const (
+	SplitVertical   Split = iota // side by side
+	SplitHorizontal              // one above the other
+)
+
+var splitNames = []string{"vertical", "horizontal"}
+
+func (s Split) String() string {
+	if s < 0 || int(s) >= len(splitNames) {
+		return fmt.Sprintf("Split(%d)", int(s))
+	}
+	return splitNames[s]
+}
+
+// ParseSplit returns the split with the given name.
+func ParseSplit(name string) (Split, error) {
+	for i, n := range splitNames {
+		if n == name {
+			return Split(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown split %q (want vertical or horizontal)", name)
+}
+
+const dividerWidth = 4
+
+// raceCutoff is how long the others have to finish once the first racer reaches
+// the goal, so one who can't doesn't hold up the results.
+const raceCutoff = 30 * 60 // ticks
+
+var dividerColor = color.RGBA{A: 0xff}
+
+// viewports returns each racer's part of the screen.
+func (g *Game) viewports() []image.Rectangle {
+	n := len(g.seats)
+	vps := make([]image.Rectangle, n)
+	for i := range vps {
+		if g.split == SplitVertical {
+			w := level.ScreenWidth / n
+			vps[i] = image.Rect(i*w, 0, (i+1)*w, level.ScreenHeight)
+		} else {
+			h := level.ScreenHeight / n
+			vps[i] = image.Rect(0, i*h, level.ScreenWidth, (i+1)*h)
+		}
+	}
+	return vps
+}
+
+// toggleSplit switches between side by side and stacked viewports.
+func (g *Game) toggleSplit() {
+	g.split = 1 - g.split
+	g.views = nil
+	g.theme = nil // the backgrounds are laid out for the viewport size
+}
+
+// followRacers moves each racer's camera, clamped to the level for its viewport size.
+func (g *Game) followRacers() {
+	for i, vp := range g.viewports() {
+		p := g.seats[i].player
+		g.seats[i].camera.Update(p.CenterX(), p.CenterY(), g.level.Width, g.level.Height, vp.Dx(), vp.Dy())
+	}
+}
+
+// swapLive swaps a racer's own blocks and triggers with the level's, before and
+// again after anything that plays or draws the level for them. Each racer has their
+// own, so one breaking a block or opening a door doesn't change the level for the
+// other. Outside a race it does nothing.
+func (g *Game) swapLive(st *seat) {
+	if g.race {
+		g.level.Live, st.live = st.live, g.level.Live
+	}
+}
+
+// firstFinish returns the race ticks when the first racer reached the goal.
+func (g *Game) firstFinish() (ticks int, ok bool) {
+	for _, st := range g.seats {
+		if st.done && (!ok || st.finish < ticks) {
+			ticks, ok = st.finish, true
+		}
+	}
+	return ticks, ok
+}
+
+// raceOver reports whether every racer has finished or the cutoff after the first has passed.
+func (g *Game) raceOver() bool {
+	first, ok := g.firstFinish()
+	return g.allDone() || ok && g.levelTicks-first >= raceCutoff
+}
+
+// updateResults waits on the results screen for a key to start the next level.
+func (g *Game) updateResults() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		g.nextLevel()
+	}
+}
+
+// finishOrder returns the player numbers in the order they finished, then those
+// who didn't finish.
+func (g *Game) finishOrder() []int {
+	order := make([]int, len(g.seats))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		sa, sb := g.seats[order[a]], g.seats[order[b]]
+		if sa.done != sb.done {
+			return sa.done
+		}
+		return sa.finish < sb.finish
+	})
+	return order
+}
+
+// drawRace draws each racer's view into their part of the screen, then the race HUD.
+func (g *Game) drawRace(screen *ebiten.Image) {
+	vps := g.viewports()
+	if g.theme == nil {
+		g.theme = theme.NewRenderer(g.loadTheme(), vps[0].Dx(), vps[0].Dy())
+	}
+	if g.views == nil {
+		for _, vp := range vps {
+			g.views = append(g.views, ebiten.NewImage(vp.Dx(), vp.Dy()))
+		}
+	}
+	for i, vp := range vps {
+		st, view := g.seats[i], g.views[i]
+		view.Clear()
+		g.swapLive(st)
+		g.drawView(view, st.camera, vp.Dx(), vp.Dy())
+		g.swapLive(st)
+
+		label := fmt.Sprintf("Player %d", i+1)
+		if st.done {
+			label += fmt.Sprintf("  finished in %.2fs", float64(st.finish)/60)
+		} else if first, ok := g.firstFinish(); ok && g.state == statePlaying {
+			label += fmt.Sprintf("  %.0fs left to finish", math.Ceil(float64(first+raceCutoff-g.levelTicks)/60))
+		}
+		ebitenutil.DebugPrintAt(view, label, 8, vp.Dy()-24)
+
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(float64(vp.Min.X), float64(vp.Min.Y))
+		screen.DrawImage(view, op)
+	}
+	if g.split == SplitVertical {
+		vector.FillRect(screen, float32(vps[1].Min.X-dividerWidth/2), 0, dividerWidth, level.ScreenHeight, dividerColor, false)
+	} else {
+		vector.FillRect(screen, 0, float32(vps[1].Min.Y-dividerWidth/2), level.ScreenWidth, dividerWidth, dividerColor, false)
+	}
+
+	ebitenutil.DebugPrint(screen, fmt.Sprintf("Race: %s   Time %.2f   [F4] %s split",
+		g.level.Name, float64(g.levelTicks)/60, 1-g.split))
+	g.drawReloadError(screen)
+	if g.notice != "" {
+		ebitenutil.DebugPrintAt(screen, g.notice, 0, level.ScreenHeight-16)
+	}
+
+	switch g.state {
+	case stateResults:
+		msg := "Race results\n\n"
+		for place, i := range g.finishOrder() {
+			if !g.seats[i].done {
+				msg += fmt.Sprintf("  -. Player %d   DNF\n", i+1)
+				continue
+			}
+			msg += fmt.Sprintf("  %d. Player %d   %.2fs\n", place+1, i+1, float64(g.seats[i].finish)/60)
+		}
+		msg += "\n[Enter] next level"
+		ebitenutil.DebugPrintAt(screen, msg, level.ScreenWidth/2-80, level.ScreenHeight/2-60)
+	case stateWon:
+		ebitenutil.DebugPrintAt(screen, "That was the last level. Thanks for racing!", level.ScreenWidth/2-130, level.ScreenHeight/2)
+	case stateFailed:
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Level %d failed to load:\n%v", g.levelNum+1, g.loadErr), level.ScreenWidth/2-130, level.ScreenHeight/2)
+	}
+}
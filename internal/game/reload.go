@@ -0,0 +1,108 @@
+package game
+
+import (
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/player"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// reloadTicks is how often a watched level file is checked for changes.
+const reloadTicks = 30
+
+var bannerColor = color.RGBA{R: 0xb0, G: 0x20, B: 0x20, A: 0xe0}
+
+// fileStamp is what changes when a file is written.
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+func stampOf(path string) (fileStamp, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{mod: fi.ModTime(), size: fi.Size()}, nil
+}
+
+// watchLevel reloads the level file when it changes on disk. It waits while the
+// editor is open or holds unsaved changes, so nothing made there is lost.
+func (g *Game) watchLevel() {
+	if !g.watch || (g.state != statePlaying && g.state != stateWon) {
+		return
+	}
+	g.watchTicks++
+	if g.watchTicks%reloadTicks != 0 || g.playtest || (g.editor != nil && g.editor.Dirty) {
+		return
+	}
+	stamp, err := stampOf(g.levelFile)
+	if err != nil || stamp == g.stamp {
+		return // a missing file is usually an editor partway through saving
+	}
+	g.stamp = stamp
+	lv, err := level.Load(g.levelFile)
+	if err != nil {
+		g.reloadErr = "Reload failed, still playing the last good version: " + err.Error()
+		return
+	}
+	g.reloadErr = ""
+	g.reload(lv)
+}
+
+// reload swaps in a new version of the level file, leaving the players where they
+// are unless the new geometry is in the way.
+func (g *Game) reload(lv *level.Level) {
+	if lv.Name == "" {
+		lv.Name = filepath.Base(g.levelFile)
+	}
+	g.notice = "Reloaded " + filepath.Base(g.levelFile)
+	if g.state == stateWon {
+		g.setLevel(lv, g.levelNum) // the run was over, so start another
+		return
+	}
+	g.level = lv
+	for _, st := range g.seats {
+		st.live = lv.Live.Clone()
+	}
+	g.theme = nil
+	g.editor = nil // it was editing the old level
+	for i, st := range g.seats {
+		g.clampOut(st, i)
+	}
+	ebiten.SetWindowTitle(g.Title())
+}
+
+// clampOut keeps st's player inside the level and moves it out of any platform or
+// slope it's now inside, the shortest way. A player buried too deep to push out goes
+// back to the start.
+func (g *Game) clampOut(st *seat, i int) {
+	p := st.player
+	p.X = math.Max(0, math.Min(p.X, float64(g.level.Width-player.Width)))
+	if !g.level.Solid(p.Rect()) {
+		return
+	}
+	p.X, p.Y, p.VX, p.VY, p.Grounded, p.Ground = g.level.ResolveCollision(p.Rect(), p.VX, p.VY)
+	if g.level.Solid(p.Rect()) {
+		x, y := g.startPos(i)
+		p.Respawn(x, y)
+	}
+	st.safeX, st.safeY = p.X, p.Y
+}
+
+// drawReloadError shows why the level file didn't reload, until it does.
+func (g *Game) drawReloadError(screen *ebiten.Image) {
+	if g.reloadErr == "" {
+		return
+	}
+	vector.FillRect(screen, 0, 32, level.ScreenWidth, 20, bannerColor, false)
+	ebitenutil.DebugPrintAt(screen, g.reloadErr, 8, 34)
+}
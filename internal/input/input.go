@@ -0,0 +1,41 @@
+package input
+
+// Buttons is a bit set of the actions held during one tick.
+type Buttons uint8
+
+const (
+	Left Buttons = 1 << iota
+	Right
+	Jump
+	Shape
+	Down
+	Up // climb; on the same key as Jump
+)
+
+// State is the input for one simulation tick.
+type State struct {
+	Held    Buttons
+	Pressed Buttons // held this tick but not the tick before
+}
+
+// Next builds the State for a tick given the buttons held on the previous tick.
+func Next(prev, held Buttons) State {
+	return State{Held: held, Pressed: held &^ prev}
+}
+
+// Down reports whether any of b is held.
+func (s State) Down(b Buttons) bool { return s.Held&b != 0 }
+
+// JustPressed reports whether any of b went down this tick.
+func (s State) JustPressed(b Buttons) bool { return s.Pressed&b != 0 }
+
+// Source yields the buttons held for each tick.
+type Source interface {
+	Poll() Buttons
+}
+
+// None is a Source with nothing ever pressed.
+type None struct{}
+
+// Poll returns no buttons.
+func (None) Poll() Buttons { return 0 }
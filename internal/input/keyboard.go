@@ -0,0 +1,57 @@
+package input
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// Keyboard reads buttons from the keyboard. Jump and Up can share a key, which then
+// holds both.
+type Keyboard struct {
+	Left, Right, Jump, Shape, Down, Up ebiten.Key
+}
+
+// DefaultKeyboard returns the W/A/S/D + Tab bindings.
+func DefaultKeyboard() *Keyboard {
+	return &Keyboard{
+		Left:  ebiten.KeyA,
+		Right: ebiten.KeyD,
+		Jump:  ebiten.KeyW,
+		Shape: ebiten.KeyTab,
+		Down:  ebiten.KeyS,
+		Up:    ebiten.KeyW,
+	}
+}
+
+// SecondKeyboard returns the arrow keys + right Shift bindings for a second player.
+func SecondKeyboard() *Keyboard {
+	return &Keyboard{
+		Left:  ebiten.KeyArrowLeft,
+		Right: ebiten.KeyArrowRight,
+		Jump:  ebiten.KeyArrowUp,
+		Shape: ebiten.KeyShiftRight,
+		Down:  ebiten.KeyArrowDown,
+		Up:    ebiten.KeyArrowUp,
+	}
+}
+
+// Poll returns the buttons whose keys are currently held.
+func (k *Keyboard) Poll() Buttons {
+	var b Buttons
+	if ebiten.IsKeyPressed(k.Left) {
+		b |= Left
+	}
+	if ebiten.IsKeyPressed(k.Right) {
+		b |= Right
+	}
+	if ebiten.IsKeyPressed(k.Jump) {
+		b |= Jump
+	}
+	if ebiten.IsKeyPressed(k.Shape) {
+		b |= Shape
+	}
+	if ebiten.IsKeyPressed(k.Down) {
+		b |= Down
+	}
+	if ebiten.IsKeyPressed(k.Up) {
+		b |= Up
+	}
+	return b
+}
@@ -0,0 +1,153 @@
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"platform-game-one/internal/levelgen"
+	"platform-game-one/internal/settings"
+)
+
+// Replay is a recorded run: which level it was played on and the buttons held each tick.
+type Replay struct {
+	Pack      string           `json:"pack,omitempty"`       // level pack; empty means the default pack
+	Level     int              `json:"level,omitempty"`      // level number in Pack, 1-based
+	LevelFile string           `json:"level_file,omitempty"` // set instead of Level for file levels
+	Generate  *levelgen.Params `json:"generate,omitempty"`   // set instead of Level for generated levels
+	Shape     int              `json:"shape"`
+	Assist    settings.Assist  `json:"assist"` // replays must run with the assists they were recorded with
+	Frames    []Buttons        `json:"-"`
+}
+
+// replayFile is the on-disk form; frames are run-length encoded as [count, buttons] pairs.
+type replayFile struct {
+	Version   int              `json:"version"`
+	Pack      string           `json:"pack,omitempty"`
+	Level     int              `json:"level,omitempty"`
+	LevelFile string           `json:"level_file,omitempty"`
+	Generate  *levelgen.Params `json:"generate,omitempty"`
+	Shape     int              `json:"shape"`
+	Assist    settings.Assist  `json:"assist"`
+	Runs      [][2]int         `json:"runs"`
+}
+
+const replayVersion = 1
+
+// MaxFrames is the longest replay read: ten hours. Frames are stored as runs, so
+// without a limit a few bytes could ask for any number of them.
+const MaxFrames = 10 * 60 * 60 * 60
+
+// MarshalJSON encodes the replay with run-length encoded frames.
+func (r *Replay) MarshalJSON() ([]byte, error) {
+	f := replayFile{Version: replayVersion, Pack: r.Pack, Level: r.Level, LevelFile: r.LevelFile, Generate: r.Generate, Shape: r.Shape, Assist: r.Assist}
+	for i := 0; i < len(r.Frames); {
+		j := i
+		for j < len(r.Frames) && r.Frames[j] == r.Frames[i] {
+			j++
+		}
+		f.Runs = append(f.Runs, [2]int{j - i, int(r.Frames[i])})
+		i = j
+	}
+	return json.Marshal(f)
+}
+
+// UnmarshalJSON decodes a replay written by MarshalJSON, of at most MaxFrames.
+func (r *Replay) UnmarshalJSON(data []byte) error {
+	return r.Decode(data, MaxFrames)
+}
+
+// Decode decodes a replay written by MarshalJSON, rejecting it if it's longer than
+// maxFrames before expanding any runs.
+func (r *Replay) Decode(data []byte, maxFrames int) error {
+	var f replayFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	if f.Version != replayVersion {
+		return fmt.Errorf("unsupported replay version %d", f.Version)
+	}
+	r.Pack, r.Level, r.LevelFile, r.Generate, r.Shape, r.Assist = f.Pack, f.Level, f.LevelFile, f.Generate, f.Shape, f.Assist
+	total := 0
+	for i, run := range f.Runs {
+		if run[0] < 0 || run[1] < 0 || run[1] > 0xff {
+			return fmt.Errorf("replay run %d is invalid: %v", i, run)
+		}
+		if run[0] > maxFrames-total {
+			return fmt.Errorf("replay is longer than %d frames", maxFrames)
+		}
+		total += run[0]
+	}
+	r.Frames = slices.Grow(r.Frames[:0], total)
+	for _, run := range f.Runs {
+		for n := 0; n < run[0]; n++ {
+			r.Frames = append(r.Frames, Buttons(run[1]))
+		}
+	}
+	return nil
+}
+
+// LoadReplay reads a replay file.
+func LoadReplay(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Replay{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("replay %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Save writes the replay to path.
+func (r *Replay) Save(path string) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Player is a Source that plays back a replay's frames, then reports nothing held.
+type Player struct {
+	frames []Buttons
+	pos    int
+}
+
+// NewPlayer plays back r from its first frame.
+func NewPlayer(r *Replay) *Player {
+	return &Player{frames: r.Frames}
+}
+
+// Poll returns the next recorded frame.
+func (p *Player) Poll() Buttons {
+	if p.pos >= len(p.frames) {
+		return 0
+	}
+	b := p.frames[p.pos]
+	p.pos++
+	return b
+}
+
+// Done reports whether every frame has been played.
+func (p *Player) Done() bool { return p.pos >= len(p.frames) }
+
+// Recorder is a Source that records everything polled from another Source.
+type Recorder struct {
+	src    Source
+	Replay *Replay
+}
+
+// NewRecorder records src into r.
+func NewRecorder(src Source, r *Replay) *Recorder {
+	return &Recorder{src: src, Replay: r}
+}
+
+// Poll polls the wrapped source and appends the result to the replay.
+func (r *Recorder) Poll() Buttons {
+	b := r.src.Poll()
+	r.Replay.Frames = append(r.Replay.Frames, b)
+	return b
+}
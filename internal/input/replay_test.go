@@ -0,0 +1,39 @@
+package input
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestReplayRoundTrip(t *testing.T) {
+	want := &Replay{Pack: "warmup", Level: 2, Frames: []Buttons{0, 0, Right, Right | Jump, Right, 0}}
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &Replay{}
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Pack != want.Pack || got.Level != want.Level || !slices.Equal(got.Frames, want.Frames) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestReplayDecodeLimitsFrames(t *testing.T) {
+	for _, runs := range []string{
+		`[[2000000000,0]]`,
+		`[[9223372036854775807,0],[9223372036854775807,0]]`,
+		`[[60,0],[41,1]]`,
+	} {
+		r := &Replay{}
+		if err := r.Decode([]byte(`{"version":1,"runs":`+runs+`}`), 100); err == nil {
+			t.Errorf("%s: decoded %d frames past the limit of 100", runs, len(r.Frames))
+		}
+	}
+	r := &Replay{}
+	if err := r.Decode([]byte(`{"version":1,"runs":[[60,0],[40,1]]}`), 100); err != nil || len(r.Frames) != 100 {
+		t.Errorf("100 frames at the limit: %d frames, %v", len(r.Frames), err)
+	}
+}
@@ -0,0 +1,78 @@
+package leaderboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"platform-game-one/internal/input"
+)
+
+// Client talks to a leaderboard Server.
+type Client struct {
+	BaseURL string // e.g. http://localhost:8080
+	HTTP    *http.Client
+}
+
+// NewClient returns a client for the server at baseURL.
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTP: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Submit sends a run that took ticks and returns its place on the leaderboard.
+func (c *Client) Submit(ctx context.Context, name string, ticks int, r *input.Replay) (*Accepted, error) {
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(Submission{Player: name, Ticks: ticks, Replay: raw})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/runs", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res := &Accepted{}
+	if err := c.do(req, http.StatusCreated, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Top returns the n fastest players on level.
+func (c *Client) Top(ctx context.Context, level string, n int) ([]Entry, error) {
+	q := url.Values{"level": {level}, "n": {fmt.Sprint(n)}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/top?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var list []Entry
+	if err := c.do(req, http.StatusOK, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// do sends req and decodes the response into v, or returns the server's error.
+func (c *Client) do(req *http.Request, want int, v any) error {
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != want {
+		var e errorBody
+		if json.NewDecoder(res.Body).Decode(&e) == nil && e.Error != "" {
+			return fmt.Errorf("leaderboard: %s", e.Error)
+		}
+		return fmt.Errorf("leaderboard: %s", res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,164 @@
+package leaderboard
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"platform-game-one/internal/input"
+	"platform-game-one/internal/pack"
+)
+
+const (
+	maxBody    = 1 << 20 // bytes; a half-hour replay is far smaller
+	maxName    = 32
+	defaultTop = 10
+	maxTop     = 100
+)
+
+// Submission is the body of POST /runs.
+type Submission struct {
+	Player string          `json:"player"`
+	Ticks  int             `json:"ticks"`  // the time the game measured; must match the replay
+	Replay json.RawMessage `json:"replay"` // an input.Replay starting on the level
+}
+
+// Accepted is the response to an accepted run.
+type Accepted struct {
+	Entry Entry `json:"entry"`
+	Rank  int   `json:"rank"` // the player's place on the level, 1-based
+}
+
+// Server is the leaderboard HTTP service:
+//
+//	POST /runs                         submit a Submission
+//	GET  /top?level=classic/1&n=10     the fastest players on a level
+type Server struct {
+	store            Store
+	packs            *pack.Registry // the packs runs may be played in
+	screenW, screenH int            // the game's screen size, which built-in levels are laid out for
+	mux              *http.ServeMux
+	now              func() time.Time
+}
+
+// NewServer returns a server keeping runs in store and accepting runs on the
+// levels of packs.
+func NewServer(store Store, packs *pack.Registry, screenW, screenH int) *Server {
+	s := &Server{store: store, packs: packs, screenW: screenW, screenH: screenH, mux: http.NewServeMux(), now: time.Now}
+	s.mux.HandleFunc("POST /runs", s.submit)
+	s.mux.HandleFunc("GET /top", s.top)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) submit(w http.ResponseWriter, r *http.Request) {
+	var sub Submission
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(&sub); err != nil {
+		status := http.StatusBadRequest
+		var big *http.MaxBytesError
+		if errors.As(err, &big) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, err)
+		return
+	}
+	if err := checkName(sub.Player); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	replay := &input.Replay{}
+	if err := replay.Decode(sub.Replay, MaxTicks); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	ticks, err := Verify(replay, s.packs, s.screenW, s.screenH)
+	if err == nil && ticks != sub.Ticks {
+		err = invalid("the replay reaches the goal in %d ticks, not %d", ticks, sub.Ticks)
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	// keep only the frames that count
+	replay.Frames = replay.Frames[:ticks]
+	raw, err := json.Marshal(replay)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	e := Entry{Player: sub.Player, Level: LevelKey(replay), Ticks: ticks, Date: s.now().UTC(), Replay: raw}
+	if err := s.store.Add(e); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	list, err := s.store.Top(e.Level, int(^uint(0)>>1))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	res := Accepted{Entry: e}
+	res.Entry.Replay = nil
+	for i, b := range list {
+		if b.Player == e.Player {
+			res.Rank = i + 1
+		}
+	}
+	writeJSON(w, http.StatusCreated, res)
+}
+
+func (s *Server) top(w http.ResponseWriter, r *http.Request) {
+	level := r.URL.Query().Get("level")
+	if level == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing level"))
+		return
+	}
+	n := defaultTop
+	if q := r.URL.Query().Get("n"); q != "" {
+		var err error
+		if n, err = strconv.Atoi(q); err != nil || n < 1 || n > maxTop {
+			writeError(w, http.StatusBadRequest, errors.New("n must be 1-100"))
+			return
+		}
+	}
+	list, err := s.store.Top(level, n)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func checkName(name string) error {
+	if strings.TrimSpace(name) == "" || len(name) > maxName {
+		return errors.New("player name must be 1-32 characters")
+	}
+	for _, r := range name {
+		if !unicode.IsPrint(r) {
+			return errors.New("player name must be printable")
+		}
+	}
+	return nil
+}
+
+// errorBody is the response to a failed request.
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorBody{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,250 @@
+package leaderboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"platform-game-one/internal/input"
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/pack"
+	"platform-game-one/internal/settings"
+)
+
+// dash is a flat level with the goal a short roll to the right of the start.
+const dash = `{
+  "name": "Dash",
+  "width": 1280,
+  "height": 720,
+  "start": [64, 640],
+  "goal": [300, 600, 360, 672],
+  "platforms": [[0, 672, 1280, 720]]
+}`
+
+// testPacks returns the built-in packs and a user pack "test" holding dash.
+func testPacks(t *testing.T) *pack.Registry {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "test")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	manifest := `{"name": "Test", "levels": [{"file": "dash.json"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "pack.json"), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "dash.json"), []byte(dash), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	packs, errs := pack.Discover(filepath.Dir(dir))
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+	return packs
+}
+
+// dashRun returns a replay that rolls right into dash's goal, with a few ticks
+// past it, and how many ticks it takes to get there.
+func dashRun(t *testing.T, packs *pack.Registry) (*input.Replay, int) {
+	t.Helper()
+	r := &input.Replay{Pack: "test", Level: 1, Frames: slices.Repeat([]input.Buttons{input.Right}, 5*60)}
+	ticks, err := Verify(r, packs, level.ScreenWidth, level.ScreenHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticks >= len(r.Frames) {
+		t.Fatalf("the run takes %d ticks, all of the replay", ticks)
+	}
+	return r, ticks
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *pack.Registry) {
+	t.Helper()
+	packs := testPacks(t)
+	srv := httptest.NewServer(NewServer(NewMemoryStore(), packs, level.ScreenWidth, level.ScreenHeight))
+	t.Cleanup(srv.Close)
+	return srv, packs
+}
+
+// post submits body to srv and returns the response status.
+func post(t *testing.T, srv *httptest.Server, body []byte) int {
+	t.Helper()
+	res, err := http.Post(srv.URL+"/runs", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	return res.StatusCode
+}
+
+func submission(t *testing.T, name string, ticks int, r *input.Replay) []byte {
+	t.Helper()
+	raw, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(Submission{Player: name, Ticks: ticks, Replay: raw})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestSubmitAcceptsVerifiedRun(t *testing.T) {
+	srv, packs := newTestServer(t)
+	r, ticks := dashRun(t, packs)
+	c := NewClient(srv.URL)
+	res, err := c.Submit(context.Background(), "Sam", ticks, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Rank != 1 || res.Entry.Ticks != ticks || res.Entry.Level != "test/1" {
+		t.Errorf("accepted %+v at rank %d, want test/1 in %d ticks at rank 1", res.Entry, res.Rank, ticks)
+	}
+	list, err := c.Top(context.Background(), "test/1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Player != "Sam" || list[0].Ticks != ticks {
+		t.Errorf("top is %+v, want Sam in %d ticks", list, ticks)
+	}
+}
+
+func TestSubmitRejects(t *testing.T) {
+	srv, packs := newTestServer(t)
+	run, ticks := dashRun(t, packs)
+	with := func(change func(r *input.Replay)) *input.Replay {
+		r := *run
+		change(&r)
+		return &r
+	}
+	tests := []struct {
+		name   string
+		body   []byte
+		status int
+	}{
+		{"a faster time than the replay", submission(t, "Sam", ticks-1, run), http.StatusUnprocessableEntity},
+		{"a slower time than the replay", submission(t, "Sam", ticks+1, run), http.StatusUnprocessableEntity},
+		{"a replay that never reaches the goal", submission(t, "Sam", ticks, with(func(r *input.Replay) { r.Frames = r.Frames[:ticks-1] })), http.StatusUnprocessableEntity},
+		{"an assisted run", submission(t, "Sam", ticks, with(func(r *input.Replay) { r.Assist = settings.Assist{Speed: 0.5} })), http.StatusUnprocessableEntity},
+		{"a level file", submission(t, "Sam", ticks, with(func(r *input.Replay) { r.Pack, r.Level, r.LevelFile = "", 0, "dash.json" })), http.StatusUnprocessableEntity},
+		{"an unknown pack", submission(t, "Sam", ticks, with(func(r *input.Replay) { r.Pack = "nope" })), http.StatusUnprocessableEntity},
+		{"no name", submission(t, "", ticks, run), http.StatusBadRequest},
+		{"a blank name", submission(t, "   ", ticks, run), http.StatusBadRequest},
+		{"a long name", submission(t, strings.Repeat("x", maxName+1), ticks, run), http.StatusBadRequest},
+		{"an unprintable name", submission(t, "Sam\x07", ticks, run), http.StatusBadRequest},
+		{"a broken replay", []byte(`{"player": "Sam", "ticks": 1, "replay": {"version": 99}}`), http.StatusBadRequest},
+		{"a body over the limit", []byte(`{"player": "` + strings.Repeat("x", maxBody) + `"}`), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := post(t, srv, tt.body); got != tt.status {
+				t.Errorf("status %d, want %d", got, tt.status)
+			}
+		})
+	}
+	list, err := NewClient(srv.URL).Top(context.Background(), "test/1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Errorf("rejected runs are on the leaderboard: %+v", list)
+	}
+}
+
+func TestTopQuery(t *testing.T) {
+	srv, _ := newTestServer(t)
+	tests := []struct {
+		query  string
+		status int
+	}{
+		{"level=test/1", http.StatusOK},
+		{"level=test/1&n=1", http.StatusOK},
+		{"level=test/1&n=100", http.StatusOK},
+		{"level=test/1&n=0", http.StatusBadRequest},
+		{"level=test/1&n=101", http.StatusBadRequest},
+		{"level=test/1&n=-3", http.StatusBadRequest},
+		{"level=test/1&n=ten", http.StatusBadRequest},
+		{"n=10", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		res, err := http.Get(srv.URL + "/top?" + tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.status {
+			t.Errorf("/top?%s: status %d, want %d", tt.query, res.StatusCode, tt.status)
+		}
+	}
+}
+
+func TestTopKeepsEachPlayersBest(t *testing.T) {
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Player: "Ann", Level: "a/1", Ticks: 300, Date: day},
+		{Player: "Ann", Level: "a/1", Ticks: 200, Date: day.Add(3 * time.Hour), Replay: json.RawMessage(`{}`)},
+		{Player: "Bob", Level: "a/1", Ticks: 200, Date: day.Add(time.Hour)},
+		{Player: "Cat", Level: "a/1", Ticks: 250, Date: day},
+		{Player: "Dan", Level: "a/2", Ticks: 100, Date: day},
+		{Player: "Eve", Level: "a/1", Ticks: 400, Date: day},
+	}
+	got := top(entries, "a/1", 3)
+	want := []Entry{entries[2], {Player: "Ann", Level: "a/1", Ticks: 200, Date: day.Add(3 * time.Hour)}, entries[3]}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		// Bob set 200 first, so keeps the place over Ann; replays are left out
+		if got[i].Player != want[i].Player || got[i].Ticks != want[i].Ticks || !got[i].Date.Equal(want[i].Date) || got[i].Replay != nil {
+			t.Errorf("place %d is %+v, want %+v", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs", "times.json")
+	s, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	added := []Entry{
+		{Player: "Ann", Level: "a/1", Ticks: 300, Date: day, Replay: json.RawMessage(`{"version":1}`)},
+		{Player: "Bob", Level: "a/1", Ticks: 200, Date: day.Add(time.Hour)},
+	}
+	for _, e := range added {
+		if err := s.Add(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.entries) != len(added) {
+		t.Fatalf("reopened with %+v, want %+v", s.entries, added)
+	}
+	var replay bytes.Buffer
+	if err := json.Compact(&replay, s.entries[0].Replay); err != nil || replay.String() != `{"version":1}` {
+		t.Fatalf("reopened with %+v, want %+v", s.entries, added)
+	}
+	list, err := s.Top("a/1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Player != "Bob" || list[1].Player != "Ann" || !list[1].Date.Equal(day) {
+		t.Errorf("top after reopening is %+v, want Bob then Ann", list)
+	}
+	if _, err := os.Stat(path + ".tmp"); err == nil {
+		t.Error("the temporary file was left behind")
+	}
+}
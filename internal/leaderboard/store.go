@@ -0,0 +1,130 @@
+package leaderboard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entry is one accepted run.
+type Entry struct {
+	Player string          `json:"player"`
+	Level  string          `json:"level"`
+	Ticks  int             `json:"ticks"`
+	Date   time.Time       `json:"date"`
+	Replay json.RawMessage `json:"replay,omitempty"` // kept so runs can be checked again
+}
+
+// Seconds returns the run time in seconds.
+func (e Entry) Seconds() float64 { return float64(e.Ticks) / 60 }
+
+// Store keeps accepted runs. Implementations are safe for concurrent use.
+type Store interface {
+	Add(e Entry) error
+	// Top returns the n fastest players on level, each with their best run, fastest first.
+	Top(level string, n int) ([]Entry, error)
+}
+
+// MemoryStore is a Store that forgets everything when the server stops.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore { return &MemoryStore{} }
+
+// Add stores e.
+func (m *MemoryStore) Add(e Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, e)
+	return nil
+}
+
+// Top returns the best run of each of the n fastest players on level.
+func (m *MemoryStore) Top(level string, n int) ([]Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return top(m.entries, level, n), nil
+}
+
+// top picks each player's best run on level and returns the n fastest, without replays.
+func top(entries []Entry, level string, n int) []Entry {
+	best := map[string]Entry{}
+	for _, e := range entries {
+		if e.Level != level {
+			continue
+		}
+		if b, ok := best[e.Player]; !ok || e.Ticks < b.Ticks {
+			e.Replay = nil
+			best[e.Player] = e
+		}
+	}
+	list := make([]Entry, 0, len(best))
+	for _, e := range best {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Ticks != list[j].Ticks {
+			return list[i].Ticks < list[j].Ticks
+		}
+		return list[i].Date.Before(list[j].Date) // first to set a time keeps the place
+	})
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+// FileStore is a Store kept in a JSON file, rewritten on every Add.
+type FileStore struct {
+	MemoryStore
+	path string
+}
+
+// OpenFileStore loads the runs in path. A missing file starts an empty store.
+func OpenFileStore(path string) (*FileStore, error) {
+	f := &FileStore{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &f.entries); err != nil {
+		return nil, fmt.Errorf("leaderboard %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Add stores e and saves the file.
+func (f *FileStore) Add(e Entry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries := append(f.entries, e)
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+	// write a new file and swap it in, so a crash never leaves half a file
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return err
+	}
+	f.entries = entries
+	return nil
+}
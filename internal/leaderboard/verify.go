@@ -0,0 +1,106 @@
+// Package leaderboard shares level times between players: a small HTTP service
+// that checks each run by replaying it, and a client for the game.
+package leaderboard
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"platform-game-one/internal/input"
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/levelgen"
+	"platform-game-one/internal/pack"
+	"platform-game-one/internal/player"
+	"platform-game-one/internal/records"
+)
+
+const (
+	dt = 1.0 / 60.0 // leaderboard runs are unassisted, so full speed
+
+	// MaxTicks is the longest run accepted: half an hour.
+	MaxTicks = 30 * 60 * 60
+)
+
+// ErrInvalidRun is wrapped by every reason a run is rejected.
+var ErrInvalidRun = errors.New("invalid run")
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRun, fmt.Sprintf(format, args...))
+}
+
+// LevelKey names the level a replay starts on, the same way the game's records do.
+func LevelKey(r *input.Replay) string {
+	if r.Generate != nil {
+		return fmt.Sprintf("seed:%d:%g", r.Generate.Seed, r.Generate.Difficulty)
+	}
+	return records.LevelKey(packID(r), r.Level)
+}
+
+// packID returns the pack r was played in.
+func packID(r *input.Replay) string {
+	if r.Pack == "" {
+		return pack.Default
+	}
+	return r.Pack
+}
+
+// Verify plays r's first level the way the game does and returns how many ticks it
+// takes to reach the goal. Only unassisted runs on levels of a pack in reg and on
+// generated levels count; a level file could be anything.
+func Verify(r *input.Replay, reg *pack.Registry, screenW, screenH int) (int, error) {
+	if r.Assist.Active() {
+		return 0, invalid("assisted runs don't go on the leaderboard")
+	}
+	if len(r.Frames) > MaxTicks {
+		return 0, invalid("longer than %d ticks", MaxTicks)
+	}
+	shape := player.Shape(r.Shape)
+	if !shape.Valid() {
+		return 0, invalid("shape %d", r.Shape)
+	}
+	lv, err := replayLevel(r, reg, screenW, screenH)
+	if err != nil {
+		return 0, err
+	}
+
+	lv.Reset()
+	p := player.New(lv.StartX, lv.StartY)
+	p.Shape = shape
+	var prev input.Buttons
+	for i, held := range r.Frames {
+		p.Step(dt, input.Next(prev, held), lv)
+		prev = held
+		lv.Update(dt, []image.Rectangle{p.Rect()})
+		if p.Y > lv.DeathY {
+			p.Respawn(lv.StartX, lv.StartY)
+			lv.Reset() // as the game does when every player falls
+		}
+		if lv.InGoal(p.Rect()) {
+			return i + 1, nil
+		}
+	}
+	return 0, invalid("the replay doesn't reach the goal")
+}
+
+func replayLevel(r *input.Replay, reg *pack.Registry, screenW, screenH int) (*level.Level, error) {
+	switch {
+	case r.LevelFile != "":
+		return nil, invalid("level files aren't on the leaderboard")
+	case r.Generate != nil:
+		lv, err := levelgen.Generate(*r.Generate, player.Envelope(dt), screenW, screenH)
+		if err != nil {
+			return nil, invalid("%v", err)
+		}
+		return lv, nil
+	}
+	p, err := reg.Find(packID(r))
+	if err != nil {
+		return nil, invalid("%v", err)
+	}
+	lv, err := p.Level(r.Level, screenW, screenH)
+	if err != nil {
+		return nil, invalid("%v", err)
+	}
+	return lv, nil
+}
@@ -0,0 +1,112 @@
+package level
+
+import "image"
+
+// Block kinds.
+const (
+	BlockCrumble = "crumble" // falls away CrumbleTime after being stood on
+	BlockBump    = "bump"    // breaks when the player's head hits it from below
+	BlockPound   = "pound"   // breaks only under a ground-pound
+)
+
+// BlockKinds lists the breakable blocks the game knows how to play and draw.
+var BlockKinds = []string{BlockCrumble, BlockBump, BlockPound}
+
+// CrumbleTime is how long a crumbling block holds once it's stood on.
+const CrumbleTime = 0.5
+
+// Block is a platform that breaks.
+type Block struct {
+	Kind    string
+	Rect    image.Rectangle
+	Respawn float64 // seconds a broken block takes to come back; 0 means not until the level restarts
+}
+
+// BlockState is what changes about a block during play. Collision and drawing look
+// at it every tick.
+type BlockState struct {
+	Crumbling bool    // stood on and about to fall
+	Broken    bool    // gone, so not solid and not drawn
+	Timer     float64 // seconds until it falls while crumbling, or comes back while broken
+}
+
+// BlockSolid reports whether block i is there to stand on.
+func (l *Level) BlockSolid(i int) bool {
+	return i >= len(l.Live.Blocks) || !l.Live.Blocks[i].Broken
+}
+
+func (l *Level) breakOff(i int) image.Rectangle {
+	l.Live.Blocks[i] = BlockState{Broken: true, Timer: l.Blocks[i].Respawn}
+	return l.Blocks[i].Rect
+}
+
+// Bump breaks a bump block that rect's top is against, and returns it.
+func (l *Level) Bump(rect image.Rectangle) (image.Rectangle, bool) {
+	for i, b := range l.Blocks {
+		if b.Kind == BlockBump && l.BlockSolid(i) && rect.Min.Y == b.Rect.Max.Y && spans(rect, b.Rect) {
+			return l.breakOff(i), true
+		}
+	}
+	return image.Rectangle{}, false
+}
+
+// Stand starts the crumbling blocks rect is standing on. A ground-pound, pound, also
+// breaks a pound block underneath, which is returned.
+func (l *Level) Stand(rect image.Rectangle, pound bool) (image.Rectangle, bool) {
+	for i, b := range l.Blocks {
+		if !l.BlockSolid(i) || rect.Max.Y != b.Rect.Min.Y || !spans(rect, b.Rect) {
+			continue
+		}
+		switch st := &l.Live.Blocks[i]; {
+		case b.Kind == BlockCrumble && !st.Crumbling:
+			st.Crumbling, st.Timer = true, CrumbleTime
+		case b.Kind == BlockPound && pound:
+			return l.breakOff(i), true
+		}
+	}
+	return image.Rectangle{}, false
+}
+
+// Firm reports whether rect is standing on ground that stays put: a platform or
+// slope, rather than a block that can break or a gate that can open under it.
+func (l *Level) Firm(rect image.Rectangle) bool {
+	for _, plat := range l.Platforms {
+		if rect.Max.Y == plat.Min.Y && spans(rect, plat) {
+			return true
+		}
+	}
+	return l.SlopeUnder(rect) != 0
+}
+
+// UpdateBlocks advances crumbling and respawn timers by dt and returns the blocks
+// that fell. A broken block waits to come back while one of players is in the way.
+func (l *Level) UpdateBlocks(dt float64, players []image.Rectangle) (fell []image.Rectangle) {
+	for i := range l.Live.Blocks {
+		st := &l.Live.Blocks[i]
+		switch {
+		case st.Crumbling:
+			if st.Timer -= dt; st.Timer <= 0 {
+				fell = append(fell, l.breakOff(i))
+			}
+		case st.Broken && l.Blocks[i].Respawn > 0:
+			if st.Timer = max(0, st.Timer-dt); st.Timer == 0 && !overlapsAny(l.Blocks[i].Rect, players) {
+				st.Broken = false
+			}
+		}
+	}
+	return fell
+}
+
+// spans reports whether a and b overlap horizontally.
+func spans(a, b image.Rectangle) bool {
+	return a.Min.X < b.Max.X && a.Max.X > b.Min.X
+}
+
+func overlapsAny(r image.Rectangle, rects []image.Rectangle) bool {
+	for _, o := range rects {
+		if r.Overlaps(o) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package level
+
+import (
+	"image"
+	"testing"
+)
+
+const tick = 1.0 / 60
+
+// blocks returns a level of one block of each kind, side by side, with respawn
+// seconds to come back.
+func blocks(respawn float64) *Level {
+	lv := &Level{
+		Platforms: []image.Rectangle{image.Rect(0, 600, 1000, 720)},
+		Blocks: []Block{
+			{Kind: BlockCrumble, Rect: image.Rect(100, 500, 200, 520), Respawn: respawn},
+			{Kind: BlockBump, Rect: image.Rect(300, 500, 400, 520), Respawn: respawn},
+			{Kind: BlockPound, Rect: image.Rect(500, 500, 600, 520), Respawn: respawn},
+		},
+	}
+	lv.Reset()
+	return lv
+}
+
+// on returns a player rect standing on r.
+func on(r image.Rectangle) image.Rectangle {
+	return image.Rect(r.Min.X+10, r.Min.Y-28, r.Min.X+38, r.Min.Y)
+}
+
+// under returns a player rect with its head against r's bottom.
+func under(r image.Rectangle) image.Rectangle {
+	return image.Rect(r.Min.X+10, r.Max.Y, r.Min.X+38, r.Max.Y+28)
+}
+
+func TestCrumbleFallsAfterCrumbleTime(t *testing.T) {
+	lv := blocks(0)
+	b := lv.Blocks[0].Rect
+	const want = int(CrumbleTime / tick)
+	for n := 1; ; n++ {
+		// standing on it all along doesn't start the timer over
+		lv.Stand(on(b), false)
+		fell := lv.UpdateBlocks(tick, []image.Rectangle{on(b)})
+		if len(fell) > 0 {
+			// the timer runs down in float steps, so may take a tick past exact
+			if n < want || n > want+1 || fell[0] != b {
+				t.Errorf("%v fell after %d ticks, want %v after %d", fell, n, b, want)
+			}
+			break
+		}
+		if n > want+1 || !lv.Live.Blocks[0].Crumbling || !lv.BlockSolid(0) {
+			t.Fatalf("tick %d: %+v, want crumbling and still there", n, lv.Live.Blocks[0])
+		}
+	}
+	if lv.BlockSolid(0) {
+		t.Error("the fallen block is still solid")
+	}
+	// with no respawn time, it stays gone
+	for range 600 {
+		lv.UpdateBlocks(tick, nil)
+	}
+	if lv.BlockSolid(0) {
+		t.Error("a block with no respawn time came back")
+	}
+	lv.Reset()
+	if !lv.BlockSolid(0) || lv.Live.Blocks[0].Crumbling {
+		t.Errorf("after Reset the block is %+v, want whole", lv.Live.Blocks[0])
+	}
+}
+
+func TestBlockRespawnWaitsForPlayersToMove(t *testing.T) {
+	lv := blocks(1)
+	b := lv.Blocks[0].Rect
+	lv.Stand(on(b), false)
+	for range int(CrumbleTime/tick) + 1 {
+		lv.UpdateBlocks(tick, nil)
+	}
+	if lv.BlockSolid(0) {
+		t.Fatal("the block didn't fall")
+	}
+	// someone is where it would come back
+	inside := image.Rect(b.Min.X+10, b.Min.Y-10, b.Min.X+38, b.Min.Y+18)
+	for range 2 * 60 {
+		lv.UpdateBlocks(tick, []image.Rectangle{on(b), inside})
+	}
+	if lv.BlockSolid(0) {
+		t.Fatal("the block came back inside a player")
+	}
+	// standing on top of it isn't in the way
+	lv.UpdateBlocks(tick, []image.Rectangle{on(b)})
+	if !lv.BlockSolid(0) {
+		t.Error("the block didn't come back once the player moved out")
+	}
+}
+
+func TestBumpAndPound(t *testing.T) {
+	crumble, bump, pound := blocks(0).Blocks[0].Rect, blocks(0).Blocks[1].Rect, blocks(0).Blocks[2].Rect
+	tests := []struct {
+		name   string
+		hit    func(lv *Level) (image.Rectangle, bool)
+		broken int // block that breaks, or -1
+	}{
+		{"a head against a bump block", func(lv *Level) (image.Rectangle, bool) { return lv.Bump(under(bump)) }, 1},
+		{"a head beside a bump block", func(lv *Level) (image.Rectangle, bool) { return lv.Bump(under(bump).Add(image.Pt(-200, 0))) }, -1},
+		{"a head a pixel below a bump block", func(lv *Level) (image.Rectangle, bool) { return lv.Bump(under(bump).Add(image.Pt(0, 1))) }, -1},
+		{"a head against a crumble block", func(lv *Level) (image.Rectangle, bool) { return lv.Bump(under(crumble)) }, -1},
+		{"a head against a pound block", func(lv *Level) (image.Rectangle, bool) { return lv.Bump(under(pound)) }, -1},
+		{"a ground-pound on a pound block", func(lv *Level) (image.Rectangle, bool) { return lv.Stand(on(pound), true) }, 2},
+		{"standing on a pound block", func(lv *Level) (image.Rectangle, bool) { return lv.Stand(on(pound), false) }, -1},
+		{"a ground-pound on a bump block", func(lv *Level) (image.Rectangle, bool) { return lv.Stand(on(bump), true) }, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lv := blocks(0)
+			r, ok := tt.hit(lv)
+			if ok != (tt.broken >= 0) || ok && r != lv.Blocks[tt.broken].Rect {
+				t.Errorf("broke %v %v, want block %d", r, ok, tt.broken)
+			}
+			for i := range lv.Blocks {
+				if lv.BlockSolid(i) == (i == tt.broken) {
+					t.Errorf("block %d solid %v", i, lv.BlockSolid(i))
+				}
+			}
+			// a broken block can't be broken again
+			if _, again := tt.hit(lv); ok && again {
+				t.Error("broke the same block twice")
+			}
+		})
+	}
+}
+
+func TestFirmGround(t *testing.T) {
+	lv := blocks(0)
+	if !lv.Firm(on(lv.Platforms[0])) {
+		t.Error("a platform isn't firm")
+	}
+	for i, b := range lv.Blocks {
+		if lv.Firm(on(b.Rect)) {
+			t.Errorf("block %d is firm", i)
+		}
+	}
+}
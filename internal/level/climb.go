@@ -0,0 +1,32 @@
+package level
+
+import "image"
+
+// Climbable kinds.
+const (
+	ClimbLadder = "ladder" // climbed straight up and down
+	ClimbVine   = "vine"   // climbed any way, like a wall of it
+)
+
+// ClimbKinds lists the climbables the game knows how to play and draw.
+var ClimbKinds = []string{ClimbLadder, ClimbVine}
+
+// Climbable is a ladder or vine the player can hold on to and climb. It has no
+// collision, and its top is as high as the player climbs.
+type Climbable struct {
+	Kind string
+	Rect image.Rectangle
+}
+
+// ClimbAt returns the ladder or vine rect is on, counting one whose top rect is
+// standing on, or false.
+func (l *Level) ClimbAt(rect image.Rectangle) (Climbable, bool) {
+	feet := rect
+	feet.Max.Y++
+	for _, c := range l.Climbables {
+		if feet.Overlaps(c.Rect) {
+			return c, true
+		}
+	}
+	return Climbable{}, false
+}
@@ -0,0 +1,389 @@
+package level
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"slices"
+	"strings"
+)
+
+// File is the JSON form of a level. Rectangles are [minX, minY, maxX, maxY].
+type File struct {
+	Name       string          `json:"name,omitempty"`
+	Width      int             `json:"width"`
+	Height     int             `json:"height"`
+	Start      [2]float64      `json:"start"`
+	DeathY     float64         `json:"death_y"`
+	Goal       [4]int          `json:"goal"`
+	Platforms  [][4]int        `json:"platforms"`
+	Slopes     []FileSlope     `json:"slopes,omitempty"`
+	Pads       []FilePad       `json:"pads,omitempty"`
+	Blocks     []FileBlock     `json:"blocks,omitempty"`
+	Gates      []FileGate      `json:"gates,omitempty"`
+	Triggers   []FileTrigger   `json:"triggers,omitempty"`
+	Zones      []FileZone      `json:"zones,omitempty"`
+	Climbables []FileClimbable `json:"climbables,omitempty"`
+	Materials  []FileMaterial  `json:"materials,omitempty"`
+	Theme      string          `json:"theme,omitempty"`
+	Props      []Prop          `json:"props,omitempty"`
+}
+
+// FileSlope is the JSON form of a Slope.
+type FileSlope struct {
+	Rect   [4]int `json:"rect"`
+	Rising bool   `json:"rising,omitempty"`
+}
+
+// FilePad is the JSON form of a Pad.
+type FilePad struct {
+	Kind        string  `json:"kind"`
+	Rect        [4]int  `json:"rect"`
+	VX          float64 `json:"vx,omitempty"`
+	VY          float64 `json:"vy,omitempty"`
+	Restitution float64 `json:"restitution,omitempty"`
+}
+
+// FileBlock is the JSON form of a Block.
+type FileBlock struct {
+	Kind    string  `json:"kind"`
+	Rect    [4]int  `json:"rect"`
+	Respawn float64 `json:"respawn,omitempty"`
+}
+
+// FileGate is the JSON form of a Gate.
+type FileGate struct {
+	ID    string   `json:"id"`
+	Kind  string   `json:"kind"`
+	Rects [][4]int `json:"rects"`
+}
+
+// FileTrigger is the JSON form of a Trigger. Targets are gate IDs.
+type FileTrigger struct {
+	Kind    string   `json:"kind"`
+	Rect    [4]int   `json:"rect"`
+	Targets []string `json:"targets"`
+	Time    float64  `json:"time,omitempty"`
+}
+
+// FileZone is the JSON form of a Zone.
+type FileZone struct {
+	Kind    string  `json:"kind"`
+	Rect    [4]int  `json:"rect"`
+	Gravity float64 `json:"gravity,omitempty"`
+	WindX   float64 `json:"wind_x,omitempty"`
+	WindY   float64 `json:"wind_y,omitempty"`
+}
+
+// FileClimbable is the JSON form of a Climbable.
+type FileClimbable struct {
+	Kind string `json:"kind"`
+	Rect [4]int `json:"rect"`
+}
+
+// FileMaterial is the JSON form of a Material.
+type FileMaterial struct {
+	Kind  string  `json:"kind"`
+	Rect  [4]int  `json:"rect"`
+	Speed float64 `json:"speed,omitempty"`
+}
+
+// Load reads and validates a level file.
+func Load(path string) (*Level, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lv, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("level %s: %w", path, err)
+	}
+	return lv, nil
+}
+
+// Parse decodes and validates level JSON.
+func Parse(data []byte) (*Level, error) {
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	return f.Level()
+}
+
+// Level converts the file to a Level, checking that it is playable.
+func (f *File) Level() (*Level, error) {
+	if f.Width <= 0 || f.Height <= 0 {
+		return nil, fmt.Errorf("size %dx%d must be positive", f.Width, f.Height)
+	}
+	if len(f.Platforms) == 0 {
+		return nil, errors.New("no platforms")
+	}
+	lv := &Level{
+		Name:   f.Name,
+		Goal:   rect(f.Goal),
+		Width:  f.Width,
+		Height: f.Height,
+		StartX: f.Start[0],
+		StartY: f.Start[1],
+		DeathY: f.DeathY,
+		Theme:  f.Theme,
+	}
+	if lv.Goal.Empty() {
+		return nil, errors.New("goal is empty")
+	}
+	for i, p := range f.Platforms {
+		r := rect(p)
+		if r.Empty() {
+			return nil, fmt.Errorf("platform %d %v is empty", i, p)
+		}
+		lv.Platforms = append(lv.Platforms, r)
+	}
+	for i, s := range f.Slopes {
+		r := rect(s.Rect)
+		if r.Empty() {
+			return nil, fmt.Errorf("slope %d %v is empty", i, s.Rect)
+		}
+		lv.Slopes = append(lv.Slopes, Slope{Rect: r, Rising: s.Rising})
+	}
+	for i, p := range f.Pads {
+		pad, err := p.pad()
+		if err != nil {
+			return nil, fmt.Errorf("pad %d: %w", i, err)
+		}
+		lv.Pads = append(lv.Pads, pad)
+	}
+	for i, b := range f.Blocks {
+		r := rect(b.Rect)
+		switch {
+		case !slices.Contains(BlockKinds, b.Kind):
+			return nil, fmt.Errorf("block %d: unknown kind %q (want %s)", i, b.Kind, strings.Join(BlockKinds, ", "))
+		case r.Empty():
+			return nil, fmt.Errorf("block %d %v is empty", i, b.Rect)
+		case b.Respawn < 0:
+			return nil, fmt.Errorf("block %d: respawn %g must not be negative", i, b.Respawn)
+		}
+		lv.Blocks = append(lv.Blocks, Block{Kind: b.Kind, Rect: r, Respawn: b.Respawn})
+	}
+	if err := lv.wire(f.Gates, f.Triggers); err != nil {
+		return nil, err
+	}
+	lv.Reset()
+	for i, z := range f.Zones {
+		zone, err := z.zone()
+		if err != nil {
+			return nil, fmt.Errorf("zone %d: %w", i, err)
+		}
+		lv.Zones = append(lv.Zones, zone)
+	}
+	for i, c := range f.Climbables {
+		r := rect(c.Rect)
+		switch {
+		case !slices.Contains(ClimbKinds, c.Kind):
+			return nil, fmt.Errorf("climbable %d: unknown kind %q (want %s)", i, c.Kind, strings.Join(ClimbKinds, ", "))
+		case r.Empty():
+			return nil, fmt.Errorf("climbable %d %v is empty", i, c.Rect)
+		}
+		lv.Climbables = append(lv.Climbables, Climbable{Kind: c.Kind, Rect: r})
+	}
+	for i, m := range f.Materials {
+		r := rect(m.Rect)
+		switch {
+		case !slices.Contains(MaterialKinds, m.Kind):
+			return nil, fmt.Errorf("material %d: unknown kind %q (want %s)", i, m.Kind, strings.Join(MaterialKinds, ", "))
+		case r.Empty():
+			return nil, fmt.Errorf("material %d %v is empty", i, m.Rect)
+		case m.Kind == MaterialConveyor && m.Speed == 0:
+			return nil, fmt.Errorf("material %d: conveyor needs a speed", i)
+		case m.Kind != MaterialConveyor && m.Speed != 0:
+			return nil, fmt.Errorf("material %d: only conveyors have a speed", i)
+		}
+		lv.Materials = append(lv.Materials, Material{Kind: m.Kind, Rect: r, Speed: m.Speed})
+	}
+	for i, p := range f.Props {
+		if !slices.Contains(PropKinds, p.Kind) {
+			return nil, fmt.Errorf("prop %d: unknown kind %q (want %s)", i, p.Kind, strings.Join(PropKinds, ", "))
+		}
+		if p.Scale < 0 {
+			return nil, fmt.Errorf("prop %d: scale %g must not be negative", i, p.Scale)
+		}
+	}
+	lv.Props = f.Props
+	if lv.DeathY == 0 {
+		lv.DeathY = float64(f.Height + 100)
+	}
+	return lv, nil
+}
+
+// ToFile converts the level to its JSON form.
+func (l *Level) ToFile() *File {
+	f := &File{
+		Name:   l.Name,
+		Width:  l.Width,
+		Height: l.Height,
+		Start:  [2]float64{l.StartX, l.StartY},
+		DeathY: l.DeathY,
+		Goal:   unrect(l.Goal),
+		Theme:  l.Theme,
+		Props:  l.Props,
+	}
+	for _, p := range l.Platforms {
+		f.Platforms = append(f.Platforms, unrect(p))
+	}
+	for _, s := range l.Slopes {
+		f.Slopes = append(f.Slopes, FileSlope{Rect: unrect(s.Rect), Rising: s.Rising})
+	}
+	for _, p := range l.Pads {
+		f.Pads = append(f.Pads, FilePad{Kind: p.Kind, Rect: unrect(p.Rect), VX: p.VX, VY: p.VY, Restitution: p.Restitution})
+	}
+	for _, b := range l.Blocks {
+		f.Blocks = append(f.Blocks, FileBlock{Kind: b.Kind, Rect: unrect(b.Rect), Respawn: b.Respawn})
+	}
+	for _, g := range l.Gates {
+		fg := FileGate{ID: g.ID, Kind: g.Kind}
+		for _, r := range g.Rects {
+			fg.Rects = append(fg.Rects, unrect(r))
+		}
+		f.Gates = append(f.Gates, fg)
+	}
+	for _, t := range l.Triggers {
+		f.Triggers = append(f.Triggers, FileTrigger{Kind: t.Kind, Rect: unrect(t.Rect), Targets: t.Targets, Time: t.Time})
+	}
+	for _, c := range l.Climbables {
+		f.Climbables = append(f.Climbables, FileClimbable{Kind: c.Kind, Rect: unrect(c.Rect)})
+	}
+	for _, z := range l.Zones {
+		f.Zones = append(f.Zones, FileZone{Kind: z.Kind, Rect: unrect(z.Rect), Gravity: z.Gravity, WindX: z.WindX, WindY: z.WindY})
+	}
+	for _, m := range l.Materials {
+		f.Materials = append(f.Materials, FileMaterial{Kind: m.Kind, Rect: unrect(m.Rect), Speed: m.Speed})
+	}
+	return f
+}
+
+// wire adds gates and the triggers that work them, checking that every trigger
+// names gates that exist and every gate has a trigger.
+func (l *Level) wire(gates []FileGate, triggers []FileTrigger) error {
+	used := make(map[string]bool)
+	for i, g := range gates {
+		switch _, dup := used[g.ID]; {
+		case g.ID == "":
+			return fmt.Errorf("gate %d has no id", i)
+		case dup:
+			return fmt.Errorf("gate %d: id %q is used twice", i, g.ID)
+		case !slices.Contains(GateKinds, g.Kind):
+			return fmt.Errorf("gate %q: unknown kind %q (want %s)", g.ID, g.Kind, strings.Join(GateKinds, ", "))
+		case len(g.Rects) == 0:
+			return fmt.Errorf("gate %q has no rects", g.ID)
+		}
+		used[g.ID] = false
+		gate := Gate{ID: g.ID, Kind: g.Kind}
+		for _, fr := range g.Rects {
+			r := rect(fr)
+			if r.Empty() {
+				return fmt.Errorf("gate %q: %v is empty", g.ID, fr)
+			}
+			gate.Rects = append(gate.Rects, r)
+		}
+		l.Gates = append(l.Gates, gate)
+	}
+	for i, t := range triggers {
+		r := rect(t.Rect)
+		switch {
+		case !slices.Contains(TriggerKinds, t.Kind):
+			return fmt.Errorf("trigger %d: unknown kind %q (want %s)", i, t.Kind, strings.Join(TriggerKinds, ", "))
+		case r.Empty():
+			return fmt.Errorf("trigger %d %v is empty", i, t.Rect)
+		case len(t.Targets) == 0:
+			return fmt.Errorf("trigger %d has no targets", i)
+		case t.Time < 0:
+			return fmt.Errorf("trigger %d: time %g must not be negative", i, t.Time)
+		case t.Kind == TriggerKey && t.Time != 0:
+			return fmt.Errorf("trigger %d: keys stay picked up, so have no time", i)
+		}
+		for _, id := range t.Targets {
+			if _, ok := used[id]; !ok {
+				return fmt.Errorf("trigger %d: no gate %q", i, id)
+			}
+			used[id] = true
+		}
+		l.Triggers = append(l.Triggers, Trigger{Kind: t.Kind, Rect: r, Targets: t.Targets, Time: t.Time})
+	}
+	for _, g := range l.Gates {
+		if !used[g.ID] {
+			return fmt.Errorf("gate %q has no trigger", g.ID)
+		}
+	}
+	return nil
+}
+
+// pad checks that p makes sense for its kind and converts it.
+func (p FilePad) pad() (Pad, error) {
+	r := rect(p.Rect)
+	switch {
+	case !slices.Contains(PadKinds, p.Kind):
+		return Pad{}, fmt.Errorf("unknown kind %q (want %s)", p.Kind, strings.Join(PadKinds, ", "))
+	case r.Empty():
+		return Pad{}, fmt.Errorf("%v is empty", p.Rect)
+	case p.Kind == PadSpring && (p.VX != 0 || p.VY >= 0):
+		return Pad{}, errors.New("spring needs an upward vy (negative) and no vx")
+	case p.Kind == PadCannon && p.VX == 0 && p.VY == 0:
+		return Pad{}, errors.New("cannon needs a vx or vy")
+	case p.Kind == PadBounce && (p.Restitution <= 0 || p.Restitution > 1):
+		return Pad{}, fmt.Errorf("bounce restitution %g must be above 0 and at most 1", p.Restitution)
+	case p.Kind != PadBounce && p.Restitution != 0:
+		return Pad{}, errors.New("only bounce pads have a restitution")
+	case p.Kind == PadBounce && (p.VX != 0 || p.VY != 0):
+		return Pad{}, errors.New("bounce pads have no vx or vy")
+	}
+	return Pad{Kind: p.Kind, Rect: r, VX: p.VX, VY: p.VY, Restitution: p.Restitution}, nil
+}
+
+// zone checks that z makes sense for its kind and converts it.
+func (z FileZone) zone() (Zone, error) {
+	r := rect(z.Rect)
+	wind := z.WindX != 0 || z.WindY != 0
+	switch {
+	case !slices.Contains(ZoneKinds, z.Kind):
+		return Zone{}, fmt.Errorf("unknown kind %q (want %s)", z.Kind, strings.Join(ZoneKinds, ", "))
+	case r.Empty():
+		return Zone{}, fmt.Errorf("%v is empty", z.Rect)
+	case z.Kind == ZoneGravity && (z.Gravity <= 0 || z.Gravity > MaxZoneGravity):
+		return Zone{}, fmt.Errorf("gravity %g must be above 0 and at most %g", z.Gravity, float64(MaxZoneGravity))
+	case z.Kind != ZoneGravity && z.Gravity != 0:
+		return Zone{}, errors.New("only gravity zones have a gravity")
+	case z.Kind == ZoneWind && !wind:
+		return Zone{}, errors.New("wind needs a wind_x or wind_y")
+	case z.Kind != ZoneWind && wind:
+		return Zone{}, errors.New("only wind zones have a wind_x or wind_y")
+	}
+	return Zone{Kind: z.Kind, Rect: r, Gravity: z.Gravity, WindX: z.WindX, WindY: z.WindY}, nil
+}
+
+// Save writes the level as indented JSON. It refuses a level that Load would
+// reject, and writes a new file and swaps it in, so anything watching the file
+// never reads half of it.
+func (l *Level) Save(path string) error {
+	f := l.ToFile()
+	if _, err := f.Level(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func rect(r [4]int) image.Rectangle {
+	return image.Rect(r[0], r[1], r[2], r[3])
+}
+
+func unrect(r image.Rectangle) [4]int {
+	return [4]int{r.Min.X, r.Min.Y, r.Max.X, r.Max.Y}
+}
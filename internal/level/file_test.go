@@ -0,0 +1,81 @@
+package level
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWireCatchesBadReferences(t *testing.T) {
+	door := FileGate{ID: "red", Kind: GateDoor, Rects: [][4]int{{300, 500, 320, 600}}}
+	key := FileTrigger{Kind: TriggerKey, Rect: [4]int{100, 560, 120, 580}, Targets: []string{"red"}}
+	tests := []struct {
+		name     string
+		gates    []FileGate
+		triggers []FileTrigger
+		want     string // in the error; empty means none
+	}{
+		{"a key and its door", []FileGate{door}, []FileTrigger{key}, ""},
+		{"an unknown target", []FileGate{door}, []FileTrigger{key, {Kind: TriggerSwitch, Rect: key.Rect, Targets: []string{"blue"}}}, `trigger 1: no gate "blue"`},
+		{"a duplicate gate ID", []FileGate{door, door}, []FileTrigger{key}, `gate 1: id "red" is used twice`},
+		{"a gate with no trigger", []FileGate{door, {ID: "blue", Kind: GateBridge, Rects: door.Rects}}, []FileTrigger{key}, `gate "blue" has no trigger`},
+		{"a trigger with no targets", []FileGate{door}, []FileTrigger{key, {Kind: TriggerLever, Rect: key.Rect}}, "trigger 1 has no targets"},
+		{"a key with a time", []FileGate{door}, []FileTrigger{{Kind: TriggerKey, Rect: key.Rect, Targets: key.Targets, Time: 3}}, "trigger 0: keys stay picked up, so have no time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := File{
+				Width: 1280, Height: 720,
+				Goal:      [4]int{1200, 500, 1260, 600},
+				Platforms: [][4]int{{0, 600, 1280, 720}},
+				Gates:     tt.gates,
+				Triggers:  tt.triggers,
+			}
+			_, err := f.Level()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.want != "" && err == nil:
+				t.Errorf("no error, want %q", tt.want)
+			case tt.want != "" && !strings.Contains(err.Error(), tt.want):
+				t.Errorf("error %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveWritesOnlyLoadableLevels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "level.json")
+	lv := &Level{
+		Name:      "Flat",
+		Width:     1280,
+		Height:    720,
+		Goal:      image.Rect(1200, 500, 1260, 600),
+		Platforms: []image.Rectangle{image.Rect(0, 600, 1280, 720)},
+		DeathY:    820,
+	}
+	if err := lv.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	back, err := Load(path)
+	if err != nil {
+		t.Fatalf("loading what was saved: %v", err)
+	}
+	if back.Name != lv.Name || back.Goal != lv.Goal || !slices.Equal(back.Platforms, lv.Platforms) {
+		t.Errorf("loaded %+v, want %+v", back, lv)
+	}
+
+	lv.Platforms = nil
+	if err := lv.Save(path); err == nil || !strings.Contains(err.Error(), "no platforms") {
+		t.Errorf("saving a level with no platforms: error %v, want no platforms", err)
+	}
+	if _, err := Load(path); err != nil {
+		t.Errorf("the refused save overwrote the file: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); err == nil {
+		t.Error("the temporary file was left behind")
+	}
+}
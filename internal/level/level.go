@@ -2,19 +2,54 @@ package level
 
 import (
 	"image"
+	"slices"
+)
+
+// The game's logical screen size. Built-in and generated levels are laid out for it,
+// so anything that replays them, like the bot and the leaderboard, must use it too.
+const (
+	ScreenWidth  = 1280
+	ScreenHeight = 720
 )
 
 // Level holds platform and goal data for one level.
 type Level struct {
-	Platforms   []image.Rectangle
-	Goal        image.Rectangle
-	Width       int
-	Height      int
-	StartX      float64
-	StartY      float64
-	DeathY      float64 // player dies if Y > DeathY
+	Name       string
+	Platforms  []image.Rectangle
+	Slopes     []Slope
+	Pads       []Pad       // springs, cannons and bounce pads
+	Blocks     []Block     // platforms that break
+	Gates      []Gate      // doors and bridges worked by triggers
+	Triggers   []Trigger   // keys, switches and levers
+	Zones      []Zone      // water, low gravity and wind
+	Climbables []Climbable // ladders and vines
+	Materials  []Material  // ice, conveyor belts and sticky ground
+	Live       Live        // what changes during play (see Reset)
+	Goal       image.Rectangle
+	Width      int
+	Height     int
+	StartX     float64
+	StartY     float64
+	DeathY     float64 // player dies if Y > DeathY
+	Theme      string  // theme name; empty means the default theme
+	Props      []Prop  // decorations, drawn behind platforms
+
+	// Extra is solid like a platform without being part of the level: the other
+	// players in co-op, set only while one of them moves.
+	Extra []image.Rectangle
 }
 
+// Prop is a decoration with no collision, standing with its bottom center at X, Y.
+type Prop struct {
+	Kind  string  `json:"kind"` // palm, bush, rock or flower
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Scale float64 `json:"scale,omitempty"` // 0 means 1
+}
+
+// PropKinds lists the decorations the renderer knows how to draw.
+var PropKinds = []string{"palm", "bush", "rock", "flower"}
+
 // FirstLevel returns the first level: 4 screens wide, complex layout.
 func FirstLevel(screenW, screenH int) *Level {
 	w := screenW * 4
@@ -55,7 +90,22 @@ func FirstLevel(screenW, screenH int) *Level {
 	startX := 64.0
 	startY := float64(floorY - 40 - 32) // above first small platform
 
+	fy := float64(floorY)
+	props := []Prop{
+		{Kind: "bush", X: 30, Y: fy},
+		{Kind: "flower", X: 220, Y: fy},
+		{Kind: "rock", X: 540, Y: fy},
+		{Kind: "flower", X: 980, Y: fy},
+		{Kind: "bush", X: 1500, Y: fy},
+		{Kind: "rock", X: 1790, Y: fy, Scale: 1.4},
+		{Kind: "flower", X: 2340, Y: fy},
+		{Kind: "bush", X: 2700, Y: fy - 200},
+		{Kind: "flower", X: 3200, Y: fy - 200},
+		{Kind: "bush", X: 4400, Y: fy - 200, Scale: 1.3},
+	}
+
 	return &Level{
+		Name:      "Level One",
 		Platforms: platforms,
 		Goal:      goal,
 		Width:     w,
@@ -63,69 +113,127 @@ func FirstLevel(screenW, screenH int) *Level {
 		StartX:    startX,
 		StartY:    startY,
 		DeathY:    float64(floorY + 100),
+		Theme:     "meadow",
+		Props:     props,
 	}
 }
 
 // ResolveCollision takes the player's current rect and velocity, resolves collisions
-// with all platforms, and returns the new position (as min X,Y of rect), new velocity,
-// and whether the player is grounded. Multiple passes ensure we don't stay stuck.
-func (l *Level) ResolveCollision(rect image.Rectangle, vx, vy float64) (newX, newY float64, newVX, newVY float64, grounded bool) {
+// with all platforms, slopes, bounce pads and unbroken blocks, and returns the new position (as min X,Y of rect),
+// new velocity, whether the player is grounded, and if so the material underfoot.
+// Multiple passes ensure we don't stay stuck.
+func (l *Level) ResolveCollision(rect image.Rectangle, vx, vy float64) (newX, newY float64, newVX, newVY float64, grounded bool, ground Material) {
 	newX = float64(rect.Min.X)
 	newY = float64(rect.Min.Y)
 	newVX = vx
 	newVY = vy
+	w, h := rect.Dx(), rect.Dy()
 	const maxPasses = 4
 	for pass := 0; pass < maxPasses; pass++ {
 		anyResolved := false
-		for _, plat := range l.Platforms {
-			rect = image.Rect(int(newX), int(newY), int(newX)+rect.Dx(), int(newY)+rect.Dy())
-			if rect.Min.X >= plat.Max.X || rect.Max.X <= plat.Min.X ||
-				rect.Min.Y >= plat.Max.Y || rect.Max.Y <= plat.Min.Y {
-				continue
+		for _, plats := range [...][]image.Rectangle{l.Platforms, l.Extra} {
+			for _, plat := range plats {
+				rect = image.Rect(int(newX), int(newY), int(newX)+w, int(newY)+h)
+				if rect.Min.X >= plat.Max.X || rect.Max.X <= plat.Min.X ||
+					rect.Min.Y >= plat.Max.Y || rect.Max.Y <= plat.Min.Y {
+					continue
+				}
+				var g bool
+				newX, newY, newVX, newVY, g = resolveRect(plat, rect, newX, newY, newVX, newVY)
+				grounded = grounded || g
+				anyResolved = true
 			}
-			overlapLeft := float64(rect.Max.X - plat.Min.X)
-			overlapRight := float64(plat.Max.X - rect.Min.X)
-			overlapTop := float64(rect.Max.Y - plat.Min.Y)
-			overlapBottom := float64(plat.Max.Y - rect.Min.Y)
-			minOverlap := overlapLeft
-			axis := 0
-			if overlapRight < minOverlap {
-				minOverlap = overlapRight
-				axis = 0
+		}
+		for _, pad := range l.Pads {
+			rect = image.Rect(int(newX), int(newY), int(newX)+w, int(newY)+h)
+			if pad.Kind != PadBounce || !rect.Overlaps(pad.Rect) {
+				continue
 			}
-			if overlapTop < minOverlap {
-				minOverlap = overlapTop
-				axis = 1
+			var g bool
+			newX, newY, newVX, newVY, g = resolveRect(pad.Rect, rect, newX, newY, newVX, newVY)
+			grounded = grounded || g
+			anyResolved = true
+		}
+		for i, b := range l.Blocks {
+			rect = image.Rect(int(newX), int(newY), int(newX)+w, int(newY)+h)
+			if !l.BlockSolid(i) || !rect.Overlaps(b.Rect) {
+				continue
 			}
-			if overlapBottom < minOverlap {
-				minOverlap = overlapBottom
-				axis = 1
+			var g bool
+			newX, newY, newVX, newVY, g = resolveRect(b.Rect, rect, newX, newY, newVX, newVY)
+			grounded = grounded || g
+			anyResolved = true
+		}
+		for i, gate := range l.Gates {
+			if !l.GateSolid(i) {
+				continue
 			}
-			if axis == 0 {
-				if overlapLeft < overlapRight {
-					newX = float64(plat.Min.X) - float64(rect.Dx())
-					newVX = 0
-				} else {
-					newX = float64(plat.Max.X)
-					newVX = 0
-				}
-				anyResolved = true
-			} else {
-				if overlapTop < overlapBottom {
-					newY = float64(plat.Min.Y) - float64(rect.Dy())
-					newVY = 0
-					grounded = true
-				} else {
-					newY = float64(plat.Max.Y)
-					newVY = 0
+			for _, r := range gate.Rects {
+				rect = image.Rect(int(newX), int(newY), int(newX)+w, int(newY)+h)
+				if !rect.Overlaps(r) {
+					continue
 				}
+				var g bool
+				newX, newY, newVX, newVY, g = resolveRect(r, rect, newX, newY, newVX, newVY)
+				grounded = grounded || g
 				anyResolved = true
 			}
 		}
+		for _, s := range l.Slopes {
+			var g, hit bool
+			newX, newY, newVX, newVY, g, hit = resolveSlope(s, newX, newY, float64(w), float64(h), newVX, newVY)
+			grounded = grounded || g
+			anyResolved = anyResolved || hit
+		}
 		if !anyResolved {
 			break
 		}
 	}
+	if grounded {
+		ground = l.MaterialAt(image.Rect(int(newX), int(newY), int(newX)+w, int(newY)+h))
+	}
+	return newX, newY, newVX, newVY, grounded, ground
+}
+
+// resolveRect pushes rect, which overlaps plat, out of it the shortest way.
+func resolveRect(plat, rect image.Rectangle, x, y, vx, vy float64) (newX, newY float64, newVX, newVY float64, grounded bool) {
+	newX, newY, newVX, newVY = x, y, vx, vy
+	overlapLeft := float64(rect.Max.X - plat.Min.X)
+	overlapRight := float64(plat.Max.X - rect.Min.X)
+	overlapTop := float64(rect.Max.Y - plat.Min.Y)
+	overlapBottom := float64(plat.Max.Y - rect.Min.Y)
+	minOverlap := overlapLeft
+	axis := 0
+	if overlapRight < minOverlap {
+		minOverlap = overlapRight
+		axis = 0
+	}
+	if overlapTop < minOverlap {
+		minOverlap = overlapTop
+		axis = 1
+	}
+	if overlapBottom < minOverlap {
+		minOverlap = overlapBottom
+		axis = 1
+	}
+	if axis == 0 {
+		if overlapLeft < overlapRight {
+			newX = float64(plat.Min.X) - float64(rect.Dx())
+			newVX = 0
+		} else {
+			newX = float64(plat.Max.X)
+			newVX = 0
+		}
+	} else {
+		if overlapTop < overlapBottom {
+			newY = float64(plat.Min.Y) - float64(rect.Dy())
+			newVY = 0
+			grounded = true
+		} else {
+			newY = float64(plat.Max.Y)
+			newVY = 0
+		}
+	}
 	return newX, newY, newVX, newVY, grounded
 }
 
@@ -183,7 +291,18 @@ func SecondLevel(screenW, screenH int) *Level {
 	startX := 80.0
 	startY := float64(floorY - 40)
 
+	fy := float64(floorY)
+	props := []Prop{
+		{Kind: "palm", X: 220, Y: fy},
+		{Kind: "rock", X: 40, Y: fy, Scale: 0.8},
+		{Kind: "palm", X: 2480, Y: fy - 60, Scale: 0.9},
+		{Kind: "palm", X: 3620, Y: fy - 120},
+		{Kind: "palm", X: 3760, Y: fy - 120, Scale: 1.2},
+		{Kind: "palm", X: 3920, Y: fy - 200, Scale: 0.8},
+	}
+
 	return &Level{
+		Name:      "Level Two",
 		Platforms: platforms,
 		Goal:      goal,
 		Width:     w,
@@ -191,6 +310,8 @@ func SecondLevel(screenW, screenH int) *Level {
 		StartX:    startX,
 		StartY:    startY,
 		DeathY:    float64(floorY + 100),
+		Theme:     "sunset",
+		Props:     props,
 	}
 }
 
@@ -235,14 +356,14 @@ func ThirdLevel(screenW, screenH int) *Level {
 		// Screen 3-4: the gauntlet -- alternating high/low with wide gaps
 		image.Rect(2720, floorY-200, 2790, floorY-160),
 		image.Rect(2900, floorY-60, 2960, floorY-20),
-		image.Rect(3080, floorY-220, 3140, floorY-180),
+		image.Rect(3080, floorY-130, 3140, floorY-90),
 		image.Rect(3260, floorY-80, 3320, floorY-40),
-		image.Rect(3440, floorY-240, 3510, floorY-200),
+		image.Rect(3440, floorY-150, 3510, floorY-110),
 
 		// Screen 4: final climb to the goal
 		image.Rect(3620, floorY-160, 3680, floorY-120),
-		image.Rect(3740, floorY-260, 3800, floorY-220),
-		image.Rect(3880, floorY-340, 3950, floorY-300),
+		image.Rect(3740, floorY-230, 3800, floorY-190),
+		image.Rect(3880, floorY-300, 3950, floorY-260),
 
 		// Goal platform -- small, must earn it
 		image.Rect(4060, floorY-280, 4200, floorY-220),
@@ -252,7 +373,16 @@ func ThirdLevel(screenW, screenH int) *Level {
 	startX := 40.0
 	startY := float64(floorY - 40)
 
+	fy := float64(floorY)
+	props := []Prop{
+		{Kind: "rock", X: 120, Y: fy, Scale: 0.7},
+		{Kind: "flower", X: 1430, Y: fy - 400},
+		{Kind: "rock", X: 2570, Y: fy - 80, Scale: 0.6},
+		{Kind: "flower", X: 4075, Y: fy - 280},
+	}
+
 	return &Level{
+		Name:      "Level Three",
 		Platforms: platforms,
 		Goal:      goal,
 		Width:     w,
@@ -260,6 +390,8 @@ func ThirdLevel(screenW, screenH int) *Level {
 		StartX:    startX,
 		StartY:    startY,
 		DeathY:    float64(floorY + 100),
+		Theme:     "night",
+		Props:     props,
 	}
 }
 
@@ -268,3 +400,47 @@ func (l *Level) InGoal(rect image.Rectangle) bool {
 	return rect.Min.X < l.Goal.Max.X && rect.Max.X > l.Goal.Min.X &&
 		rect.Min.Y < l.Goal.Max.Y && rect.Max.Y > l.Goal.Min.Y
 }
+
+// Solid reports whether rect is inside a platform, bounce pad, unbroken block or shut
+// gate, or below a slope's surface.
+func (l *Level) Solid(rect image.Rectangle) bool {
+	if slices.ContainsFunc(l.Platforms, rect.Overlaps) || slices.ContainsFunc(l.Extra, rect.Overlaps) {
+		return true
+	}
+	for _, pad := range l.Pads {
+		if pad.Kind == PadBounce && rect.Overlaps(pad.Rect) {
+			return true
+		}
+	}
+	for i, b := range l.Blocks {
+		if l.BlockSolid(i) && rect.Overlaps(b.Rect) {
+			return true
+		}
+	}
+	for i, g := range l.Gates {
+		if l.GateSolid(i) && slices.ContainsFunc(g.Rects, rect.Overlaps) {
+			return true
+		}
+	}
+	for _, s := range l.Slopes {
+		surf, over := s.under(float64(rect.Min.X), float64(rect.Max.X))
+		if over && float64(rect.Max.Y) > surf+1 && rect.Min.Y < s.Rect.Max.Y {
+			return true
+		}
+	}
+	return false
+}
+
+// Blank returns a new level for the editor: one screen with a floor, a start and a goal.
+func Blank(screenW, screenH int) *Level {
+	floorY := screenH - 48
+	return &Level{
+		Platforms: []image.Rectangle{image.Rect(0, floorY, screenW, screenH)},
+		Goal:      image.Rect(screenW-120, floorY-100, screenW-24, floorY),
+		Width:     screenW,
+		Height:    screenH,
+		StartX:    64,
+		StartY:    float64(floorY - 32),
+		DeathY:    float64(floorY + 100),
+	}
+}
@@ -0,0 +1,34 @@
+package level
+
+import "image"
+
+// Material kinds.
+const (
+	MaterialIce      = "ice"      // slippery: the player speeds up and slows down gradually
+	MaterialConveyor = "conveyor" // carries whoever stands on it along at Speed
+	MaterialSticky   = "sticky"   // gooey: jumps off it are lower
+)
+
+// MaterialKinds lists the materials the game knows how to play and draw.
+var MaterialKinds = []string{MaterialIce, MaterialConveyor, MaterialSticky}
+
+// Material is what the ground is made of inside Rect, usually a platform's own rect.
+// It has no collision of its own; it changes how the player moves while standing on
+// the platforms, slopes or blocks under it. Ground without one is plain.
+type Material struct {
+	Kind  string
+	Rect  image.Rectangle
+	Speed float64 // conveyors: pixels/second, negative is left
+}
+
+// MaterialAt returns the material under the middle of rect's bottom edge, or the zero
+// Material for plain ground.
+func (l *Level) MaterialAt(rect image.Rectangle) Material {
+	feet := image.Pt((rect.Min.X+rect.Max.X)/2, rect.Max.Y)
+	for _, m := range l.Materials {
+		if feet.In(m.Rect) {
+			return m
+		}
+	}
+	return Material{}
+}
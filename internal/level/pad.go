@@ -0,0 +1,43 @@
+package level
+
+import "image"
+
+// Pad kinds.
+const (
+	PadSpring = "spring" // throws the player straight up at VY
+	PadCannon = "cannon" // fires the player at VX, VY; VX carries on until landing
+	PadBounce = "bounce" // solid; landing on it bounces back Restitution of the fall speed
+)
+
+// PadKinds lists the pads the game knows how to play and draw.
+var PadKinds = []string{PadSpring, PadCannon, PadBounce}
+
+// Pad is a level object that changes the player's velocity on contact. Springs and
+// cannons fire when the player touches them; bounce pads are solid and bounce the
+// player off their top.
+type Pad struct {
+	Kind        string
+	Rect        image.Rectangle
+	VX, VY      float64 // launch velocity in pixels/second, negative VY is up
+	Restitution float64 // share of the landing speed a bounce pad gives back, 0-1
+}
+
+// Touching returns the spring or cannon rect overlaps, or nil.
+func (l *Level) Touching(rect image.Rectangle) *Pad {
+	for i := range l.Pads {
+		if p := &l.Pads[i]; p.Kind != PadBounce && rect.Overlaps(p.Rect) {
+			return p
+		}
+	}
+	return nil
+}
+
+// BounceUnder returns the restitution of the bounce pad rect is standing on, or 0.
+func (l *Level) BounceUnder(rect image.Rectangle) float64 {
+	for _, p := range l.Pads {
+		if p.Kind == PadBounce && rect.Max.Y == p.Rect.Min.Y && rect.Min.X < p.Rect.Max.X && rect.Max.X > p.Rect.Min.X {
+			return p.Restitution
+		}
+	}
+	return 0
+}
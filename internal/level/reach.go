@@ -0,0 +1,527 @@
+package level
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// Envelope is the player movement the reachability check assumes. The level package
+// can't import the player, so the player provides it (see player.Envelope).
+type Envelope struct {
+	Width, Height float64 // collider size
+	MoveSpeed     float64 // horizontal speed, pixels/second
+	JumpVelocity  float64 // pixels/second, negative is up
+	Gravity       float64
+	CoyoteTime    float64 // seconds a jump is still allowed after walking off an edge
+	WindDrag      float64 // how quickly wind drift settles; steady drift is the wind over this
+	StickyJump    float64 // share of JumpVelocity jumping off sticky ground
+	Step          float64 // simulation tick in seconds
+}
+
+// Reach is the result of CheckReach.
+type Reach struct {
+	Start     int    // surface the player lands on from the start position, or -1
+	Reachable []bool // per surface: each platform, slope, pad and block (see Level.Surface)
+	Goal      bool   // whether the goal can be touched
+	Misses    []Miss // the closest failed jump to each unreachable platform and to the goal
+}
+
+// Miss is the closest a reachable platform comes to reaching a target.
+type Miss struct {
+	From  int     // surface jumped from
+	To    int     // surface jumped to; -1 is the goal
+	Short float64 // pixels missing
+	High  bool    // the target is too high rather than too far
+}
+
+// Err describes why the level can't be finished, or returns nil if it can.
+func (r *Reach) Err() error {
+	switch {
+	case r.Start < 0:
+		return fmt.Errorf("start position is not above a platform or slope")
+	case r.Goal:
+		return nil
+	}
+	for _, m := range r.Misses {
+		if m.To >= 0 {
+			continue
+		}
+		if m.High {
+			return fmt.Errorf("goal is %.0fpx too high to reach from surface %d", m.Short, m.From)
+		}
+		return fmt.Errorf("goal is %.0fpx too far to reach from surface %d", m.Short, m.From)
+	}
+	return fmt.Errorf("goal can't be reached")
+}
+
+// point is one tick of a jump, relative to where the feet left the ground.
+// Y is negative above the takeoff height.
+type point struct{ x, y float64 }
+
+// arc is the path of one way to leave a platform, moving sideways the whole time.
+type arc struct {
+	points []point
+	apex   int // index of the highest point
+}
+
+// arcs returns the ways the player can leave a platform moving right or left: a jump
+// from the edge and a jump at the end of coyote time after walking off it. drop is
+// how far below the takeoff the arcs are followed.
+func (e Envelope) arcs(drop float64, left bool) []arc {
+	coyote := int(e.CoyoteTime / e.Step)
+	return []arc{e.arc(0, drop, left), e.arc(coyote, drop, left)}
+}
+
+// arc follows the same integration as the player: velocity first, then position.
+// Collision resolution truncates the position to whole pixels every tick, which
+// makes jumps higher than the formula and moving left faster than moving right,
+// so that is followed too.
+func (e Envelope) arc(fallTicks int, drop float64, left bool) arc {
+	return e.fly(e.MoveSpeed, e.JumpVelocity, fallTicks, drop, left)
+}
+
+// fly is like arc for any launch: moving sideways at speed, and setting the vertical
+// velocity to vy after fallTicks ticks of falling.
+func (e Envelope) fly(speed, vy0 float64, fallTicks int, drop float64, left bool) arc {
+	var a arc
+	x, y, vy := 0.0, 0.0, 0.0
+	a.points = append(a.points, point{})
+	for i := 0; y <= drop; i++ {
+		if i == fallTicks {
+			vy = vy0
+		}
+		vy += e.Gravity * e.Step
+		if left {
+			x = math.Ceil(x + speed*e.Step)
+		} else {
+			x = math.Floor(x + speed*e.Step)
+		}
+		y = math.Floor(y + vy*e.Step)
+		a.points = append(a.points, point{x, y})
+		if y < a.points[a.apex].y {
+			a.apex = len(a.points) - 1
+		}
+	}
+	return a
+}
+
+// launches returns the arcs leaving pad p moving right and left: what it fires the
+// player at, steered either way in the air. bounce is how fast a bounce pad throws
+// the player up.
+func (e Envelope) launches(p Pad, bounce, drop float64) (right, left []arc) {
+	vx, vy := 0.0, p.VY
+	if p.Kind == PadBounce {
+		vy = bounce
+	} else {
+		vx = p.VX
+	}
+	if s := vx + e.MoveSpeed; s > 0 {
+		right = append(right, e.fly(s, vy, 0, drop, false))
+	}
+	if s := e.MoveSpeed - vx; s > 0 {
+		left = append(left, e.fly(s, vy, 0, drop, true))
+	}
+	return right, left
+}
+
+// BounceSpeed returns the upward velocity, which is negative, a bounce pad with
+// restitution r gives the player after a fall of drop pixels.
+func (e Envelope) BounceSpeed(r, drop float64) float64 {
+	return -r * math.Sqrt(2*e.Gravity*math.Max(0, drop))
+}
+
+// JumpHeight returns how far above the takeoff the feet get at the top of a jump.
+func (e Envelope) JumpHeight() float64 {
+	a := e.arc(0, 0, false)
+	return -a.points[a.apex].y
+}
+
+// JumpDistance returns how far to the right a jump carries the player before the feet
+// come down rise pixels above the takeoff (negative is below), or 0 if it can't get
+// that high.
+func (e Envelope) JumpDistance(rise float64) float64 {
+	a := e.arc(0, math.Max(0, -rise), false)
+	if -rise < a.points[a.apex].y {
+		return 0
+	}
+	for _, p := range a.points[a.apex:] {
+		if p.y >= -rise {
+			return p.x
+		}
+	}
+	return 0
+}
+
+// land reports how many pixels short the arc falls of landing on a top need pixels
+// below the takeoff (negative is above) and gap pixels away. Tops a little above the
+// apex count, because the collision resolver pushes the player up onto them.
+func (a arc) land(need, gap, stepUp float64) (short float64, high bool) {
+	apex := a.points[a.apex].y
+	if need < apex-stepUp {
+		return apex - need, true
+	}
+	target := math.Max(need, apex)
+	for _, p := range a.points[a.apex:] {
+		if p.y >= target {
+			return math.Max(0, gap-p.x), false
+		}
+	}
+	return gap, false
+}
+
+// touch reports how many pixels short the arc falls of overlapping a box spanning
+// top to bottom (relative to the takeoff) gap pixels away. h is the player height.
+func (a arc) touch(top, bottom, gap, h float64) (short float64, high bool) {
+	short = math.Inf(1)
+	for _, p := range a.points {
+		if p.y > top && p.y-h < bottom {
+			short = math.Min(short, math.Max(0, gap-p.x))
+		}
+	}
+	if !math.IsInf(short, 1) {
+		return short, false
+	}
+	if highest := a.points[a.apex].y - h; bottom <= highest {
+		return highest - bottom, true
+	}
+	return gap, false
+}
+
+// span returns the player X positions that overlap r horizontally.
+func (e Envelope) span(r image.Rectangle) (lo, hi float64) {
+	return float64(r.Min.X) - e.Width + 1, float64(r.Max.X) - 1
+}
+
+// gap returns how far the player must move sideways from standing on a to overlap b.
+func (e Envelope) gap(a, b image.Rectangle) float64 {
+	alo, ahi := e.span(a)
+	blo, bhi := e.span(b)
+	return math.Max(0, math.Max(blo-ahi, alo-bhi))
+}
+
+// Surface returns the bounds of surface i of the reachability check: platform i, or
+// past the platforms, a slope, then a pad, a block, a bridge's platform and a trigger.
+func (l *Level) Surface(i int) image.Rectangle {
+	if i < len(l.Platforms) {
+		return l.Platforms[i]
+	}
+	if i -= len(l.Platforms); i < len(l.Slopes) {
+		return l.Slopes[i].Rect
+	}
+	if i -= len(l.Slopes); i < len(l.Pads) {
+		return l.Pads[i].Rect
+	}
+	if i -= len(l.Pads); i < len(l.Blocks) {
+		return l.Blocks[i].Rect
+	}
+	i -= len(l.Blocks)
+	for _, g := range l.bridges() {
+		if i < len(g.Rects) {
+			return g.Rects[i]
+		}
+		i -= len(g.Rects)
+	}
+	return l.Triggers[i].Rect
+}
+
+// Surfaces returns how many surfaces the reachability check has.
+func (l *Level) Surfaces() int {
+	n := len(l.Platforms) + len(l.Slopes) + len(l.Pads) + len(l.Blocks) + len(l.Triggers)
+	for _, g := range l.bridges() {
+		n += len(g.Rects)
+	}
+	return n
+}
+
+// bridges returns the gates that are something to stand on. Doors are walls, which
+// the check doesn't look at.
+func (l *Level) bridges() []Gate {
+	var bs []Gate
+	for _, g := range l.Gates {
+		if g.Kind == GateBridge {
+			bs = append(bs, g)
+		}
+	}
+	return bs
+}
+
+// trigger reports whether surface i is a trigger, which is touched rather than stood on.
+func (l *Level) trigger(i int) bool {
+	return i >= l.Surfaces()-len(l.Triggers)
+}
+
+// pad returns the pad surface i is, or nil.
+func (l *Level) pad(i int) *Pad {
+	if i -= len(l.Platforms) + len(l.Slopes); i >= 0 && i < len(l.Pads) {
+		return &l.Pads[i]
+	}
+	return nil
+}
+
+// walkable reports whether the player can walk between surfaces a and b, which is
+// where a slope's end meets the ground beside it.
+func (l *Level) walkable(a, b int, stepUp float64) bool {
+	if a > b {
+		a, b = b, a
+	}
+	n := len(l.Platforms)
+	if b < n || b >= n+len(l.Slopes) {
+		return false // only slopes are joined to anything by walking
+	}
+	meets := func(p image.Point, i int) bool {
+		if i >= n {
+			lo, hi := l.Slopes[i-n].Ends()
+			return near(p, lo, stepUp) || near(p, hi, stepUp)
+		}
+		r := l.Platforms[i]
+		return p.X >= r.Min.X && p.X <= r.Max.X && math.Abs(float64(p.Y-r.Min.Y)) <= stepUp
+	}
+	lo, hi := l.Slopes[b-n].Ends()
+	return meets(lo, a) || meets(hi, a)
+}
+
+// swims reports whether the player can swim from a to b: both are in or at the edge
+// of the same water, counting rise above its surface for jumping out.
+func (l *Level) swims(a, b image.Rectangle, rise float64) bool {
+	for _, z := range l.Zones {
+		if z.Kind != ZoneWater {
+			continue
+		}
+		wet := image.Rect(z.Rect.Min.X-1, z.Rect.Min.Y-int(rise), z.Rect.Max.X+1, z.Rect.Max.Y+1)
+		if a.Overlaps(wet) && b.Overlaps(wet) {
+			return true
+		}
+	}
+	return false
+}
+
+// climbs reports whether the player can climb from a to b: both are by the same
+// ladder or vine, counting reach below it for jumping up to grab it and width to
+// either side for stepping off.
+func (l *Level) climbs(a, b image.Rectangle, reach, width float64) bool {
+	for _, c := range l.Climbables {
+		by := image.Rect(c.Rect.Min.X-int(width), c.Rect.Min.Y-1, c.Rect.Max.X+int(width), c.Rect.Max.Y+int(reach))
+		if a.Overlaps(by) && b.Overlaps(by) {
+			return true
+		}
+	}
+	return false
+}
+
+// envAt returns env for leaving surface r: with the lightest gravity and strongest
+// wind of any zone a jump from its top could pass through, so it stays optimistic.
+// The fastest conveyor on its top speeds the run-up, and jumps are only lower if
+// sticky ground covers all of it.
+func (l *Level) envAt(env Envelope, r image.Rectangle) Envelope {
+	air := image.Rect(r.Min.X, r.Min.Y-int(env.Height+env.JumpHeight()), r.Max.X, r.Min.Y)
+	gravity, wind, lift := 1.0, 0.0, 0.0
+	for _, z := range l.Zones {
+		if !air.Overlaps(z.Rect) {
+			continue
+		}
+		switch z.Kind {
+		case ZoneGravity:
+			gravity = math.Min(gravity, z.Gravity)
+		case ZoneWind:
+			wind = math.Max(wind, math.Abs(z.WindX))
+			lift = math.Min(lift, z.WindY)
+		}
+	}
+	// strong enough lift floats the player up for good; keep the arcs coming down
+	env.Gravity = math.Max(env.Gravity*gravity+lift, env.Gravity*0.1)
+	if env.WindDrag > 0 {
+		env.MoveSpeed += wind / env.WindDrag
+	}
+	top := image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1)
+	belt := 0.0
+	for _, m := range l.Materials {
+		switch {
+		case m.Kind == MaterialConveyor && top.Overlaps(m.Rect):
+			belt = math.Max(belt, math.Abs(m.Speed))
+		case m.Kind == MaterialSticky && top.In(m.Rect) && env.StickyJump > 0:
+			env.JumpVelocity *= env.StickyJump
+		}
+	}
+	env.MoveSpeed += belt
+	return env
+}
+
+func near(p, q image.Point, d float64) bool {
+	return math.Abs(float64(p.X-q.X)) <= d && math.Abs(float64(p.Y-q.Y)) <= d
+}
+
+// CheckReach works out which platforms, slopes and pads and whether the goal can be
+// reached from the start with the movement in env. Touching a spring or cannon and
+// bouncing off a bounce pad launch new arcs, so they join surfaces the jump alone
+// can't. It follows arcs without ceilings, lands on slopes as if they were flat at
+// their highest and launches off pads from their top, so it is optimistic: a jump it
+// rejects is impossible, but one it allows may still be blocked. Breakable blocks and
+// bridges count as always there, doors as never, and triggers as places to touch.
+// Jumps near gravity and wind zones get their lightest gravity and strongest wind,
+// and everything in the same water or by the same ladder or vine is joined, as the
+// player can swim or climb between it.
+func (l *Level) CheckReach(env Envelope) *Reach {
+	surfaces := l.Surfaces()
+	r := &Reach{Start: -1, Reachable: make([]bool, surfaces)}
+	depth := float64(l.Height)
+	jumpRight, jumpLeft := env.arcs(depth, false), env.arcs(depth, true)
+	stepUp := env.MoveSpeed * env.Step
+	rise := env.JumpHeight()
+
+	// the platform the player falls onto from the start position
+	feet := l.StartY + env.Height
+	best := math.Inf(1)
+	for i := range surfaces {
+		if l.trigger(i) {
+			continue
+		}
+		p := l.Surface(i)
+		lo, hi := env.span(p)
+		top := float64(p.Min.Y)
+		if l.StartX >= lo && l.StartX <= hi && top >= feet-stepUp && top < best {
+			r.Start, best = i, top
+		}
+	}
+	if r.Start < 0 {
+		return r
+	}
+
+	// drops[i] is the furthest the player can fall onto bounce pad i before bouncing
+	drops := make([]float64, surfaces)
+	drops[r.Start] = math.Max(0, float64(l.Surface(r.Start).Min.Y)-feet)
+
+	// jumps returns the jumps off surface i to the right and left. Zones change them,
+	// so those are worked out the first time they're needed.
+	zoned := make(map[int][2][]arc)
+	jumps := func(i int) (right, left []arc) {
+		e := l.envAt(env, l.Surface(i))
+		if e == env {
+			return jumpRight, jumpLeft
+		}
+		js, ok := zoned[i]
+		if !ok {
+			js = [2][]arc{e.arcs(depth, false), e.arcs(depth, true)}
+			zoned[i] = js
+		}
+		return js[0], js[1]
+	}
+
+	// ways returns the arcs leaving surface i to the right and left: jumps from its
+	// top, or for a pad, what it launches.
+	ways := func(i int) (right, left []arc) {
+		p := l.pad(i)
+		if p == nil {
+			return jumps(i)
+		}
+		e := l.envAt(env, p.Rect)
+		right, left = e.launches(*p, e.BounceSpeed(p.Restitution, drops[i]), depth)
+		if p.Kind == PadBounce {
+			jr, jl := jumps(i)
+			return append(right, jr...), append(left, jl...)
+		}
+		return right, left
+	}
+
+	// try reports the best of the arcs leaving from; to is -1 for the goal. apex is
+	// the world Y of the top of the highest arc that comes closest.
+	try := func(from, to int, right, left []arc) (short float64, high bool, apex float64) {
+		a := l.Surface(from)
+		target := l.Goal
+		touch := true
+		if to >= 0 {
+			target = l.Surface(to)
+			p := l.pad(to)
+			touch = l.trigger(to) || p != nil && p.Kind != PadBounce
+		}
+		arcs := right
+		if target.Max.X <= a.Min.X {
+			arcs = left
+		}
+		short, apex = math.Inf(1), float64(a.Min.Y)
+		for _, arc := range arcs {
+			var s float64
+			var h bool
+			if touch {
+				top, bottom := float64(target.Min.Y-a.Min.Y), float64(target.Max.Y-a.Min.Y)
+				s, h = arc.touch(top, bottom, env.gap(a, target), env.Height)
+			} else {
+				s, h = arc.land(float64(target.Min.Y-a.Min.Y), env.gap(a, target), stepUp)
+			}
+			top := float64(a.Min.Y) + arc.points[arc.apex].y
+			switch {
+			case s < short:
+				short, high, apex = s, h, top
+			case s == short && top < apex:
+				apex = top // the higher of two that make it falls further
+			}
+		}
+		if math.IsInf(short, 1) {
+			short = env.gap(a, target) // a cannon that can't be steered back this way
+		}
+		return short, high, apex
+	}
+
+	r.Reachable[r.Start] = true
+	queue := []int{r.Start}
+	for len(queue) > 0 {
+		from := queue[0]
+		queue = queue[1:]
+		right, left := ways(from)
+		for to := range surfaces {
+			s, _, apex := try(from, to, right, left)
+			a, b := l.Surface(from), l.Surface(to)
+			if s > 0 && !l.walkable(from, to, stepUp) && !l.swims(a, b, rise) && !l.climbs(a, b, rise+env.Height, env.Width) {
+				continue
+			}
+			if p := l.pad(to); p != nil && p.Kind == PadBounce {
+				// falling from higher up bounces higher, so look again from here
+				drop := math.Min(float64(p.Rect.Min.Y)-apex, depth)
+				if s == 0 && drop > drops[to]+1 {
+					drops[to] = drop
+					if r.Reachable[to] {
+						queue = append(queue, to)
+					}
+				}
+			}
+			if !r.Reachable[to] {
+				r.Reachable[to] = true
+				if !l.trigger(to) {
+					queue = append(queue, to)
+				}
+			}
+		}
+		if !r.Goal {
+			if s, _, _ := try(from, -1, right, left); s == 0 || l.swims(l.Surface(from), l.Goal, rise) ||
+				l.climbs(l.Surface(from), l.Goal, rise+env.Height, env.Width) {
+				r.Goal = true
+			}
+		}
+	}
+
+	// for everything left over, find the jump that comes closest
+	miss := func(to int) {
+		m := Miss{From: -1, To: to, Short: math.Inf(1)}
+		for from, ok := range r.Reachable {
+			if !ok || l.trigger(from) {
+				continue
+			}
+			right, left := ways(from)
+			if s, h, _ := try(from, to, right, left); s < m.Short {
+				m.From, m.Short, m.High = from, s, h
+			}
+		}
+		if m.From >= 0 {
+			r.Misses = append(r.Misses, m)
+		}
+	}
+	for to, ok := range r.Reachable {
+		if !ok {
+			miss(to)
+		}
+	}
+	if !r.Goal {
+		miss(-1)
+	}
+	return r
+}
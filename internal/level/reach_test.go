@@ -0,0 +1,179 @@
+package level
+
+import (
+	"image"
+	"strings"
+	"testing"
+)
+
+// env is the player's movement, as player.Envelope gives it; the player package
+// imports this one, so it can't be used here.
+var env = Envelope{
+	Width:        28,
+	Height:       28,
+	MoveSpeed:    280,
+	JumpVelocity: -420,
+	Gravity:      980,
+	CoyoteTime:   0.12,
+	WindDrag:     2,
+	StickyJump:   0.7,
+	Step:         1.0 / 60,
+}
+
+// ledges is a level of two platforms: the start on platform 0, at the left, and
+// the goal on the right end of platform 1, at b.
+func ledges(b image.Rectangle) *Level {
+	return &Level{
+		Platforms: []image.Rectangle{image.Rect(0, 600, 200, 720), b},
+		Goal:      image.Rect(b.Max.X-40, b.Min.Y-60, b.Max.X, b.Min.Y),
+		StartX:    80,
+		StartY:    600 - env.Height,
+		Width:     3000,
+		Height:    720,
+	}
+}
+
+func TestCheckReach(t *testing.T) {
+	near := image.Rect(350, 600, 550, 720)  // an easy jump across
+	far := image.Rect(600, 600, 800, 720)   // further than a jump carries
+	high := image.Rect(250, 400, 450, 720)  // higher than a jump goes
+	step := image.Rect(250, 530, 900, 720)  // a little lower than a jump goes
+	ledge := image.Rect(250, 330, 450, 350) // high up, open below
+	with := func(lv *Level, change func(lv *Level)) *Level {
+		change(lv)
+		return lv
+	}
+	tests := []struct {
+		name  string
+		lv    *Level
+		goal  bool
+		short bool // platform 1 is missed by some distance
+		high  bool // and that's because it's too high
+	}{
+		{"a gap a jump clears", ledges(near), true, false, false},
+		{"a gap too far to jump", ledges(far), false, true, false},
+		{"a ledge too high to jump", ledges(high), false, true, true},
+
+		// user-044: pads launch arcs of their own
+		{"a spring up to the ledge", with(ledges(high), func(lv *Level) {
+			lv.Pads = []Pad{{Kind: PadSpring, Rect: image.Rect(150, 590, 180, 600), VY: -900}}
+		}), true, false, false},
+		{"a cannon across the gap", with(ledges(far), func(lv *Level) {
+			lv.Pads = []Pad{{Kind: PadCannon, Rect: image.Rect(150, 590, 180, 600), VX: 600, VY: -400}}
+		}), true, false, false},
+		{"a bounce pad fallen onto from high up", with(ledges(ledge), func(lv *Level) {
+			lv.Pads = []Pad{{Kind: PadBounce, Rect: image.Rect(0, 580, 200, 600), Restitution: 0.9}}
+			lv.StartY = 200
+		}), true, false, false},
+		{"a bounce pad with nothing to fall from", with(ledges(ledge), func(lv *Level) {
+			lv.Pads = []Pad{{Kind: PadBounce, Rect: image.Rect(0, 580, 200, 600), Restitution: 0.9}}
+			lv.StartY = 580 - env.Height
+		}), false, true, true},
+
+		// user-047: zones change the jumps near them, and water joins what's in it
+		{"water up to the ledge", with(ledges(ledge), func(lv *Level) {
+			lv.Zones = []Zone{{Kind: ZoneWater, Rect: image.Rect(200, 300, 400, 720)}}
+		}), true, false, false},
+		{"low gravity up to the ledge", with(ledges(high), func(lv *Level) {
+			lv.Zones = []Zone{{Kind: ZoneGravity, Rect: image.Rect(0, 0, 600, 600), Gravity: 0.3}}
+		}), true, false, false},
+		{"strong gravity under a jump that clears", with(ledges(near), func(lv *Level) {
+			lv.Zones = []Zone{{Kind: ZoneGravity, Rect: image.Rect(0, 0, 600, 600), Gravity: 3}}
+		}), true, false, false},
+		{"wind across the gap", with(ledges(far), func(lv *Level) {
+			lv.Zones = []Zone{{Kind: ZoneWind, Rect: image.Rect(0, 0, 3000, 600), WindX: 600}}
+		}), true, false, false},
+
+		// user-048: a ladder joins what's at its top and bottom
+		{"a ladder up to the ledge", with(ledges(ledge), func(lv *Level) {
+			lv.Climbables = []Climbable{{Kind: ClimbLadder, Rect: image.Rect(220, 330, 240, 600)}}
+		}), true, false, false},
+		{"a ladder too short to grab", with(ledges(ledge), func(lv *Level) {
+			lv.Climbables = []Climbable{{Kind: ClimbLadder, Rect: image.Rect(220, 330, 240, 400)}}
+		}), false, true, true},
+
+		// user-049: sticky ground jumps lower, conveyors speed the run-up
+		{"a step up off plain ground", ledges(step), true, false, false},
+		{"a step up off sticky ground", with(ledges(step), func(lv *Level) {
+			lv.Materials = []Material{{Kind: MaterialSticky, Rect: lv.Platforms[0]}}
+		}), false, true, true},
+		{"sticky ground under only part of the takeoff", with(ledges(step), func(lv *Level) {
+			lv.Materials = []Material{{Kind: MaterialSticky, Rect: image.Rect(0, 600, 100, 720)}}
+		}), true, false, false},
+		{"a conveyor toward the gap", with(ledges(far), func(lv *Level) {
+			lv.Materials = []Material{{Kind: MaterialConveyor, Rect: lv.Platforms[0], Speed: 300}}
+		}), true, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.lv.CheckReach(env)
+			if r.Start < 0 {
+				t.Fatal("no start surface")
+			}
+			if r.Goal != tt.goal {
+				t.Errorf("goal reachable %v, want %v; %v", r.Goal, tt.goal, r.Err())
+			}
+			if r.Reachable[1] == tt.short {
+				t.Errorf("platform 1 reachable %v, want %v", r.Reachable[1], !tt.short)
+			}
+			if !tt.short {
+				return
+			}
+			for _, m := range r.Misses {
+				if m.To != 1 {
+					continue
+				}
+				if m.Short <= 0 || m.High != tt.high {
+					t.Errorf("missed platform 1 by %.0fpx, high %v; want high %v", m.Short, m.High, tt.high)
+				}
+				return
+			}
+			t.Errorf("no miss for platform 1 in %+v", r.Misses)
+		})
+	}
+}
+
+func TestCheckReachErr(t *testing.T) {
+	tests := []struct {
+		name string
+		lv   *Level
+		want string // empty means no error
+	}{
+		{"reachable", ledges(image.Rect(350, 600, 550, 720)), ""},
+		{"too far", ledges(image.Rect(600, 600, 800, 720)), "px too far to reach from surface 0"},
+		{"too high", ledges(image.Rect(40, 200, 200, 240)), "px too high to reach from surface 0"},
+		{"a start over nothing", func() *Level {
+			lv := ledges(image.Rect(350, 600, 550, 720))
+			lv.StartX = 250
+			return lv
+		}(), "start position is not above a platform or slope"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.lv.CheckReach(env).Err()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.want != "" && err == nil:
+				t.Errorf("no error, want %q", tt.want)
+			case tt.want != "" && !strings.Contains(err.Error(), tt.want):
+				t.Errorf("error %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckReachWalksOntoSlopes(t *testing.T) {
+	// a ramp from the start platform up to a ledge far higher than a jump
+	ramp := Ramp(200, 600, 400, 30, true)
+	lv := ledges(image.Rect(600, ramp.Rect.Min.Y, 800, 720))
+	lv.Slopes = []Slope{ramp}
+	r := lv.CheckReach(env)
+	if !r.Goal {
+		t.Errorf("goal at the top of a ramp unreachable: %v", r.Err())
+	}
+	lv.Slopes = nil
+	if lv.CheckReach(env).Goal {
+		t.Error("goal reachable without the ramp")
+	}
+}
@@ -0,0 +1,140 @@
+package level
+
+import (
+	"image"
+	"math"
+)
+
+// Slope is a solid ramp: the right triangle of Rect below its diagonal. A rising
+// slope goes up to the right, so its tall side is at Rect.Max.X; a falling one goes
+// down to the right. The angle comes from the rect, so a square is a 45° tile and
+// one about 2.4 times as wide as it is tall is a 22.5° tile.
+type Slope struct {
+	Rect   image.Rectangle
+	Rising bool
+}
+
+// Ramp returns a slope width pixels wide at the given angle in degrees, with its
+// lower end at x and its base at bottom.
+func Ramp(x, bottom, width int, degrees float64, rising bool) Slope {
+	h := int(math.Round(float64(width) * math.Tan(degrees*math.Pi/180)))
+	return Slope{Rect: image.Rect(x, bottom-h, x+width, bottom), Rising: rising}
+}
+
+// Grade returns how many pixels the surface rises for each pixel to the right;
+// negative for a falling slope.
+func (s Slope) Grade() float64 {
+	g := float64(s.Rect.Dy()) / float64(s.Rect.Dx())
+	if !s.Rising {
+		return -g
+	}
+	return g
+}
+
+// SurfaceAt returns the world Y of the surface at world x, which is clamped to the slope.
+func (s Slope) SurfaceAt(x float64) float64 {
+	x = math.Max(float64(s.Rect.Min.X), math.Min(x, float64(s.Rect.Max.X)))
+	return float64(s.Rect.Max.Y) - (x-float64(s.lowX()))*s.Grade()
+}
+
+// lowX returns the X of the slope's lower end.
+func (s Slope) lowX() int {
+	if s.Rising {
+		return s.Rect.Min.X
+	}
+	return s.Rect.Max.X
+}
+
+// Ends returns the bottom of the lower end and the top of the higher end, where
+// the slope joins whatever the player walks onto from it.
+func (s Slope) Ends() (low, high image.Point) {
+	if s.Rising {
+		return image.Pt(s.Rect.Min.X, s.Rect.Max.Y), image.Pt(s.Rect.Max.X, s.Rect.Min.Y)
+	}
+	return image.Pt(s.Rect.Max.X, s.Rect.Max.Y), image.Pt(s.Rect.Min.X, s.Rect.Min.Y)
+}
+
+// Corners returns the triangle's corners: the two ends of the surface, then the
+// foot of the tall side.
+func (s Slope) Corners() [3]image.Point {
+	low, high := s.Ends()
+	return [3]image.Point{low, high, image.Pt(high.X, s.Rect.Max.Y)}
+}
+
+// under returns the highest point of the surface under a box spanning x0 to x1, or
+// false if the box isn't over the slope.
+func (s Slope) under(x0, x1 float64) (float64, bool) {
+	lo, hi := math.Max(x0, float64(s.Rect.Min.X)), math.Min(x1, float64(s.Rect.Max.X))
+	if lo >= hi {
+		return 0, false
+	}
+	if s.Rising {
+		return s.SurfaceAt(hi), true
+	}
+	return s.SurfaceAt(lo), true
+}
+
+// resolveSlope pushes a w x h box at x, y moving at vy out of s: up onto the surface,
+// out past the tall side, or down below the base when jumping into it from beneath,
+// whichever is least.
+func resolveSlope(s Slope, x, y, w, h, vx, vy float64) (nx, ny, nvx, nvy float64, grounded, hit bool) {
+	r := s.Rect
+	surf, over := s.under(x, x+w)
+	if !over || y >= float64(r.Max.Y) {
+		return x, y, vx, vy, false, false
+	}
+	up, down := y+h-surf, float64(r.Max.Y)-y
+	if up <= 0 {
+		return x, y, vx, vy, false, false
+	}
+	side, sideX := float64(r.Max.X)-x, float64(r.Max.X)
+	if !s.Rising {
+		side, sideX = x+w-float64(r.Min.X), float64(r.Min.X)-w
+	}
+	switch {
+	case vy < 0 && down < up && down < side:
+		return x, float64(r.Max.Y), vx, 0, false, true // head hit the underside
+	case side < up:
+		return sideX, y, 0, vy, false, true
+	}
+	return x, math.Floor(surf - h), vx, 0, true, true
+}
+
+// SlopeUnder returns the grade of the slope rect is standing on, or 0.
+func (l *Level) SlopeUnder(rect image.Rectangle) float64 {
+	for _, s := range l.Slopes {
+		if surf, ok := s.under(float64(rect.Min.X), float64(rect.Max.X)); ok && math.Abs(surf-float64(rect.Max.Y)) <= 1 {
+			return s.Grade()
+		}
+	}
+	return 0
+}
+
+// SnapDown returns how far rect must move down, at most dist, to stand on a slope,
+// or with platforms also on a platform. Walking down a slope leaves the ground a
+// little every tick; snapping keeps the player on it, and on the ground at its foot,
+// instead of bouncing down in small falls.
+func (l *Level) SnapDown(rect image.Rectangle, dist float64, platforms bool) (dy float64, ok bool) {
+	feet := float64(rect.Max.Y)
+	best := math.Inf(1)
+	try := func(top float64) {
+		if d := top - feet; d >= 0 && d <= dist && d < best {
+			best = d
+		}
+	}
+	for _, s := range l.Slopes {
+		if surf, over := s.under(float64(rect.Min.X), float64(rect.Max.X)); over {
+			try(math.Floor(surf))
+		}
+	}
+	if platforms {
+		for _, plats := range [...][]image.Rectangle{l.Platforms, l.Extra} {
+			for _, p := range plats {
+				if rect.Min.X < p.Max.X && rect.Max.X > p.Min.X {
+					try(float64(p.Min.Y))
+				}
+			}
+		}
+	}
+	return best, !math.IsInf(best, 1)
+}
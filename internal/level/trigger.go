@@ -0,0 +1,153 @@
+package level
+
+import (
+	"image"
+	"slices"
+)
+
+// Gate kinds.
+const (
+	GateDoor   = "door"   // solid until one of its triggers is on
+	GateBridge = "bridge" // solid only while one of its triggers is on
+)
+
+// GateKinds lists the gates the game knows how to play and draw.
+var GateKinds = []string{GateDoor, GateBridge}
+
+// Trigger kinds.
+const (
+	TriggerKey    = "key"    // picked up by touching it, and stays on
+	TriggerSwitch = "switch" // turns on while stood in
+	TriggerLever  = "lever"  // flipped on and off by pressing Down in front of it
+)
+
+// TriggerKinds lists the triggers the game knows how to play and draw.
+var TriggerKinds = []string{TriggerKey, TriggerSwitch, TriggerLever}
+
+// Gate is a set of platforms that triggers make appear or disappear together, like
+// a door to open or a bridge to put out. A door and a bridge worked by the same
+// lever swap places each time it's flipped.
+type Gate struct {
+	ID    string
+	Kind  string
+	Rects []image.Rectangle
+}
+
+// Trigger works the gates named in Targets while it is on.
+type Trigger struct {
+	Kind    string
+	Rect    image.Rectangle
+	Targets []string // IDs of the gates it works
+	Time    float64  // seconds a switch stays on after it's left, or a lever before it flips back; 0 means for good
+}
+
+// TriggerState is what changes about a trigger during play.
+type TriggerState struct {
+	On    bool    // picked up, pressed or pulled
+	Timer float64 // seconds until it turns off by itself
+}
+
+// GateSolid reports whether gate i's platforms are there to stand on.
+func (l *Level) GateSolid(i int) bool {
+	on := i < len(l.Live.Gates) && l.Live.Gates[i]
+	return on == (l.Gates[i].Kind == GateBridge)
+}
+
+// GateIndex returns the index of the gate with the given ID, or -1.
+func (l *Level) GateIndex(id string) int {
+	return slices.IndexFunc(l.Gates, func(g Gate) bool { return g.ID == id })
+}
+
+// UpdateTriggers picks up keys and presses switches that players overlap, runs down
+// the timers of switches and levers by dt, and returns the triggers that turned on
+// or off.
+func (l *Level) UpdateTriggers(dt float64, players []image.Rectangle) (changed []image.Rectangle) {
+	for i, t := range l.Triggers {
+		st := &l.Live.Triggers[i]
+		was := st.On
+		switch {
+		case t.Kind != TriggerLever && overlapsAny(t.Rect, players):
+			st.On, st.Timer = true, t.Time
+		case st.On && t.Kind != TriggerKey && t.Time > 0:
+			if st.Timer -= dt; st.Timer <= 0 {
+				st.On = false
+			}
+		}
+		if st.On != was {
+			changed = append(changed, t.Rect)
+		}
+	}
+	if changed != nil {
+		l.workGates()
+	}
+	return changed
+}
+
+// Pull flips a lever rect is in front of, and returns it.
+func (l *Level) Pull(rect image.Rectangle) (image.Rectangle, bool) {
+	for i, t := range l.Triggers {
+		if t.Kind != TriggerLever || !rect.Overlaps(t.Rect) {
+			continue
+		}
+		st := &l.Live.Triggers[i]
+		st.On, st.Timer = !st.On, t.Time
+		l.workGates()
+		return t.Rect, true
+	}
+	return image.Rectangle{}, false
+}
+
+// workGates turns on each gate with a trigger on.
+func (l *Level) workGates() {
+	for gi, g := range l.Gates {
+		l.Live.Gates[gi] = false
+		for ti, t := range l.Triggers {
+			if l.Live.Triggers[ti].On && slices.Contains(t.Targets, g.ID) {
+				l.Live.Gates[gi] = true
+				break
+			}
+		}
+	}
+}
+
+// Live is the part of a level that changes during play: broken blocks, triggers and
+// the gates they work. Copying it with Clone saves it.
+type Live struct {
+	Blocks   []BlockState
+	Triggers []TriggerState
+	Gates    []bool // whether each gate has a trigger on
+}
+
+// Clone returns a copy of lv that shares nothing with it.
+func (lv Live) Clone() Live {
+	return Live{
+		Blocks:   slices.Clone(lv.Blocks),
+		Triggers: slices.Clone(lv.Triggers),
+		Gates:    slices.Clone(lv.Gates),
+	}
+}
+
+// Equal reports whether lv and o are the same.
+func (lv Live) Equal(o Live) bool {
+	return slices.Equal(lv.Blocks, o.Blocks) && slices.Equal(lv.Triggers, o.Triggers) && slices.Equal(lv.Gates, o.Gates)
+}
+
+// Update runs the level for a tick of dt after the players, at players, have moved:
+// crumbling blocks fall and come back, and keys and switches work their gates. The
+// game, the bot and the leaderboard all call it, so they play a level the same way.
+// It returns the blocks that fell and the triggers that changed, for effects.
+func (l *Level) Update(dt float64, players []image.Rectangle) (fell, changed []image.Rectangle) {
+	if len(l.Blocks) == 0 && len(l.Triggers) == 0 {
+		return nil, nil
+	}
+	return l.UpdateBlocks(dt, players), l.UpdateTriggers(dt, players)
+}
+
+// Reset puts back every broken block and turns every trigger off.
+func (l *Level) Reset() {
+	l.Live = Live{
+		Blocks:   make([]BlockState, len(l.Blocks)),
+		Triggers: make([]TriggerState, len(l.Triggers)),
+		Gates:    make([]bool, len(l.Gates)),
+	}
+}
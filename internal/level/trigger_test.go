@@ -0,0 +1,135 @@
+package level
+
+import (
+	"image"
+	"testing"
+)
+
+// gated returns a level with a door and a bridge, both worked by trigger, which is
+// at 100,560.
+func gated(trigger Trigger) *Level {
+	trigger.Rect = image.Rect(100, 560, 120, 580)
+	trigger.Targets = []string{"door", "bridge"}
+	lv := &Level{
+		Platforms: []image.Rectangle{image.Rect(0, 600, 1000, 720)},
+		Gates: []Gate{
+			{ID: "door", Kind: GateDoor, Rects: []image.Rectangle{image.Rect(300, 500, 320, 600)}},
+			{ID: "bridge", Kind: GateBridge, Rects: []image.Rectangle{image.Rect(400, 600, 500, 620)}},
+		},
+		Triggers: []Trigger{trigger},
+	}
+	lv.Reset()
+	return lv
+}
+
+var (
+	onTrigger  = []image.Rectangle{image.Rect(96, 572, 124, 600)}
+	offTrigger = []image.Rectangle{image.Rect(196, 572, 224, 600)}
+)
+
+// open reports whether trigger 0 is on, and fails t if the gates don't agree.
+func open(t *testing.T, lv *Level) bool {
+	t.Helper()
+	on := lv.Live.Triggers[0].On
+	if lv.GateSolid(0) == on || lv.GateSolid(1) != on {
+		t.Fatalf("trigger on %v, but door solid %v and bridge solid %v", on, lv.GateSolid(0), lv.GateSolid(1))
+	}
+	return on
+}
+
+func TestKeysStayPickedUp(t *testing.T) {
+	lv := gated(Trigger{Kind: TriggerKey})
+	if changed := lv.UpdateTriggers(tick, offTrigger); changed != nil || open(t, lv) {
+		t.Fatalf("the key turned on from a distance: %v", changed)
+	}
+	if changed := lv.UpdateTriggers(tick, onTrigger); len(changed) != 1 || !open(t, lv) {
+		t.Fatalf("touching the key changed %v", changed)
+	}
+	for range 10 * 60 {
+		if changed := lv.UpdateTriggers(tick, offTrigger); changed != nil {
+			t.Fatalf("the key changed %v after it was picked up", changed)
+		}
+	}
+	if !open(t, lv) {
+		t.Error("the key was dropped")
+	}
+	if _, ok := lv.Pull(onTrigger[0]); ok || !open(t, lv) {
+		t.Error("pulled a key like a lever")
+	}
+	lv.Reset()
+	if open(t, lv) {
+		t.Error("the key is still held after Reset")
+	}
+}
+
+func TestSwitches(t *testing.T) {
+	const hold = 1.0
+	for _, secs := range []float64{0, hold} {
+		lv := gated(Trigger{Kind: TriggerSwitch, Time: secs})
+		lv.UpdateTriggers(tick, onTrigger)
+		if !open(t, lv) {
+			t.Fatalf("time %g: standing on the switch didn't press it", secs)
+		}
+		// still pressed while stood on, however long
+		for range 3 * 60 {
+			lv.UpdateTriggers(tick, onTrigger)
+		}
+		if !open(t, lv) {
+			t.Fatalf("time %g: the switch came up while stood on", secs)
+		}
+		ticks := 0
+		for ; ticks < 5*60 && open(t, lv); ticks++ {
+			lv.UpdateTriggers(tick, offTrigger)
+		}
+		switch {
+		case secs == 0 && ticks != 5*60:
+			t.Errorf("a switch with no time came up after %d ticks", ticks)
+		case secs > 0 && (ticks < int(secs/tick) || ticks > int(secs/tick)+1):
+			t.Errorf("a %gs switch came up after %d ticks", secs, ticks)
+		}
+	}
+}
+
+func TestLevers(t *testing.T) {
+	lv := gated(Trigger{Kind: TriggerLever})
+	if changed := lv.UpdateTriggers(tick, onTrigger); changed != nil || open(t, lv) {
+		t.Fatal("standing in front of a lever pulled it")
+	}
+	if _, ok := lv.Pull(offTrigger[0]); ok || open(t, lv) {
+		t.Fatal("pulled a lever from a distance")
+	}
+	for _, want := range []bool{true, false, true} {
+		if r, ok := lv.Pull(onTrigger[0]); !ok || r != lv.Triggers[0].Rect || open(t, lv) != want {
+			t.Fatalf("pull: got %v %v, want the lever on %v", r, ok, want)
+		}
+	}
+
+	// a timed lever flips back by itself
+	lv = gated(Trigger{Kind: TriggerLever, Time: 1})
+	lv.Pull(onTrigger[0])
+	ticks := 0
+	for ; ticks < 5*60 && open(t, lv); ticks++ {
+		lv.UpdateTriggers(tick, onTrigger)
+	}
+	if ticks < 60 || ticks > 61 {
+		t.Errorf("a 1s lever flipped back after %d ticks", ticks)
+	}
+}
+
+func TestGatesOpenWhileAnyTriggerIsOn(t *testing.T) {
+	lv := gated(Trigger{Kind: TriggerSwitch, Time: 1})
+	lv.Triggers = append(lv.Triggers, Trigger{Kind: TriggerLever, Rect: image.Rect(600, 560, 620, 580), Targets: []string{"door"}})
+	lv.Reset()
+	lv.Pull(image.Rect(596, 572, 624, 600))
+	if lv.GateSolid(0) || lv.GateSolid(1) {
+		t.Fatalf("the lever opened the door %v and put out the bridge %v, want only the door", !lv.GateSolid(0), lv.GateSolid(1))
+	}
+	lv.UpdateTriggers(tick, onTrigger)
+	for range 2 * 60 {
+		lv.UpdateTriggers(tick, offTrigger)
+	}
+	// the switch has come up, but the lever still holds the door
+	if lv.GateSolid(0) || lv.GateSolid(1) {
+		t.Errorf("door solid %v and bridge solid %v, want the door open and the bridge gone", lv.GateSolid(0), lv.GateSolid(1))
+	}
+}
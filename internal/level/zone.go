@@ -0,0 +1,62 @@
+package level
+
+import "image"
+
+// Zone kinds.
+const (
+	ZoneWater   = "water"   // the player floats, slows down and swims
+	ZoneGravity = "gravity" // gravity is Gravity times normal
+	ZoneWind    = "wind"    // pushes the player at WindX, WindY
+)
+
+// MaxZoneGravity is the strongest a gravity zone can pull, as a share of normal.
+const MaxZoneGravity = 3
+
+// ZoneKinds lists the zones the game knows how to play and draw.
+var ZoneKinds = []string{ZoneWater, ZoneGravity, ZoneWind}
+
+// Zone is a region of the level that changes how the player moves while inside it.
+// Zones have no collision, and where they overlap their effects add up.
+type Zone struct {
+	Kind         string
+	Rect         image.Rectangle
+	Gravity      float64 // gravity zones: share of normal gravity, above 0
+	WindX, WindY float64 // wind zones: push in pixels/second², negative WindY is up
+}
+
+// Physics is how the zones a player is in change their movement. Each zone counts
+// for the share of the player inside it, so moving in and out is gradual.
+type Physics struct {
+	Gravity      float64 // share of normal gravity
+	Water        float64 // share of the player underwater, 0 to 1
+	WindX, WindY float64 // push in pixels/second²
+}
+
+// Normal is Physics outside every zone.
+var Normal = Physics{Gravity: 1}
+
+// PhysicsAt returns the physics for a player at rect.
+func (l *Level) PhysicsAt(rect image.Rectangle) Physics {
+	ph := Normal
+	area := float64(rect.Dx() * rect.Dy())
+	if area == 0 {
+		return ph
+	}
+	for _, z := range l.Zones {
+		in := rect.Intersect(z.Rect)
+		if in.Empty() {
+			continue
+		}
+		f := float64(in.Dx()*in.Dy()) / area
+		switch z.Kind {
+		case ZoneWater:
+			ph.Water = min(1, ph.Water+f)
+		case ZoneGravity:
+			ph.Gravity += f * (z.Gravity - 1)
+		case ZoneWind:
+			ph.WindX += f * z.WindX
+			ph.WindY += f * z.WindY
+		}
+	}
+	return ph
+}
@@ -0,0 +1,225 @@
+// Package levelgen builds random levels from a seed.
+package levelgen
+
+import (
+	"fmt"
+	"image"
+	"math/rand/v2"
+
+	"platform-game-one/internal/level"
+)
+
+const (
+	screens     = 4   // level width in screens
+	maxAttempts = 100 // layouts tried before giving up on a seed
+	snap        = 10  // platform coordinates are multiples of this
+	ceiling     = 440 // highest platform top above the floor line
+	goalRun     = 800 // room left for the end of a section and the goal platform
+)
+
+// Params selects a generated level. The same Params always give the same level.
+type Params struct {
+	Seed       uint64  `json:"seed"`
+	Difficulty float64 `json:"difficulty"` // 0 is easiest, 1 is hardest
+}
+
+// Validate checks that the difficulty is in range.
+func (p Params) Validate() error {
+	if p.Difficulty < 0 || p.Difficulty > 1 {
+		return fmt.Errorf("difficulty %g out of range 0-1", p.Difficulty)
+	}
+	return nil
+}
+
+// Next returns the params for the level after this one.
+func (p Params) Next() Params {
+	p.Seed++
+	return p
+}
+
+// Generate builds a level screens wide out of sections like the built-in levels:
+// stairs, zigzags, narrow bridges and gauntlets. Gaps and climbs stay inside env's
+// jump envelope, and every level returned passes level.CheckReach.
+func Generate(p Params, env level.Envelope, screenW, screenH int) (*level.Level, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	src := rand.NewPCG(p.Seed, p.Seed^0x9e3779b97f4a7c15)
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		b := newBuilder(src, p.Difficulty, env, screenW, screenH)
+		lv := b.build()
+		lv.Name = fmt.Sprintf("Seed %d", p.Seed)
+		if lv.CheckReach(env).Err() == nil {
+			return lv, nil
+		}
+	}
+	return nil, fmt.Errorf("seed %d: no reachable layout in %d attempts", p.Seed, maxAttempts)
+}
+
+// builder lays platforms out left to right.
+type builder struct {
+	src    rand.Source // only Uint64 is used, so levels don't change between Go versions
+	d      float64
+	env    level.Envelope
+	w, h   int
+	floorY int
+	x, top int // right edge and top of the last platform
+
+	platforms []image.Rectangle
+	props     []level.Prop
+}
+
+func newBuilder(src rand.Source, d float64, env level.Envelope, screenW, screenH int) *builder {
+	h := screenH * 2
+	return &builder{src: src, d: d, env: env, w: screenW * screens, h: h, floorY: h - 48}
+}
+
+// float returns a number in [0, 1).
+func (b *builder) float() float64 { return float64(b.src.Uint64()>>11) / (1 << 53) }
+
+// between returns a number in [lo, hi], rounded to snap.
+func (b *builder) between(lo, hi float64) int {
+	return round(lo + (hi-lo)*b.float())
+}
+
+func round(v float64) int {
+	if v < 0 {
+		return -round(-v)
+	}
+	return int(v+snap/2) / snap * snap
+}
+
+// lerp picks a value between easy and hard by difficulty.
+func (b *builder) lerp(easy, hard float64) float64 { return easy + (hard-easy)*b.d }
+
+// rise returns a climb that fits the jump, bigger on harder levels.
+func (b *builder) rise() int {
+	max := b.env.JumpHeight() * b.lerp(0.45, 0.85)
+	return b.between(max/2, max)
+}
+
+// gap returns a horizontal gap for a jump that lands rise pixels higher.
+func (b *builder) gap(rise int) int {
+	reach := b.env.JumpDistance(float64(rise))
+	return b.between(reach*b.lerp(0.15, 0.5), reach*b.lerp(0.4, 0.8))
+}
+
+// width returns a platform width, narrower on harder levels.
+func (b *builder) width() int {
+	return b.between(b.lerp(140, 50), b.lerp(200, 80))
+}
+
+// place adds a platform gap pixels right of the last one with its top rise pixels
+// higher, keeping it between the floor and the ceiling.
+func (b *builder) place(gap, rise, width int) {
+	top := b.top - rise
+	if top < b.floorY-ceiling || top > b.floorY-20 {
+		// climbing would leave the level; go the other way instead
+		top = b.top + rise
+		gap = b.gap(-rise)
+	}
+	r := image.Rect(b.x+gap, top, b.x+gap+width, top+b.between(40, 60))
+	b.platforms = append(b.platforms, r)
+	b.x, b.top = r.Max.X, r.Min.Y
+	if width >= 100 && b.float() < 0.4 {
+		b.prop(r)
+	}
+}
+
+func (b *builder) prop(r image.Rectangle) {
+	kinds := []string{"flower", "bush", "rock"}
+	if r.Dx() >= 160 {
+		kinds = append(kinds, "palm")
+	}
+	kind := kinds[int(b.float()*float64(len(kinds)))]
+	b.props = append(b.props, level.Prop{
+		Kind:  kind,
+		X:     float64(b.between(float64(r.Min.X+20), float64(r.Max.X-20))),
+		Y:     float64(r.Min.Y),
+		Scale: 0.8 + 0.4*b.float(),
+	})
+}
+
+// full reports whether it's time for the goal platform.
+func (b *builder) full() bool { return b.x >= b.w-goalRun }
+
+// sections are the building blocks, modeled on the built-in levels.
+var sections = []func(*builder){
+	(*builder).stairs,
+	(*builder).zigzag,
+	(*builder).bridge,
+	(*builder).gauntlet,
+}
+
+// stairs climbs or descends a few steps of the same height.
+func (b *builder) stairs() {
+	rise := b.rise()
+	if b.float() < 0.5 {
+		rise = -rise
+	}
+	for n := 3 + int(b.float()*3); n > 0 && !b.full(); n-- {
+		b.place(b.gap(rise), rise, b.width())
+	}
+}
+
+// zigzag alternates up and down.
+func (b *builder) zigzag() {
+	rise := b.rise()
+	for n := 4 + int(b.float()*3); n > 0 && !b.full(); n-- {
+		b.place(b.gap(rise), rise, b.width())
+		rise = -rise
+	}
+}
+
+// bridge is a row of narrow platforms at one height.
+func (b *builder) bridge() {
+	w := b.between(b.lerp(80, 40), b.lerp(100, 50))
+	for n := 4 + int(b.float()*3); n > 0 && !b.full(); n-- {
+		b.place(b.gap(0), 0, w)
+	}
+}
+
+// gauntlet alternates big climbs and drops across the widest gaps.
+func (b *builder) gauntlet() {
+	rise := round(b.env.JumpHeight() * b.lerp(0.6, 0.9))
+	for n := 4 + int(b.float()*2); n > 0 && !b.full(); n-- {
+		reach := b.env.JumpDistance(float64(rise))
+		b.place(b.between(reach*b.lerp(0.3, 0.6), reach*b.lerp(0.5, 0.85)), rise, b.width())
+		rise = -rise
+	}
+}
+
+func (b *builder) build() *level.Level {
+	// start platform, plus a floor everywhere on easy levels
+	start := image.Rect(0, b.floorY, 240, b.h)
+	if b.d < 0.25 {
+		start.Max.X = b.w
+	}
+	b.platforms = append(b.platforms, start)
+	b.x, b.top = 240, b.floorY
+
+	for !b.full() {
+		sections[int(b.float()*float64(len(sections)))](b)
+	}
+
+	// the goal platform runs to the end of the level
+	rise := b.rise()
+	gap := b.gap(rise)
+	b.place(gap, rise, b.w-(b.x+gap))
+	end := &b.platforms[len(b.platforms)-1]
+	end.Max.X = b.w // place may have changed the gap
+	goal := image.Rect(end.Max.X-120, end.Min.Y-100, end.Max.X-24, end.Min.Y)
+
+	themes := []string{"meadow", "sunset", "night"}
+	return &level.Level{
+		Platforms: b.platforms,
+		Goal:      goal,
+		Width:     b.w,
+		Height:    b.h,
+		StartX:    64,
+		StartY:    float64(b.floorY - 40),
+		DeathY:    float64(b.floorY + 100),
+		Theme:     themes[int(b.float()*float64(len(themes)))],
+		Props:     b.props,
+	}
+}
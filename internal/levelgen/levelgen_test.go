@@ -0,0 +1,70 @@
+package levelgen_test
+
+import (
+	"reflect"
+	"testing"
+
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/levelgen"
+	"platform-game-one/internal/player"
+)
+
+const screenW, screenH = level.ScreenWidth, level.ScreenHeight
+
+var env = player.Envelope(1.0 / 60)
+
+func TestSameParamsSameLevel(t *testing.T) {
+	for _, p := range []levelgen.Params{{Seed: 1}, {Seed: 42, Difficulty: 0.5}, {Seed: 1 << 40, Difficulty: 1}} {
+		a, err := levelgen.Generate(p, env, screenW, screenH)
+		if err != nil {
+			t.Fatalf("%+v: %v", p, err)
+		}
+		b, err := levelgen.Generate(p, env, screenW, screenH)
+		if err != nil {
+			t.Fatalf("%+v again: %v", p, err)
+		}
+		if !reflect.DeepEqual(a, b) {
+			t.Errorf("%+v gave two different levels", p)
+		}
+	}
+}
+
+func TestSeedsAndDifficultiesDiffer(t *testing.T) {
+	base, err := levelgen.Generate(levelgen.Params{Seed: 7, Difficulty: 0.5}, env, screenW, screenH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []levelgen.Params{{Seed: 8, Difficulty: 0.5}, {Seed: 7, Difficulty: 0}} {
+		lv, err := levelgen.Generate(p, env, screenW, screenH)
+		if err != nil {
+			t.Fatalf("%+v: %v", p, err)
+		}
+		if reflect.DeepEqual(lv.Platforms, base.Platforms) {
+			t.Errorf("%+v laid out the same platforms as seed 7 at difficulty 0.5", p)
+		}
+	}
+}
+
+func TestGeneratedLevelsAreReachable(t *testing.T) {
+	for _, d := range []float64{0, 0.25, 0.5, 0.75, 1} {
+		for seed := uint64(0); seed < 40; seed++ {
+			p := levelgen.Params{Seed: seed, Difficulty: d}
+			lv, err := levelgen.Generate(p, env, screenW, screenH)
+			if err != nil {
+				t.Errorf("%+v: %v", p, err)
+				continue
+			}
+			if err := lv.CheckReach(env).Err(); err != nil {
+				t.Errorf("%+v: %v", p, err)
+			}
+		}
+	}
+}
+
+func TestDifficultyOutOfRange(t *testing.T) {
+	for _, d := range []float64{-0.1, 1.5} {
+		if _, err := levelgen.Generate(levelgen.Params{Seed: 1, Difficulty: d}, env, screenW, screenH); err == nil {
+			t.Errorf("difficulty %g: no error", d)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package netplay
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"platform-game-one/internal/input"
+)
+
+// A packet carries the sender's inputs that the receiver hasn't acknowledged, so a
+// lost packet is made up for by the next one:
+//
+//	magic   uint8   'R'
+//	ack     uint32  frames of the receiver's input the sender has
+//	start   uint32  frame of the first input
+//	count   uint8
+//	inputs  count × uint8
+//	checked uint32  frame of the checksum, noCheck if none yet
+//	sum     uint64  state checksum at the start of frame checked
+type packet struct {
+	ack     int
+	start   int
+	inputs  []input.Buttons
+	checked int
+	sum     uint64
+}
+
+const (
+	magic     = 'R'
+	maxInputs = 255 // count is one byte
+	noCheck   = 1<<32 - 1
+)
+
+var errBadPacket = errors.New("bad packet")
+
+func (p *packet) encode(buf []byte) []byte {
+	buf = append(buf[:0], magic)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(p.ack))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(p.start))
+	buf = append(buf, uint8(len(p.inputs)))
+	for _, b := range p.inputs {
+		buf = append(buf, uint8(b))
+	}
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(p.checked))
+	return binary.LittleEndian.AppendUint64(buf, p.sum)
+}
+
+func (p *packet) decode(buf []byte) error {
+	if len(buf) < 10 || buf[0] != magic {
+		return errBadPacket
+	}
+	p.ack = int(binary.LittleEndian.Uint32(buf[1:]))
+	p.start = int(binary.LittleEndian.Uint32(buf[5:]))
+	n := int(buf[9])
+	buf = buf[10:]
+	if len(buf) != n+12 {
+		return errBadPacket
+	}
+	p.inputs = p.inputs[:0]
+	for _, b := range buf[:n] {
+		p.inputs = append(p.inputs, input.Buttons(b))
+	}
+	p.checked = int(binary.LittleEndian.Uint32(buf[n:]))
+	p.sum = binary.LittleEndian.Uint64(buf[n+4:])
+	return nil
+}
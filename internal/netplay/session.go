@@ -0,0 +1,277 @@
+// Package netplay runs a two-player game over a network with rollback: each peer
+// simulates ahead on a guess of the other's input and, when the real input arrives
+// and differs, rewinds to the saved state and simulates forward again.
+package netplay
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"platform-game-one/internal/input"
+)
+
+// Players is how many players a session has.
+const Players = 2
+
+// Sim is a deterministic simulation: the same states and inputs always produce the
+// same next state, on every machine.
+type Sim interface {
+	// Step advances one tick with each player's held buttons. resim is true when the
+	// tick is being simulated again after a rollback, so it shouldn't play sounds
+	// or effects a second time.
+	Step(in [Players]input.Buttons, resim bool)
+	Save() State
+	Load(State)
+}
+
+// State is a saved Sim state.
+type State interface {
+	// Checksum hashes everything that affects the simulation, for desync detection.
+	Checksum() uint64
+}
+
+// Config configures a Session. Both peers must use the same Delay.
+type Config struct {
+	Local         int           // which player this peer controls, 0 or 1
+	Delay         int           // ticks between reading local input and using it; hides latency
+	MaxPrediction int           // ticks to run ahead of the peer's input before waiting; 0 means 8
+	Timeout       time.Duration // give up when the peer goes quiet this long; 0 means 5s
+}
+
+const (
+	checkEvery = 60 // ticks between state checksums
+	maxSend    = 64 // inputs sent per packet
+)
+
+// ErrDesync is returned when the peers' states stop matching.
+var ErrDesync = errors.New("desync")
+
+// Stats counts what the session has done, for display.
+type Stats struct {
+	Rollbacks   int // times real input differed from the prediction
+	Resimulated int // ticks simulated again after rollbacks
+	Stalls      int // ticks spent waiting for the peer
+	Ahead       int // ticks simulated past the peer's last input
+}
+
+// Session keeps two peers' simulations in step.
+type Session struct {
+	t     Transport
+	cfg   Config
+	frame int // next tick to simulate
+
+	inputs [Players][]input.Buttons // confirmed inputs by tick
+	ring   []saved                  // the last few ticks' states and predictions
+	acked  int                      // ticks of local input the peer has
+
+	checked   int            // next tick to checksum
+	sums      map[int]uint64 // local checksums the peer hasn't matched yet
+	peerSums  map[int]uint64
+	lastCheck int // tick of the latest local checksum, sent in every packet
+	lastSum   uint64
+	heard     time.Time
+
+	pkt     packet
+	buf     []byte
+	stats   Stats
+	waiting bool // the last Advance waited for the peer
+}
+
+// saved is the state at the start of a tick and the peer input guessed for it.
+type saved struct {
+	frame int
+	state State
+	guess input.Buttons
+}
+
+// New creates a session on t.
+func New(t Transport, cfg Config) (*Session, error) {
+	if cfg.Local < 0 || cfg.Local >= Players {
+		return nil, fmt.Errorf("local player %d out of range 0-%d", cfg.Local, Players-1)
+	}
+	if cfg.Delay < 0 {
+		return nil, fmt.Errorf("input delay %d must not be negative", cfg.Delay)
+	}
+	if cfg.MaxPrediction == 0 {
+		cfg.MaxPrediction = 8
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	s := &Session{
+		t:         t,
+		cfg:       cfg,
+		ring:      make([]saved, cfg.MaxPrediction+2),
+		sums:      map[int]uint64{},
+		peerSums:  map[int]uint64{},
+		lastCheck: noCheck,
+	}
+	// the first Delay ticks have no input on either side
+	for p := range s.inputs {
+		s.inputs[p] = make([]input.Buttons, cfg.Delay)
+	}
+	return s, nil
+}
+
+// Local returns which player this peer controls.
+func (s *Session) Local() int { return s.cfg.Local }
+
+// Frame returns how many ticks have been simulated.
+func (s *Session) Frame() int { return s.frame }
+
+// Confirmed returns how many ticks have been simulated with both players' real
+// input. Those are final: no rollback will run them again.
+func (s *Session) Confirmed() int {
+	return min(s.frame, len(s.inputs[0]), len(s.inputs[1]))
+}
+
+// Stats returns what the session has done so far.
+func (s *Session) Stats() Stats {
+	st := s.stats
+	st.Ahead = max(0, s.frame-len(s.inputs[s.peer()]))
+	return st
+}
+
+// Waiting reports whether the last Advance waited for the peer instead of simulating.
+func (s *Session) Waiting() bool { return s.waiting }
+
+// Close closes the transport.
+func (s *Session) Close() error { return s.t.Close() }
+
+func (s *Session) peer() int { return 1 - s.cfg.Local }
+
+// Advance runs one tick of sim with local held this tick. It reads the peer's
+// packets, rolls back and resimulates if they contradict a guess, and sends local
+// input. It returns false without simulating if the peer is too far behind.
+func (s *Session) Advance(sim Sim, local input.Buttons) (bool, error) {
+	rollback, err := s.receive()
+	if err != nil {
+		return false, err
+	}
+	if rollback < s.frame {
+		s.stats.Rollbacks++
+		sim.Load(s.ring[rollback%len(s.ring)].state)
+		for f := rollback; f < s.frame; f++ {
+			s.simulate(sim, f, true)
+			s.stats.Resimulated++
+		}
+	}
+	if err := s.check(); err != nil {
+		return false, err
+	}
+
+	peer := len(s.inputs[s.peer()])
+	s.waiting = s.frame >= peer+s.cfg.MaxPrediction
+	if s.waiting {
+		s.stats.Stalls++
+		if !s.heard.IsZero() && time.Since(s.heard) > s.cfg.Timeout {
+			return false, fmt.Errorf("peer hasn't answered in %v", s.cfg.Timeout)
+		}
+		return false, s.send()
+	}
+	s.inputs[s.cfg.Local] = append(s.inputs[s.cfg.Local], local)
+	s.simulate(sim, s.frame, false)
+	s.frame++
+	return true, s.send()
+}
+
+// simulate saves the state at the start of tick f and runs it, guessing that the
+// peer still holds their last known buttons.
+func (s *Session) simulate(sim Sim, f int, resim bool) {
+	var in [Players]input.Buttons
+	for p := range in {
+		known := s.inputs[p]
+		switch {
+		case f < len(known):
+			in[p] = known[f]
+		case len(known) > 0:
+			in[p] = known[len(known)-1]
+		}
+	}
+	s.ring[f%len(s.ring)] = saved{frame: f, state: sim.Save(), guess: in[s.peer()]}
+	sim.Step(in, resim)
+}
+
+// receive takes in the peer's packets and returns the earliest tick whose guess
+// turned out wrong, or s.frame if none did.
+func (s *Session) receive() (int, error) {
+	rollback := s.frame
+	for {
+		buf, ok := s.t.Receive()
+		if !ok {
+			return rollback, nil
+		}
+		if err := s.pkt.decode(buf); err != nil {
+			continue // not ours, or corrupted
+		}
+		s.heard = time.Now()
+		s.acked = max(s.acked, s.pkt.ack)
+		if s.pkt.checked != noCheck {
+			s.peerSums[s.pkt.checked] = s.pkt.sum
+		}
+		known := &s.inputs[s.peer()]
+		for i, b := range s.pkt.inputs {
+			f := s.pkt.start + i
+			if f != len(*known) {
+				continue // already have it, or a packet in between was lost
+			}
+			*known = append(*known, b)
+			if f < rollback && s.ring[f%len(s.ring)].guess != b {
+				rollback = f
+			}
+		}
+	}
+}
+
+// check checksums ticks whose inputs are now all confirmed and compares them with
+// the peer's.
+func (s *Session) check() error {
+	// the state at the start of tick f is final once the inputs before it are
+	confirmed := min(s.frame-1, len(s.inputs[0]), len(s.inputs[1]))
+	for ; s.checked <= confirmed; s.checked += checkEvery {
+		sv := s.ring[s.checked%len(s.ring)]
+		if sv.frame != s.checked || sv.state == nil {
+			continue // fell out of the ring while stalled; skip it
+		}
+		s.lastCheck, s.lastSum = s.checked, sv.state.Checksum()
+		s.sums[s.checked] = s.lastSum
+	}
+	for f, sum := range s.peerSums {
+		mine, ok := s.sums[f]
+		if !ok {
+			if f < s.checked-checkEvery*4 {
+				delete(s.peerSums, f) // one we skipped
+			}
+			continue
+		}
+		if mine != sum {
+			return fmt.Errorf("%w at tick %d", ErrDesync, f)
+		}
+		delete(s.peerSums, f)
+		delete(s.sums, f)
+	}
+	for f := range s.sums {
+		if f < s.checked-checkEvery*4 {
+			delete(s.sums, f) // the peer skipped it
+		}
+	}
+	return nil
+}
+
+// send sends the local inputs the peer hasn't acknowledged.
+func (s *Session) send() error {
+	local := s.inputs[s.cfg.Local]
+	start := min(s.acked, len(local))
+	end := min(len(local), start+maxSend)
+	s.pkt = packet{
+		ack:     len(s.inputs[s.peer()]),
+		start:   start,
+		inputs:  local[start:end],
+		checked: s.lastCheck,
+		sum:     s.lastSum,
+	}
+	s.buf = s.pkt.encode(s.buf)
+	s.pkt.inputs = nil // don't let decode write into local
+	return s.t.Send(s.buf)
+}
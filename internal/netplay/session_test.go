@@ -0,0 +1,197 @@
+package netplay
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"platform-game-one/internal/input"
+)
+
+// toy is a tiny deterministic Sim: each player's number folds in their buttons
+// every tick, so any input applied to the wrong tick changes every later state.
+type toy struct {
+	tick   int
+	pos    [Players]int64
+	skew   int64          // added to player 0 every tick, to make one peer diverge
+	after  map[int]uint64 // checksum of the state reached by each tick, latest simulation
+	resims int            // ticks stepped with resim set
+}
+
+type toyState struct {
+	tick int
+	pos  [Players]int64
+}
+
+func (s toyState) Checksum() uint64 {
+	h := fnv.New64a()
+	var buf []byte
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(s.tick))
+	for _, p := range s.pos {
+		buf = binary.LittleEndian.AppendUint64(buf, uint64(p))
+	}
+	h.Write(buf)
+	return h.Sum64()
+}
+
+func newToy() *toy { return &toy{after: map[int]uint64{}} }
+
+func (t *toy) Step(in [Players]input.Buttons, resim bool) {
+	for p := range t.pos {
+		t.pos[p] = t.pos[p]*31 + int64(in[p]) + 1
+	}
+	t.pos[0] += t.skew
+	t.tick++
+	t.after[t.tick] = t.Save().Checksum()
+	if resim {
+		t.resims++
+	}
+}
+
+func (t *toy) Save() State { return toyState{t.tick, t.pos} }
+
+func (t *toy) Load(s State) {
+	st := s.(toyState)
+	t.tick, t.pos = st.tick, st.pos
+}
+
+// peer is one side of a test game.
+type peer struct {
+	s      *Session
+	sim    *toy
+	script []input.Buttons // local buttons for each frame
+}
+
+// testGame runs two peers over a loopback, impaired as link says, with a fake clock
+// that moves one tick per round.
+type testGame struct {
+	peers [Players]*peer
+	now   time.Time
+}
+
+const tick = time.Second / 60
+
+func newTestGame(t *testing.T, link Link, frames int) *testGame {
+	t.Helper()
+	g := &testGame{now: time.Unix(0, 0)}
+	link.Now = func() time.Time { return g.now }
+	a, b := NewLoopback()
+	ends := [Players]Transport{a, b}
+	for p := range g.peers {
+		l := link
+		l.Seed += uint64(p) // the two directions lose different packets
+		s, err := New(Impair(ends[p], l), Config{Local: p, Delay: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rng := rand.New(rand.NewPCG(uint64(p), 1))
+		script := make([]input.Buttons, frames)
+		for f := range script {
+			if f > 0 && rng.IntN(8) != 0 {
+				script[f] = script[f-1] // players hold buttons for a while
+			} else {
+				script[f] = input.Buttons(rng.IntN(16))
+			}
+		}
+		g.peers[p] = &peer{s: s, sim: newToy(), script: script}
+	}
+	return g
+}
+
+// run plays rounds until both peers have simulated frames ticks, and returns the
+// first error either session reports.
+func (g *testGame) run(frames int) error {
+	for round := 0; round < frames*10; round++ {
+		g.now = g.now.Add(tick)
+		done := true
+		for _, pr := range g.peers {
+			f := pr.s.Frame()
+			if f >= frames {
+				f = frames - 1 // keep holding the last buttons while the other catches up
+			} else {
+				done = false
+			}
+			if _, err := pr.s.Advance(pr.sim, pr.script[f]); err != nil {
+				return err
+			}
+		}
+		if done {
+			return nil
+		}
+	}
+	return errors.New("peers never finished")
+}
+
+// confirmed returns how many ticks both peers know every input for.
+func (g *testGame) confirmed() int {
+	return min(g.peers[0].s.Confirmed(), g.peers[1].s.Confirmed())
+}
+
+func TestSessionConvergesOverBadLink(t *testing.T) {
+	const frames = 600
+	for _, link := range []Link{
+		{},
+		{Latency: 50 * time.Millisecond, Jitter: 30 * time.Millisecond, Loss: 0.2, Seed: 1},
+		{Latency: 120 * time.Millisecond, Loss: 0.4, Seed: 2},
+	} {
+		g := newTestGame(t, link, frames)
+		if err := g.run(frames); err != nil {
+			t.Fatalf("%+v: %v", link, err)
+		}
+		a, b := g.peers[0], g.peers[1]
+		n := g.confirmed()
+		if n < frames/2 {
+			t.Fatalf("%+v: only %d of %d ticks confirmed", link, n, frames)
+		}
+
+		// both peers saw the same inputs, and ended up where a plain run on them does
+		ref := newToy()
+		for f := 0; f < n; f++ {
+			var in [Players]input.Buttons
+			for p := range in {
+				in[p] = a.s.inputs[p][f]
+				if got := b.s.inputs[p][f]; got != in[p] {
+					t.Fatalf("%+v: tick %d: peers disagree on player %d's input: %v and %v", link, f, p, in[p], got)
+				}
+			}
+			ref.Step(in, false)
+			for i, pr := range g.peers {
+				if got, want := pr.sim.after[f+1], ref.after[f+1]; got != want {
+					t.Fatalf("%+v: peer %d diverged at tick %d", link, i, f+1)
+				}
+			}
+		}
+
+		for i, pr := range g.peers {
+			st := pr.s.Stats()
+			if link.Latency > 0 && st.Rollbacks == 0 {
+				t.Errorf("%+v: peer %d never rolled back", link, i)
+			}
+			if pr.sim.resims != st.Resimulated {
+				t.Errorf("%+v: peer %d: %d ticks stepped as resims, stats say %d", link, i, pr.sim.resims, st.Resimulated)
+			}
+		}
+	}
+}
+
+func TestSessionReportsDesync(t *testing.T) {
+	const frames = 600
+	g := newTestGame(t, Link{Latency: 30 * time.Millisecond, Loss: 0.1, Seed: 3}, frames)
+	g.peers[1].sim.skew = 1 // the same inputs, a different result
+	err := g.run(frames)
+	if !errors.Is(err, ErrDesync) {
+		t.Fatalf("got %v, want %v", err, ErrDesync)
+	}
+}
+
+func TestNewRejectsBadConfig(t *testing.T) {
+	a, _ := NewLoopback()
+	for _, cfg := range []Config{{Local: 2}, {Local: -1}, {Delay: -1}} {
+		if _, err := New(a, cfg); err == nil {
+			t.Errorf("%+v: no error", cfg)
+		}
+	}
+}
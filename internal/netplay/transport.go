@@ -0,0 +1,188 @@
+package netplay
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net"
+	"sort"
+	"time"
+)
+
+// Transport carries packets between the two peers. Packets may be lost, duplicated
+// or reordered; the session copes with all three.
+type Transport interface {
+	Send(p []byte) error
+	// Receive returns the next packet that has arrived, or ok false if there is none
+	// yet. It never blocks.
+	Receive() (p []byte, ok bool)
+	Close() error
+}
+
+// Loopback is an in-process Transport, one end of a pair made by NewLoopback.
+type Loopback struct {
+	in, out chan []byte
+}
+
+// loopbackQueue is how many packets a Loopback holds before dropping new ones,
+// like a full socket buffer.
+const loopbackQueue = 1024
+
+// NewLoopback returns the two connected ends of an in-process transport.
+func NewLoopback() (a, b *Loopback) {
+	ab, ba := make(chan []byte, loopbackQueue), make(chan []byte, loopbackQueue)
+	return &Loopback{in: ba, out: ab}, &Loopback{in: ab, out: ba}
+}
+
+// Send queues a copy of p for the other end.
+func (l *Loopback) Send(p []byte) error {
+	select {
+	case l.out <- append([]byte(nil), p...):
+	default:
+	}
+	return nil
+}
+
+// Receive returns the next packet from the other end.
+func (l *Loopback) Receive() ([]byte, bool) {
+	select {
+	case p := <-l.in:
+		return p, true
+	default:
+		return nil, false
+	}
+}
+
+// Close does nothing; the pair is garbage collected.
+func (l *Loopback) Close() error { return nil }
+
+// UDP is a Transport over a UDP socket, exchanging packets with one peer address.
+type UDP struct {
+	conn *net.UDPConn
+	peer *net.UDPAddr
+	in   chan []byte
+}
+
+// maxPacket is larger than any packet the session sends.
+const maxPacket = 1500
+
+// ListenUDP listens on local (e.g. ":7000") and exchanges packets with peer
+// (e.g. "192.168.1.20:7000"). Packets from other addresses are ignored.
+func ListenUDP(local, peer string) (*UDP, error) {
+	laddr, err := net.ResolveUDPAddr("udp", local)
+	if err != nil {
+		return nil, err
+	}
+	paddr, err := net.ResolveUDPAddr("udp", peer)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", laddr)
+	if err != nil {
+		return nil, err
+	}
+	u := &UDP{conn: conn, peer: paddr, in: make(chan []byte, loopbackQueue)}
+	go u.read()
+	return u, nil
+}
+
+// read moves packets from the socket to the in channel until the socket is closed.
+func (u *UDP) read() {
+	buf := make([]byte, maxPacket)
+	for {
+		n, from, err := u.conn.ReadFromUDP(buf)
+		if errors.Is(err, net.ErrClosed) {
+			close(u.in)
+			return
+		}
+		if err != nil || !from.IP.Equal(u.peer.IP) || from.Port != u.peer.Port {
+			continue // e.g. the peer isn't listening yet
+		}
+		select {
+		case u.in <- append([]byte(nil), buf[:n]...):
+		default:
+		}
+	}
+}
+
+// Send sends p to the peer.
+func (u *UDP) Send(p []byte) error {
+	_, err := u.conn.WriteToUDP(p, u.peer)
+	return err
+}
+
+// Receive returns the next packet from the peer.
+func (u *UDP) Receive() ([]byte, bool) {
+	select {
+	case p, ok := <-u.in:
+		return p, ok
+	default:
+		return nil, false
+	}
+}
+
+// Close closes the socket.
+func (u *UDP) Close() error { return u.conn.Close() }
+
+// Link describes a bad network to simulate.
+type Link struct {
+	Latency time.Duration    // one way
+	Jitter  time.Duration    // up to this much extra latency per packet
+	Loss    float64          // chance of dropping each packet, 0-1
+	Seed    uint64           // the same seed drops and delays the same packets
+	Now     func() time.Time // nil means time.Now; tests can run faster than real time
+}
+
+// Impaired wraps a Transport, delaying and dropping the packets it sends as Link says.
+type Impaired struct {
+	Transport
+	link    Link
+	rng     *rand.Rand
+	pending []delayed // sorted by due
+}
+
+type delayed struct {
+	due time.Time
+	p   []byte
+}
+
+// Impair returns t with outgoing packets delayed and dropped as link says.
+func Impair(t Transport, link Link) *Impaired {
+	if link.Now == nil {
+		link.Now = time.Now
+	}
+	return &Impaired{Transport: t, link: link, rng: rand.New(rand.NewPCG(link.Seed, link.Seed))}
+}
+
+// Send drops p or holds it until its latency has passed.
+func (im *Impaired) Send(p []byte) error {
+	if im.rng.Float64() < im.link.Loss {
+		return im.flush()
+	}
+	delay := im.link.Latency
+	if im.link.Jitter > 0 {
+		delay += time.Duration(im.rng.Int64N(int64(im.link.Jitter)))
+	}
+	im.pending = append(im.pending, delayed{due: im.link.Now().Add(delay), p: append([]byte(nil), p...)})
+	// jitter can reorder packets, as on a real network
+	sort.SliceStable(im.pending, func(i, j int) bool { return im.pending[i].due.Before(im.pending[j].due) })
+	return im.flush()
+}
+
+// Receive passes on packets that arrived, after sending any that are due.
+func (im *Impaired) Receive() ([]byte, bool) {
+	im.flush()
+	return im.Transport.Receive()
+}
+
+// flush sends the held packets whose latency has passed.
+func (im *Impaired) flush() error {
+	now := im.link.Now()
+	for len(im.pending) > 0 && !im.pending[0].due.After(now) {
+		p := im.pending[0].p
+		im.pending = im.pending[1:]
+		if err := im.Transport.Send(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
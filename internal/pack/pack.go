@@ -0,0 +1,224 @@
+// Package pack loads level packs: ordered campaigns described by a pack.json
+// manifest, built into the game or added in the user's packs directory.
+package pack
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/levelgen"
+	"platform-game-one/internal/player"
+)
+
+//go:embed packs
+var embedded embed.FS
+
+// Default is the pack played when none is chosen.
+const Default = "classic"
+
+// Manifest is a pack's pack.json.
+type Manifest struct {
+	Name       string  `json:"name"`
+	Author     string  `json:"author,omitempty"`
+	Difficulty string  `json:"difficulty,omitempty"` // free text, e.g. "easy"
+	Levels     []Entry `json:"levels"`
+}
+
+// Entry is one level of a pack. Exactly one of File, Builtin and Generate is set.
+type Entry struct {
+	Name     string           `json:"name,omitempty"`     // overrides the level's own name
+	File     string           `json:"file,omitempty"`     // a level file next to pack.json
+	Builtin  string           `json:"builtin,omitempty"`  // one of the levels built into the game
+	Generate *levelgen.Params `json:"generate,omitempty"` // a generated level
+}
+
+// builtins are the levels written in Go, which packs refer to by name.
+var builtins = map[string]func(screenW, screenH int) *level.Level{
+	"first":  level.FirstLevel,
+	"second": level.SecondLevel,
+	"third":  level.ThirdLevel,
+}
+
+// Pack is a loaded manifest and where its level files are.
+type Pack struct {
+	ID string // directory name, used in records and replays
+	Manifest
+	fsys fs.FS // the pack's directory
+	User bool  // from the user's packs directory rather than built in
+}
+
+// Len returns the number of levels.
+func (p *Pack) Len() int { return len(p.Levels) }
+
+// Level builds level num (1-based) for the given screen size.
+func (p *Pack) Level(num, screenW, screenH int) (*level.Level, error) {
+	if num < 1 || num > p.Len() {
+		return nil, fmt.Errorf("pack %s: level %d out of range 1-%d", p.ID, num, p.Len())
+	}
+	e := p.Levels[num-1]
+	var lv *level.Level
+	var err error
+	switch {
+	case e.Builtin != "":
+		lv = builtins[e.Builtin](screenW, screenH)
+	case e.Generate != nil:
+		lv, err = levelgen.Generate(*e.Generate, player.Envelope(1.0/60.0), screenW, screenH)
+	default:
+		var data []byte
+		if data, err = fs.ReadFile(p.fsys, e.File); err == nil {
+			lv, err = level.Parse(data)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pack %s level %d: %w", p.ID, num, err)
+	}
+	if e.Name != "" {
+		lv.Name = e.Name
+	}
+	if lv.Name == "" {
+		lv.Name = fmt.Sprintf("%s %d", p.Name, num)
+	}
+	return lv, nil
+}
+
+// Load reads the pack in directory id of fsys.
+func Load(fsys fs.FS, id string) (*Pack, error) {
+	data, err := fs.ReadFile(fsys, path.Join(id, "pack.json"))
+	if err != nil {
+		return nil, fmt.Errorf("pack %s: %w", id, err)
+	}
+	p := &Pack{ID: id}
+	if err := json.Unmarshal(data, &p.Manifest); err != nil {
+		return nil, fmt.Errorf("pack %s: %w", id, err)
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("pack %s: %w", id, err)
+	}
+	if p.fsys, err = fs.Sub(fsys, id); err != nil {
+		return nil, err
+	}
+	// parse the level files now, so a broken one is reported with the other
+	// problems instead of ending the game partway through the pack
+	for i, e := range p.Levels {
+		if e.File == "" {
+			continue
+		}
+		data, err := fs.ReadFile(p.fsys, e.File)
+		if err == nil {
+			_, err = level.Parse(data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pack %s level %d: %w", id, i+1, err)
+		}
+	}
+	return p, nil
+}
+
+func (p *Pack) validate() error {
+	if p.Name == "" {
+		return errors.New("no name")
+	}
+	if len(p.Levels) == 0 {
+		return errors.New("no levels")
+	}
+	for i, e := range p.Levels {
+		n := 0
+		for _, set := range []bool{e.File != "", e.Builtin != "", e.Generate != nil} {
+			if set {
+				n++
+			}
+		}
+		switch {
+		case n != 1:
+			return fmt.Errorf("level %d: set exactly one of file, builtin and generate", i+1)
+		case e.Builtin != "" && builtins[e.Builtin] == nil:
+			return fmt.Errorf("level %d: no built-in level %q", i+1, e.Builtin)
+		case e.Generate != nil:
+			if err := e.Generate.Validate(); err != nil {
+				return fmt.Errorf("level %d: %w", i+1, err)
+			}
+		case e.File != "" && !fs.ValidPath(e.File):
+			return fmt.Errorf("level %d: file %q must be a path inside the pack", i+1, e.File)
+		}
+	}
+	return nil
+}
+
+// Registry is every pack found.
+type Registry struct {
+	Packs []*Pack // built-in packs first, then by ID
+}
+
+// Discover loads the built-in packs and those in userDir, which may be empty or
+// missing. A user pack that fails to load, or reuses a built-in pack's ID, is left
+// out and reported in errs.
+func Discover(userDir string) (r *Registry, errs []error) {
+	r = &Registry{}
+	sub, err := fs.Sub(embedded, "packs")
+	if err != nil {
+		panic(err) // the directory is embedded, so this can't happen
+	}
+	r.Packs, errs = loadAll(sub, false, nil)
+	if userDir == "" {
+		return r, errs
+	}
+	user, userErrs := loadAll(os.DirFS(userDir), true, r)
+	r.Packs = append(r.Packs, user...)
+	return r, append(errs, userErrs...)
+}
+
+// loadAll loads every pack directory in fsys. Packs already in r are skipped.
+func loadAll(fsys fs.FS, user bool, r *Registry) ([]*Pack, []error) {
+	dirs, err := fs.ReadDir(fsys, ".")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []error{err}
+	}
+	var packs []*Pack
+	var errs []error
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		if r != nil {
+			if _, err := r.Find(d.Name()); err == nil {
+				errs = append(errs, fmt.Errorf("pack %s: a built-in pack has that name", d.Name()))
+				continue
+			}
+		}
+		p, err := Load(fsys, d.Name())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		p.User = user
+		packs = append(packs, p)
+	}
+	sort.Slice(packs, func(i, j int) bool {
+		// the default pack comes first
+		if (packs[i].ID == Default) != (packs[j].ID == Default) {
+			return packs[i].ID == Default
+		}
+		return packs[i].ID < packs[j].ID
+	})
+	return packs, errs
+}
+
+// Find returns the pack with the given ID.
+func (r *Registry) Find(id string) (*Pack, error) {
+	for _, p := range r.Packs {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("no level pack %q", id)
+}
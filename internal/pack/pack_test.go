@@ -0,0 +1,137 @@
+package pack
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// flat is a small level file that loads.
+const flat = `{
+  "name": "Flat",
+  "width": 1280,
+  "height": 720,
+  "start": [64, 600],
+  "goal": [1200, 560, 1260, 640],
+  "platforms": [[0, 640, 1280, 720]]
+}`
+
+func TestLoadRejectsBadManifests(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		files    map[string]string // more files in the pack
+		want     string            // in the error; empty means none
+	}{
+		{"a good pack", `{"name": "P", "levels": [{"file": "a.json"}, {"builtin": "first"}, {"generate": {"seed": 1, "difficulty": 0.5}}]}`, map[string]string{"a.json": flat}, ""},
+		{"no manifest", "", nil, "pack p: open"},
+		{"broken JSON", `{"name": "P",`, nil, "pack p: unexpected end of JSON input"},
+		{"no name", `{"levels": [{"builtin": "first"}]}`, nil, "pack p: no name"},
+		{"no levels", `{"name": "P", "levels": []}`, nil, "pack p: no levels"},
+		{"a level with nothing", `{"name": "P", "levels": [{"builtin": "first"}, {"name": "Empty"}]}`, nil, "level 2: set exactly one of file, builtin and generate"},
+		{"a level with two sources", `{"name": "P", "levels": [{"builtin": "first", "file": "a.json"}]}`, map[string]string{"a.json": flat}, "level 1: set exactly one"},
+		{"an unknown built-in level", `{"name": "P", "levels": [{"builtin": "fourth"}]}`, nil, `level 1: no built-in level "fourth"`},
+		{"a bad difficulty", `{"name": "P", "levels": [{"generate": {"seed": 1, "difficulty": 2}}]}`, nil, "level 1: difficulty 2 out of range 0-1"},
+		{"a file outside the pack", `{"name": "P", "levels": [{"file": "../other/a.json"}]}`, nil, `level 1: file "../other/a.json" must be a path inside the pack`},
+		{"a missing level file", `{"name": "P", "levels": [{"file": "gone.json"}]}`, nil, "pack p level 1: open gone.json"},
+		{"a level file that doesn't load", `{"name": "P", "levels": [{"builtin": "first"}, {"file": "a.json"}]}`, map[string]string{"a.json": `{"width": 100, "height": 100}`}, "pack p level 2: no platforms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			if tt.manifest != "" {
+				fsys["p/pack.json"] = &fstest.MapFile{Data: []byte(tt.manifest)}
+			}
+			for name, data := range tt.files {
+				fsys["p/"+name] = &fstest.MapFile{Data: []byte(data)}
+			}
+			_, err := Load(fsys, "p")
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.want != "" && err == nil:
+				t.Errorf("no error, want %q", tt.want)
+			case tt.want != "" && !strings.Contains(err.Error(), tt.want):
+				t.Errorf("error %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAllSkipsClashesAndSorts(t *testing.T) {
+	builtin, errs := Discover("")
+	if len(errs) > 0 {
+		t.Fatalf("built-in packs: %v", errs)
+	}
+	manifest := []byte(`{"name": "Mine", "levels": [{"builtin": "first"}]}`)
+	fsys := fstest.MapFS{
+		"zeta/pack.json":       {Data: manifest},
+		"alpha/pack.json":      {Data: manifest},
+		Default + "/pack.json": {Data: manifest},     // shadows a built-in pack
+		"broken/pack.json":     {Data: []byte(`{}`)}, // no name
+		"notes.txt":            {Data: []byte("not a pack")},
+	}
+	packs, errs := loadAll(fsys, true, builtin)
+	var ids []string
+	for _, p := range packs {
+		ids = append(ids, p.ID)
+		if !p.User {
+			t.Errorf("pack %s isn't marked as the user's", p.ID)
+		}
+	}
+	if got := strings.Join(ids, " "); got != "alpha zeta" {
+		t.Errorf("loaded %s, want alpha zeta", got)
+	}
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	want := []string{"pack broken: no name", "pack classic: a built-in pack has that name"}
+	if strings.Join(msgs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("errors %q, want %q", msgs, want)
+	}
+}
+
+func TestDiscoverBuiltins(t *testing.T) {
+	r, errs := Discover(t.TempDir() + "/missing")
+	if len(errs) > 0 {
+		t.Fatalf("errors %v, want none for a missing user directory", errs)
+	}
+	if len(r.Packs) == 0 || r.Packs[0].ID != Default {
+		t.Fatalf("packs %+v, want %s first", r.Packs, Default)
+	}
+	for _, p := range r.Packs {
+		if p.User {
+			t.Errorf("built-in pack %s is marked as the user's", p.ID)
+		}
+	}
+	if _, err := r.Find("nope"); err == nil || err.Error() != `no level pack "nope"` {
+		t.Errorf("Find(nope) = %v", err)
+	}
+}
+
+func TestLevel(t *testing.T) {
+	fsys := fstest.MapFS{
+		"p/pack.json": {Data: []byte(`{"name": "P", "levels": [{"file": "a.json"}, {"builtin": "first", "name": "Renamed"}, {"file": "b.json"}]}`)},
+		"p/a.json":    {Data: []byte(flat)},
+		"p/b.json":    {Data: []byte(strings.Replace(flat, `"name": "Flat",`, "", 1))},
+	}
+	p, err := Load(fsys, "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for num, want := range map[int]string{1: "Flat", 2: "Renamed", 3: "P 3"} {
+		lv, err := p.Level(num, 1280, 720)
+		if err != nil {
+			t.Fatalf("level %d: %v", num, err)
+		}
+		if lv.Name != want {
+			t.Errorf("level %d is named %q, want %q", num, lv.Name, want)
+		}
+	}
+	for _, num := range []int{0, -1, 4} {
+		if _, err := p.Level(num, 1280, 720); err == nil || !strings.Contains(err.Error(), "out of range 1-3") {
+			t.Errorf("level %d: error %v, want out of range 1-3", num, err)
+		}
+	}
+}
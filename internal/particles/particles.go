@@ -0,0 +1,170 @@
+package particles
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// MaxParticles caps how many particles can be alive at once; emits past the cap are dropped.
+const MaxParticles = 1024
+
+// Shape is how a particle is drawn.
+type Shape int
+
+const (
+	Square Shape = iota
+	Circle
+)
+
+// Ramp is a list of colors a particle fades through over its life.
+type Ramp []color.RGBA
+
+func (r Ramp) at(t float64) color.RGBA {
+	if len(r) == 1 || t <= 0 {
+		return r[0]
+	}
+	if t >= 1 {
+		return r[len(r)-1]
+	}
+	f := t * float64(len(r)-1)
+	i := int(f)
+	k := f - float64(i)
+	a, b := r[i], r[i+1]
+	lerp := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*k) }
+	return color.RGBA{R: lerp(a.R, b.R), G: lerp(a.G, b.G), B: lerp(a.B, b.B), A: lerp(a.A, b.A)}
+}
+
+// Emitter describes a burst of particles. Angles are in radians, 0 pointing right, Y down.
+type Emitter struct {
+	Count   int
+	Life    float64 // seconds
+	Speed   float64 // pixels/second, randomized down to half
+	Angle   float64 // center direction
+	Spread  float64 // total spread around Angle
+	Gravity float64
+	Drag    float64 // fraction of velocity lost per second
+	Size    float64 // side or diameter in pixels, shrinking to zero over the life
+	Ramp    Ramp
+	Shape   Shape
+}
+
+type particle struct {
+	x, y, vx, vy float64
+	age, life    float64
+	gravity      float64
+	drag         float64
+	size         float64
+	ramp         Ramp
+	shape        Shape
+}
+
+// System owns a fixed pool of particles. It allocates only in New.
+type System struct {
+	pool  [MaxParticles]particle
+	alive int
+	rng   uint32
+
+	atlas    *ebiten.Image
+	vertices []ebiten.Vertex
+	indices  []uint16
+	op       ebiten.DrawTrianglesOptions
+}
+
+const cell = 16 // atlas cell size: a white square, then a white circle
+
+// New creates an empty particle system.
+func New() *System {
+	s := &System{
+		rng:      1,
+		vertices: make([]ebiten.Vertex, 0, MaxParticles*4),
+		indices:  make([]uint16, 0, MaxParticles*6),
+	}
+	s.atlas = ebiten.NewImage(cell*2, cell)
+	vector.FillRect(s.atlas, 0, 0, cell, cell, color.White, false)
+	vector.FillCircle(s.atlas, cell*1.5, cell/2, cell/2, color.White, true)
+	return s
+}
+
+// random returns a deterministic value in [0, 1).
+func (s *System) random() float64 {
+	s.rng = s.rng*1664525 + 1013904223
+	return float64(s.rng>>8) / float64(1<<24)
+}
+
+// Emit spawns e's particles at (x, y) in world coordinates.
+func (s *System) Emit(e *Emitter, x, y float64) {
+	for i := 0; i < e.Count && s.alive < MaxParticles; i++ {
+		angle := e.Angle + (s.random()-0.5)*e.Spread
+		speed := e.Speed * (0.5 + 0.5*s.random())
+		s.pool[s.alive] = particle{
+			x: x, y: y,
+			vx:      math.Cos(angle) * speed,
+			vy:      math.Sin(angle) * speed,
+			life:    e.Life * (0.75 + 0.25*s.random()),
+			gravity: e.Gravity,
+			drag:    e.Drag,
+			size:    e.Size,
+			ramp:    e.Ramp,
+			shape:   e.Shape,
+		}
+		s.alive++
+	}
+}
+
+// Clear removes all particles.
+func (s *System) Clear() { s.alive = 0 }
+
+// Len returns the number of live particles.
+func (s *System) Len() int { return s.alive }
+
+// Update ages and moves particles, removing dead ones.
+func (s *System) Update(dt float64) {
+	for i := 0; i < s.alive; {
+		p := &s.pool[i]
+		p.age += dt
+		if p.age >= p.life {
+			s.alive--
+			s.pool[i] = s.pool[s.alive]
+			continue
+		}
+		p.vy += p.gravity * dt
+		damp := 1 - p.drag*dt
+		p.vx *= damp
+		p.vy *= damp
+		p.x += p.vx * dt
+		p.y += p.vy * dt
+		i++
+	}
+}
+
+// Draw renders all particles in one batch, offset by the camera position.
+func (s *System) Draw(screen *ebiten.Image, camX, camY float64) {
+	if s.alive == 0 {
+		return
+	}
+	s.vertices = s.vertices[:0]
+	s.indices = s.indices[:0]
+	for i := 0; i < s.alive; i++ {
+		p := &s.pool[i]
+		t := p.age / p.life
+		c := p.ramp.at(t)
+		half := float32(p.size * (1 - t) / 2)
+		x := float32(p.x - camX)
+		y := float32(p.y - camY)
+		sx := float32(int(p.shape) * cell)
+		r, g, b, a := float32(c.R)/0xff, float32(c.G)/0xff, float32(c.B)/0xff, float32(c.A)/0xff
+		base := uint16(len(s.vertices))
+		for _, corner := range [4][2]float32{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+			s.vertices = append(s.vertices, ebiten.Vertex{
+				DstX: x - half + corner[0]*2*half, DstY: y - half + corner[1]*2*half,
+				SrcX: sx + corner[0]*cell, SrcY: corner[1] * cell,
+				ColorR: r, ColorG: g, ColorB: b, ColorA: a,
+			})
+		}
+		s.indices = append(s.indices, base, base+1, base+2, base+1, base+3, base+2)
+	}
+	screen.DrawTriangles(s.vertices, s.indices, s.atlas, &s.op)
+}
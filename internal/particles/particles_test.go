@@ -0,0 +1,64 @@
+package particles
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var spark = Emitter{
+	Count: 64, Life: 1, Speed: 200, Spread: 6.28, Gravity: 300, Drag: 1, Size: 4,
+	Ramp: Ramp{{R: 0xff, A: 0xff}, {B: 0xff}},
+}
+
+func TestFullFrameDoesNotAllocate(t *testing.T) {
+	s := New()
+	screen := ebiten.NewImage(320, 240)
+	for range MaxParticles / spark.Count {
+		s.Emit(&spark, 160, 120)
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		s.Emit(&spark, 160, 120)
+		s.Update(1.0 / 60)
+		s.Draw(screen, 0, 0)
+	})
+	// Ebiten may allocate to queue the draw itself; count only what the system adds
+	var op ebiten.DrawTrianglesOptions
+	ebitenAllocs := testing.AllocsPerRun(100, func() {
+		screen.DrawTriangles(s.vertices, s.indices, s.atlas, &op)
+	})
+	if allocs > ebitenAllocs {
+		t.Errorf("%g allocations a frame at %d particles, want only Ebiten's %g", allocs, s.Len(), ebitenAllocs)
+	}
+}
+
+func TestEmitPastTheCapReusesThePool(t *testing.T) {
+	s := New()
+	vertices, indices := cap(s.vertices), cap(s.indices)
+	for range 2 * MaxParticles / spark.Count {
+		s.Emit(&spark, 0, 0)
+	}
+	if s.Len() != MaxParticles {
+		t.Fatalf("%d particles alive, want the cap of %d", s.Len(), MaxParticles)
+	}
+	// the emits past the cap were dropped, so nothing is left of a marked particle
+	s.pool[0].ramp = Ramp{{G: 0xff}}
+	s.Emit(&spark, 0, 0)
+	if s.Len() != MaxParticles || len(s.pool[0].ramp) != 1 {
+		t.Errorf("emitting at the cap changed the pool: %d alive", s.Len())
+	}
+	s.Draw(ebiten.NewImage(16, 16), 0, 0)
+	if cap(s.vertices) != vertices || cap(s.indices) != indices {
+		t.Errorf("drawing at the cap grew the vertex buffers from %d, %d to %d, %d", vertices, indices, cap(s.vertices), cap(s.indices))
+	}
+	// once some die, their slots are used again
+	s.Update(2)
+	if s.Len() != 0 {
+		t.Fatalf("%d particles outlived their life", s.Len())
+	}
+	s.Emit(&spark, 0, 0)
+	if s.Len() != spark.Count || s.pool[0].ramp[0] != (color.RGBA{R: 0xff, A: 0xff}) {
+		t.Errorf("%d alive after emitting into an empty pool, want %d in the first slots", s.Len(), spark.Count)
+	}
+}
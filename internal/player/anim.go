@@ -0,0 +1,175 @@
+package player
+
+import (
+	"image/color"
+	"math"
+
+	"platform-game-one/internal/skin"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	maxSquash     = 0.35 // squash on the hardest landing; stretch is negative squash
+	maxStretch    = 0.2
+	squashPerFall = 1.0 / 1600 // squash per pixel/second of landing speed
+	squashSpring  = 12         // how fast squash returns to its target, per second
+	blinkEvery    = 3.2        // seconds between blinks
+	blinkLength   = 0.12
+	fearDistance  = 320 // start widening the eyes this far above DeathY
+	lookSpeed     = 10  // how fast the eyes turn toward the movement, per second
+)
+
+// Eye layout around the body center, matching the pre-rendered shapes.
+const (
+	eyeR      = 4
+	eyeOffX   = 4.5
+	eyeOffY   = -3.5
+	pupilR    = 2
+	pupilOffX = 5.0
+	lookReach = 1.5 // how far pupils move toward the look direction
+)
+
+// eyeOffsetY moves the eyes per shape; the triangle's sit slightly above center.
+var eyeOffsetY = [shapeCount]float64{0, -Radius * 0.1, 0}
+
+// anim is the procedural animation layer. It never affects physics.
+type anim struct {
+	squash       float64 // >0 wider and shorter, <0 taller and thinner
+	lookX, lookY float64 // -1..1, where the eyes point
+	blinkClock   float64
+	fear         float64 // 0..1, widens the eyes when falling toward DeathY
+	blinks       int
+
+	// Skin animation
+	skinAnim  skin.Anim
+	skinClock float64 // seconds into skinAnim
+	facing    float64 // 1 right, -1 left
+}
+
+// stateSkins is the skin animation shown in each player state.
+var stateSkins = [stateCount]skin.Anim{
+	StateRunning:  skin.Run,
+	StateJumping:  skin.Jump,
+	StateFalling:  skin.Fall,
+	StateLanding:  skin.Land,
+	StatePounding: skin.Fall,
+	StateSwimming: skin.Fall,
+}
+
+// land kicks the squash for a landing at speed, entering StateLanding.
+func (a *anim) land(speed float64) {
+	a.squash = math.Min(speed*squashPerFall, maxSquash)
+}
+
+// appear pops the player in tall and thin, entering StateRespawning.
+func (a *anim) appear() {
+	a.squash = -maxStretch
+}
+
+func (a *anim) update(p *Player, dt, deathY float64) {
+	// Squash: kicked by the state hooks, stretch with upward speed, spring back otherwise
+	target := 0.0
+	if !p.Grounded && p.VY < 0 {
+		target = -maxStretch * math.Min(-p.VY/-JumpVelocity, 1)
+	}
+	a.squash += (target - a.squash) * math.Min(squashSpring*dt, 1)
+
+	// Look toward the movement
+	lx := p.VX / MoveSpeed
+	ly := math.Max(-1, math.Min(1, p.VY/600))
+	if p.Grounded {
+		ly = 0
+	}
+	k := math.Min(lookSpeed*dt, 1)
+	a.lookX += (lx - a.lookX) * k
+	a.lookY += (ly - a.lookY) * k
+
+	a.blinkClock += dt
+	// vary the gap a little so blinking doesn't look mechanical
+	if a.blinkClock > blinkEvery+float64(a.blinks%3)*0.7+blinkLength {
+		a.blinkClock = 0
+		a.blinks++
+	}
+
+	a.fear = 0
+	if !p.Grounded && p.VY > 0 {
+		a.fear = math.Max(0, math.Min(1, 1-(deathY-p.Y)/fearDistance))
+	}
+
+	if p.VX > 0 {
+		a.facing = 1
+	} else if p.VX < 0 {
+		a.facing = -1
+	}
+	next := stateSkins[p.State()]
+	if next != a.skinAnim {
+		a.skinAnim, a.skinClock = next, 0
+	}
+	a.skinClock += dt
+}
+
+func (a *anim) blinking() bool {
+	return a.blinkClock > blinkEvery+float64(a.blinks%3)*0.7
+}
+
+var (
+	eyeImg   *ebiten.Image
+	pupilImg *ebiten.Image
+)
+
+func init() {
+	eyeImg = ebiten.NewImage(eyeR*2+2, eyeR*2+2)
+	vector.FillCircle(eyeImg, eyeR+1, eyeR+1, eyeR, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, true)
+	pupilImg = ebiten.NewImage(pupilR*2+2, pupilR*2+2)
+	vector.FillCircle(pupilImg, pupilR+1, pupilR+1, pupilR, color.RGBA{R: 0x10, G: 0x10, B: 0x20, A: 0xff}, true)
+}
+
+// drawEyes draws both eyes upright around the body center (cx, cy) in screen coordinates.
+func (a *anim) drawEyes(screen *ebiten.Image, cx, cy float64) {
+	eyeScale := 1 + 0.4*a.fear
+	pupilScale := 1 - 0.3*a.fear
+	lid := 1.0
+	if a.blinking() {
+		lid = 0.15
+	}
+	for _, side := range [2]float64{-1, 1} {
+		ex := cx + side*eyeOffX
+		ey := cy + eyeOffY
+		drawCentered(screen, eyeImg, ex, ey, eyeScale, eyeScale*lid)
+		if lid < 1 {
+			continue
+		}
+		px := cx + side*pupilOffX + a.lookX*lookReach
+		py := cy + eyeOffY + a.lookY*lookReach
+		drawCentered(screen, pupilImg, px, py, pupilScale, pupilScale)
+	}
+}
+
+func drawCentered(dst, img *ebiten.Image, x, y, sx, sy float64) {
+	half := float64(img.Bounds().Dx()) / 2
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-half, -half)
+	op.GeoM.Scale(sx, sy)
+	op.GeoM.Translate(x, y)
+	op.Filter = ebiten.FilterLinear
+	dst.DrawImage(img, op)
+}
+
+// drawSkin draws the current skin frame scaled to the collider width, standing on
+// its bottom edge and facing the movement direction. sx, sy is the collider's top-left.
+func (a *anim) drawSkin(screen *ebiten.Image, sk *skin.Skin, sx, sy int) {
+	frame := sk.Frame(a.skinAnim, a.skinClock)
+	scale := float64(Width) / float64(sk.FrameW)
+	facing := a.facing
+	if facing == 0 {
+		facing = 1
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-float64(sk.FrameW)/2, -float64(sk.FrameH))
+	op.GeoM.Scale(scale*facing*(1+a.squash), scale*(1-a.squash))
+	op.GeoM.Translate(float64(sx)+Width/2, float64(sy)+Height)
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(frame, op)
+}
@@ -1,12 +1,16 @@
 package player
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"math"
 
+	"platform-game-one/internal/input"
+	"platform-game-one/internal/level"
+	"platform-game-one/internal/skin"
+
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
@@ -19,6 +23,36 @@ const (
 	Gravity       = 980
 	CoyoteTimeMax = 0.12
 	JumpBufferMax = 0.1
+
+	// On slopes: walking speed is divided by 1 + UphillDrag*grade going up and
+	// multiplied by 1 + DownhillBoost*grade going down, or RollBoost for the circle.
+	UphillDrag    = 0.5
+	DownhillBoost = 0.2
+	RollBoost     = 0.6
+	MaxSnapGrade  = 2 // steepest slope, rise over run, the player stays on walking down
+
+	MinBounceSpeed = 150  // slower landings on a bounce pad just land
+	PadCooldown    = 0.25 // seconds before a spring or cannon fires the player again
+	PoundSpeed     = 900  // how fast a ground-pound falls, pixels/second
+
+	// In water, by the share of the player underwater: gravity is cut by Buoyancy,
+	// vertical speed drops by WaterDrag each second, and walking speed falls to
+	// SwimSpeed of normal. Jump swims a stroke up at SwimVelocity when at least
+	// SwimDepth is under.
+	Buoyancy     = 0.75
+	WaterDrag    = 3
+	SwimSpeed    = 0.55
+	SwimVelocity = -300
+	SwimDepth    = 0.5
+	MinSplash    = 100 // slower entries into water make no splash, pixels/second
+
+	WindDrag = 2 // how quickly drift settles in wind, per second; steady drift is wind/WindDrag
+
+	ClimbSpeed    = 200 // on a ladder or vine, pixels/second
+	ClimbCooldown = 0.5 // seconds after letting go before grabbing on again
+
+	IceGrip    = 500 // how quickly the player speeds up or slows down on ice, pixels/second²
+	StickyJump = 0.7 // share of JumpVelocity when jumping off sticky ground
 )
 
 // Shape selects the player's visual appearance.
@@ -31,20 +65,92 @@ const (
 	shapeCount // keep last for cycling
 )
 
+var shapeNames = [shapeCount]string{"circle", "triangle", "hexagon"}
+
+var shapeColors = [shapeCount]color.RGBA{
+	{R: 0x8a, G: 0x2b, B: 0xe2, A: 0xff}, // purple
+	{R: 0xd8, G: 0x2a, B: 0x2a, A: 0xff}, // red
+	{R: 0xff, G: 0x8c, B: 0x00, A: 0xff}, // orange
+}
+
+// ShapeColor returns the body color of shape s.
+func ShapeColor(s Shape) color.RGBA {
+	if !s.Valid() {
+		return shapeColors[ShapeCircle]
+	}
+	return shapeColors[s]
+}
+
+func (s Shape) String() string {
+	if s < 0 || s >= shapeCount {
+		return fmt.Sprintf("Shape(%d)", int(s))
+	}
+	return shapeNames[s]
+}
+
+// Valid reports whether s is one of the defined shapes.
+func (s Shape) Valid() bool { return s >= 0 && s < shapeCount }
+
+// ParseShape returns the shape with the given name.
+func ParseShape(name string) (Shape, error) {
+	for i, n := range shapeNames {
+		if n == name {
+			return Shape(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown shape %q (want circle, triangle or hexagon)", name)
+}
+
 // Player represents the controllable character.
 type Player struct {
 	X, Y       float64
 	VX, VY     float64
 	Grounded   bool
+	Climbing   bool           // holding on to a ladder or vine, with gravity off
+	GrabTime   float64        // seconds until the player can grab a ladder or vine again
+	Slope      float64        // grade of the slope stood on, rise over run; 0 on flat ground
+	Ground     level.Material // what the ground stood on is made of, kept through coyote time; Kind is empty for plain ground
+	Run        float64        // sideways velocity from walking, which only changes gradually on ice
+	Carry      float64        // sideways velocity from a cannon or off a conveyor, kept until landing or a wall
+	PadTime    float64        // seconds until a spring or cannon can fire again
+	Pounding   bool           // falling straight down in a ground-pound
+	Drift      float64        // sideways velocity from wind, which dies away out of it
+	Physics    level.Physics  // the zones the player is in, as of the start of the last Step
 	Rotation   float64
 	Shape      Shape
 	CoyoteTime float64
 	JumpBuffer float64
+
+	InfiniteJumps bool // assist: jumping is allowed in mid-air
+
+	// What happened during the last Step, for effects and sound.
+	Jumped       bool
+	Launched     bool            // by a spring, cannon or bounce pad
+	Splashed     bool            // fell into water
+	Broke        image.Rectangle // a block broken by a head-bump or ground-pound; empty if none
+	Pulled       image.Rectangle // a lever flipped by pressing Down; empty if none
+	Landed       bool
+	LandSpeed    float64 // downward speed just before landing
+	ShapeChanged bool
+
+	ladder  level.Climbable // the ladder or vine the player is at, set by Step; Kind is empty if none
+	machine machine
+	anim    anim
+	skins   [shapeCount]*skin.Skin // nil draws the vector shape
+}
+
+// SetSkin draws shape s with sk instead of its vector shape. A nil sk restores the vector shape.
+func (p *Player) SetSkin(s Shape, sk *skin.Skin) {
+	if s.Valid() {
+		p.skins[s] = sk
+	}
 }
 
 // New creates a player at the given position.
 func New(x, y float64) *Player {
-	return &Player{X: x, Y: y, Shape: ShapeCircle}
+	p := &Player{X: x, Y: y, Shape: ShapeCircle, Physics: level.Normal}
+	p.animate()
+	return p
 }
 
 // Rect returns the axis-aligned bounding box in world coordinates.
@@ -55,38 +161,74 @@ func (p *Player) Rect() image.Rectangle {
 	)
 }
 
-// Respawn moves the player to the start position and zeroes velocity.
+// Respawn moves the player to the start position, zeroes velocity and enters StateRespawning.
 func (p *Player) Respawn(x, y float64) {
 	p.X, p.Y = x, y
 	p.VX, p.VY = 0, 0
 	p.Grounded = false
+	p.Climbing, p.GrabTime = false, 0
+	p.Slope = 0
+	p.Ground, p.Run = level.Material{}, 0
+	p.Carry, p.PadTime = 0, 0
+	p.Pounding = false
+	p.Drift, p.Physics = 0, level.Normal
 	p.CoyoteTime = 0
 	p.JumpBuffer = 0
+	p.Jumped, p.Launched, p.Splashed, p.Landed, p.ShapeChanged = false, false, false, false, false
+	p.Broke, p.Pulled = image.Rectangle{}, image.Rectangle{}
+	p.anim = anim{}
+	p.setState(StateRespawning)
 }
 
-// Update applies input, gravity, and integrates position.
-func (p *Player) Update(dt float64) {
-	// Toggle shape with Tab (cycle through all shapes)
-	if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+// Update applies input, gravity and the zones in p.Physics, and integrates position.
+func (p *Player) Update(dt float64, in input.State) {
+	ph := p.Physics
+	// Toggle shape (cycle through all shapes)
+	p.ShapeChanged = in.JustPressed(input.Shape)
+	if p.ShapeChanged {
 		p.Shape = (p.Shape + 1) % shapeCount
 	}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyW) {
+	if in.JustPressed(input.Jump) {
 		p.JumpBuffer = JumpBufferMax
 	}
 	if p.JumpBuffer > 0 {
 		p.JumpBuffer -= dt
 	}
+	if p.climb(dt, in) {
+		return
+	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyA) {
-		p.VX = -MoveSpeed
-	} else if ebiten.IsKeyPressed(ebiten.KeyD) {
-		p.VX = MoveSpeed
+	speed := MoveSpeed * (1 - (1-SwimSpeed)*ph.Water)
+	run := 0.0
+	if in.Down(input.Left) {
+		run = -speed
+	} else if in.Down(input.Right) {
+		run = speed
+	}
+	if p.Ground.Kind == level.MaterialIce {
+		p.Run += math.Max(-IceGrip*dt, math.Min(run-p.Run, IceGrip*dt))
 	} else {
-		p.VX = 0
+		p.Run = run
+	}
+	p.VX = p.Run + p.Carry
+	if p.Grounded && p.Slope != 0 {
+		p.VX *= p.slopeSpeed()
+	}
+	if p.Ground.Kind == level.MaterialConveyor {
+		p.VX += p.Ground.Speed
+	}
+	p.Drift += (ph.WindX - WindDrag*p.Drift) * dt
+	p.VX += p.Drift
+	if in.JustPressed(input.Down) && !p.Grounded && p.CoyoteTime == 0 && ph.Water == 0 {
+		p.Pounding = true // only once properly in the air, not off an edge
 	}
 
-	p.VY += Gravity * dt
+	p.VY += (Gravity*ph.Gravity*(1-Buoyancy*ph.Water) + ph.WindY) * dt
+	p.VY -= p.VY * math.Min(1, WaterDrag*ph.Water*dt)
+	if p.Pounding {
+		p.VX, p.VY, p.Carry = 0, PoundSpeed, 0
+	}
 	p.X += p.VX * dt
 	p.Y += p.VY * dt
 
@@ -102,14 +244,191 @@ func (p *Player) Update(dt float64) {
 	}
 }
 
-// TryJump applies jump velocity if W was pressed and the player can jump.
+// climb grabs the ladder or vine the player is at when Up or Down leads along it,
+// unless they're still on the way up from a jump, and moves the player on it. It
+// reports whether the player is climbing. Jump with Left or Right jumps off, and on
+// a ladder Left or Right alone steps off.
+func (p *Player) climb(dt float64, in input.State) bool {
+	c := p.ladder
+	feet := p.Y + Height
+	up, down := in.Down(input.Up), in.Down(input.Down)
+	p.GrabTime = math.Max(0, p.GrabTime-dt)
+	switch {
+	case c.Kind == "":
+		p.Climbing = false
+	case !p.Climbing && p.GrabTime == 0 && p.VY >= 0 && (up && float64(c.Rect.Min.Y) < feet || down && float64(c.Rect.Max.Y) > feet):
+		p.Climbing = true
+		p.Pounding, p.Carry, p.Drift, p.Run = false, 0, 0, 0
+	}
+	if !p.Climbing {
+		return false
+	}
+	dir := 0.0
+	if in.Down(input.Left) {
+		dir--
+	}
+	if in.Down(input.Right) {
+		dir++
+	}
+	if dir != 0 && (in.JustPressed(input.Jump) || c.Kind == level.ClimbLadder) {
+		// climbing counts as coyote time, so TryJump jumps off
+		p.Climbing = false
+		p.GrabTime = ClimbCooldown
+		return false
+	}
+	p.JumpBuffer = 0 // Up shares Jump's key, and climbs rather than jumps
+	p.VX, p.VY = 0, 0
+	if up {
+		p.VY -= ClimbSpeed
+	}
+	if down {
+		p.VY += ClimbSpeed
+	}
+	if c.Kind == level.ClimbVine {
+		p.VX = dir * ClimbSpeed
+	}
+	p.X += p.VX * dt
+	p.Y += p.VY * dt
+	if top := float64(c.Rect.Min.Y - Height); p.Y < top {
+		p.Y, p.VY = top, 0 // as high as it goes
+	}
+	p.CoyoteTime = CoyoteTimeMax
+	return true
+}
+
+// Step advances the player one tick: input and gravity, collision against lv, then jumping.
+func (p *Player) Step(dt float64, in input.State, lv *level.Level) {
+	wasGrounded := p.Grounded
+	wasWet := p.Physics.Water > 0
+	p.Physics = lv.PhysicsAt(p.Rect())
+	p.Splashed = !wasWet && p.Physics.Water > 0 && p.VY >= MinSplash
+	p.ladder, _ = lv.ClimbAt(p.Rect())
+	p.Update(dt, in)
+	fallSpeed := p.VY
+	nx, ny, nvx, nvy, grounded, ground := lv.ResolveCollision(p.Rect(), p.VX, p.VY)
+	if nvx == 0 && p.VX != 0 {
+		p.Carry, p.Drift, p.Run = 0, 0, 0 // hit a wall
+	}
+	p.X, p.Y = nx, ny
+	p.VX, p.VY = nvx, nvy
+	p.Broke = image.Rectangle{}
+	if fallSpeed < 0 && p.VY == 0 && !grounded {
+		p.Broke, _ = lv.Bump(p.Rect()) // hit a ceiling
+	}
+	if !grounded && p.CoyoteTime > 0 && p.VY >= 0 {
+		// stay on a slope going down, and on the ground at its foot, rather than
+		// falling off a little each tick
+		if dy, ok := lv.SnapDown(p.Rect(), math.Abs(p.VX*dt)*MaxSnapGrade+1, p.Slope != 0); ok {
+			p.Y += dy
+			p.VY = 0
+			grounded = true
+			ground = lv.MaterialAt(p.Rect())
+		}
+	}
+	p.Launched = false
+	if grounded {
+		if broke, ok := lv.Stand(p.Rect(), p.Pounding); ok {
+			p.Broke = broke
+			grounded = false // and keep pounding through whatever is below
+		} else {
+			p.Pounding = false
+		}
+	}
+	if grounded {
+		p.Carry = 0
+		if r := lv.BounceUnder(p.Rect()); r > 0 && fallSpeed >= MinBounceSpeed {
+			p.VY = -fallSpeed * r
+			p.Launched = true
+			grounded = false
+		}
+	}
+	p.Grounded = grounded
+	if grounded {
+		p.Climbing = false // climbed down to the ground
+		p.Ground = ground
+	} else if p.CoyoteTime == 0 {
+		// properly in the air, not just between ticks on the ground
+		if p.Ground.Kind == level.MaterialConveyor {
+			p.Carry += p.Ground.Speed // fly on the way the belt was going
+		}
+		p.Ground = level.Material{}
+	}
+	p.Slope = 0
+	if grounded {
+		p.Slope = lv.SlopeUnder(p.Rect())
+	}
+	p.PadTime = math.Max(0, p.PadTime-dt)
+	if pad := lv.Touching(p.Rect()); pad != nil && p.PadTime == 0 && (pad.Kind == level.PadCannon || p.VY >= 0) {
+		p.launch(pad)
+	}
+	p.Pulled = image.Rectangle{}
+	if in.JustPressed(input.Down) && !p.Pounding {
+		p.Pulled, _ = lv.Pull(p.Rect()) // Down on the ground works a lever
+	}
+	p.Landed = grounded && !wasGrounded
+	p.LandSpeed = 0
+	if p.Landed {
+		p.LandSpeed = fallSpeed
+	}
+	p.Jumped = false
+	p.TryJump()
+	p.updateState(dt, lv.DeathY)
+	p.anim.update(p, dt, lv.DeathY)
+}
+
+// launch fires the player off a spring or cannon. Springs only push a player coming
+// down onto them or walking into them.
+func (p *Player) launch(pad *level.Pad) {
+	p.VY = pad.VY
+	p.Carry = pad.VX
+	p.Grounded = false
+	p.Slope = 0
+	p.CoyoteTime = 0
+	p.PadTime = PadCooldown
+	p.Pounding = false
+	p.Climbing = false
+	p.Launched = true
+}
+
+// slopeSpeed returns how much the slope underfoot scales walking speed.
+func (p *Player) slopeSpeed() float64 {
+	climb := math.Max(-MaxSnapGrade, math.Min(p.Slope, MaxSnapGrade))
+	if p.VX < 0 {
+		climb = -climb
+	}
+	if climb > 0 {
+		return 1 / (1 + UphillDrag*climb)
+	}
+	boost := DownhillBoost
+	if p.Shape == ShapeCircle {
+		boost = RollBoost // it rolls
+	}
+	return 1 - boost*climb
+}
+
+// TryJump applies jump velocity if W was pressed and the player can jump, lower off
+// sticky ground, or swims a stroke up if deep enough in water.
 func (p *Player) TryJump() {
-	if p.JumpBuffer > 0 && (p.Grounded || p.CoyoteTime > 0) {
+	if p.JumpBuffer <= 0 {
+		return
+	}
+	switch {
+	case p.Grounded || p.CoyoteTime > 0 || p.InfiniteJumps:
 		p.VY = JumpVelocity
-		p.Grounded = false
-		p.CoyoteTime = 0
-		p.JumpBuffer = 0
+		if p.Ground.Kind == level.MaterialSticky {
+			p.VY *= StickyJump
+		}
+	case p.Physics.Water >= SwimDepth:
+		p.VY = SwimVelocity
+	default:
+		return
 	}
+	p.Pounding = false
+	p.Climbing = false
+	p.Jumped = true
+	p.Grounded = false
+	p.CoyoteTime = 0
+	p.JumpBuffer = 0
 }
 
 // Pre-rendered images for each shape.
@@ -137,9 +456,7 @@ func buildCircleImage(size int) {
 	r := float32(Radius)
 
 	// Purple body
-	vector.DrawFilledCircle(circleImg, cx, cy, r, color.RGBA{R: 0x8a, G: 0x2b, B: 0xe2, A: 0xff}, true)
-
-	drawEyes(circleImg, cx, cy)
+	vector.DrawFilledCircle(circleImg, cx, cy, r, shapeColors[ShapeCircle], true)
 }
 
 func buildTriangleImage(size int) {
@@ -157,27 +474,7 @@ func buildTriangleImage(size int) {
 	brX := cx + r*float32(math.Cos(math.Pi/6))
 	brY := cy + r*float32(math.Sin(math.Pi/6))
 
-	drawFilledTriangle(triangleImg, topX, topY, blX, blY, brX, brY, color.RGBA{R: 0xd8, G: 0x2a, B: 0x2a, A: 0xff})
-
-	// Eyes positioned near the centroid (slightly above)
-	eyeCenterY := cy - r*0.1
-	drawEyes(triangleImg, cx, eyeCenterY)
-}
-
-func drawEyes(img *ebiten.Image, cx, cy float32) {
-	eyeR := float32(4)
-	eyeOffX := float32(4.5)
-	eyeOffY := float32(-3.5)
-	white := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
-	vector.DrawFilledCircle(img, cx-eyeOffX, cy+eyeOffY, eyeR, white, true)
-	vector.DrawFilledCircle(img, cx+eyeOffX, cy+eyeOffY, eyeR, white, true)
-
-	pupilR := float32(2)
-	pupilOffX := float32(5.0)
-	pupilOffY := float32(-3.5)
-	dark := color.RGBA{R: 0x10, G: 0x10, B: 0x20, A: 0xff}
-	vector.DrawFilledCircle(img, cx-pupilOffX, cy+pupilOffY, pupilR, dark, true)
-	vector.DrawFilledCircle(img, cx+pupilOffX, cy+pupilOffY, pupilR, dark, true)
+	drawFilledTriangle(triangleImg, topX, topY, blX, blY, brX, brY, shapeColors[ShapeTriangle])
 }
 
 func buildHexagonImage(size int) {
@@ -193,9 +490,7 @@ func buildHexagonImage(size int) {
 		vertices[i*2] = cx + r*float32(math.Cos(angle))
 		vertices[i*2+1] = cy + r*float32(math.Sin(angle))
 	}
-	drawFilledPolygon(hexagonImg, vertices, color.RGBA{R: 0xff, G: 0x8c, B: 0x00, A: 0xff})
-
-	drawEyes(hexagonImg, cx, cy)
+	drawFilledPolygon(hexagonImg, vertices, shapeColors[ShapeHexagon])
 }
 
 func drawFilledPolygon(dst *ebiten.Image, verts []float32, clr color.Color) {
@@ -257,8 +552,14 @@ func drawFilledTriangle(dst *ebiten.Image, x1, y1, x2, y2, x3, y3 float32, clr c
 	dst.DrawTriangles(vs, is, whitePixel, op)
 }
 
-// Draw draws the player with the active shape, rolling.
+// Draw draws the player with the active shape, rolling and squashing, with upright eyes.
+// Shapes with a skin draw its sprite instead.
 func (p *Player) Draw(screen *ebiten.Image, sx, sy int) {
+	if sk := p.skins[p.Shape]; sk != nil {
+		p.anim.drawSkin(screen, sk, sx, sy)
+		return
+	}
+
 	var img *ebiten.Image
 	switch p.Shape {
 	case ShapeTriangle:
@@ -273,13 +574,20 @@ func (p *Player) Draw(screen *ebiten.Image, sx, sy int) {
 	imgSize := float64(img.Bounds().Dx())
 	half := imgSize / 2
 
+	// Squash keeps the bottom edge in place
+	sq := p.anim.squash
 	op.GeoM.Translate(-half, -half)
 	op.GeoM.Rotate(p.Rotation)
-	op.GeoM.Translate(half, half)
+	op.GeoM.Scale(1+sq, 1-sq)
+	op.GeoM.Translate(half, half+half*sq)
 	op.GeoM.Translate(float64(sx)-1, float64(sy)-1)
 
 	op.Filter = ebiten.FilterLinear
 	screen.DrawImage(img, op)
+
+	cx := float64(sx) - 1 + half
+	cy := float64(sy) - 1 + half + half*sq + eyeOffsetY[p.Shape]*(1-sq)
+	p.anim.drawEyes(screen, cx, cy)
 }
 
 // CenterX returns the world X of the player's center.
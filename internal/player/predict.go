@@ -0,0 +1,49 @@
+package player
+
+import (
+	"platform-game-one/internal/input"
+	"platform-game-one/internal/level"
+)
+
+// Point is a position in world coordinates.
+type Point struct {
+	X, Y float64
+}
+
+// PredictJump returns the center points the player would pass through if jump were
+// pressed now while holding held, stopping on landing, below the level, or after max ticks.
+// Neither the player nor the level is modified.
+func (p *Player) PredictJump(lv *level.Level, dt float64, held input.Buttons, max int) []Point {
+	// stepping starts blocks crumbling and breaks them, so step on a copy
+	defer func(live level.Live) { lv.Live = live }(lv.Live)
+	lv.Live = lv.Live.Clone()
+	sim := *p
+	sim.machine.hooks = nil // a prediction doesn't happen, so nothing reacts to it
+	held &^= input.Shape
+	prev := held &^ input.Jump
+	points := make([]Point, 0, max)
+	for i := 0; i < max; i++ {
+		sim.Step(dt, input.Next(prev, held|input.Jump), lv)
+		prev = held | input.Jump
+		points = append(points, Point{sim.CenterX(), sim.CenterY()})
+		if (i > 0 && sim.Grounded) || sim.Y > lv.DeathY {
+			break
+		}
+	}
+	return points
+}
+
+// Envelope returns the player's movement for level reachability checks at tick dt.
+func Envelope(dt float64) level.Envelope {
+	return level.Envelope{
+		Width:        Width,
+		Height:       Height,
+		MoveSpeed:    MoveSpeed,
+		JumpVelocity: JumpVelocity,
+		Gravity:      Gravity,
+		CoyoteTime:   CoyoteTimeMax,
+		WindDrag:     WindDrag,
+		StickyJump:   StickyJump,
+		Step:         dt,
+	}
+}
@@ -0,0 +1,76 @@
+package player
+
+import (
+	"image"
+	"testing"
+
+	"platform-game-one/internal/input"
+	"platform-game-one/internal/level"
+)
+
+// hill is a ledge at the left, a ramp down from it at the given angle and a floor
+// from the ramp's foot on.
+func hill(degrees float64) *level.Level {
+	const top, bottom, rampX, rampW = 400, 640, 300, 400
+	ramp := level.Ramp(rampX, bottom, rampW, degrees, false)
+	return &level.Level{
+		Platforms: []image.Rectangle{
+			image.Rect(0, ramp.Rect.Min.Y, rampX, ramp.Rect.Min.Y+top),
+			image.Rect(rampX, bottom, 2000, 720),
+		},
+		Slopes: []level.Slope{ramp},
+		Width:  2000,
+		Height: 720,
+		DeathY: deathY,
+	}
+}
+
+// rollDown puts a shape on hill's ledge, holds Right until it's well past the foot
+// of the ramp and returns how many ticks that took. It fails t if the player is
+// ever off the ground while over the ramp. On flat ground the player is only
+// grounded every other tick at rest, which coyote time covers; on a ramp it has
+// to be snapped down every tick or it would hop down it in little falls.
+func rollDown(t *testing.T, lv *level.Level, shape Shape) int {
+	t.Helper()
+	ramp := lv.Slopes[0].Rect
+	p := New(float64(ramp.Min.X-2*Width), float64(ramp.Min.Y-Height))
+	p.Shape = shape
+	for n := 0; !p.Grounded; n++ {
+		if n == 60 {
+			t.Fatalf("never landed on the ledge; at %.1f,%.1f", p.X, p.Y)
+		}
+		p.Step(dt, input.State{}, lv)
+	}
+	held := input.Buttons(0)
+	for n := 1; n < 600; n++ {
+		in := input.Next(held, input.Right)
+		held = input.Right
+		p.Step(dt, in, lv)
+		if over := p.CenterX() > float64(ramp.Min.X) && p.CenterX() < float64(ramp.Max.X); over && !p.Grounded {
+			t.Fatalf("%v, tick %d: left the ramp at %.1f,%.1f going %.1f,%.1f", shape, n, p.X, p.Y, p.VX, p.VY)
+		}
+		if p.X > float64(ramp.Max.X+2*Width) {
+			return n
+		}
+	}
+	t.Fatalf("%v never got past the ramp; stuck at %.1f,%.1f", shape, p.X, p.Y)
+	return 0
+}
+
+func TestWalkingDownSlopesStaysGrounded(t *testing.T) {
+	for _, degrees := range []float64{45, 22.5} {
+		for _, shape := range []Shape{ShapeCircle, ShapeTriangle, ShapeHexagon} {
+			rollDown(t, hill(degrees), shape)
+		}
+	}
+}
+
+// TestCircleRollsDownhillFaster uses a steep ramp: the player moves whole pixels a
+// tick, so on a gentle one both shapes' boosted speeds round down to the same step.
+func TestCircleRollsDownhillFaster(t *testing.T) {
+	lv := hill(45)
+	circle, triangle := rollDown(t, lv, ShapeCircle), rollDown(t, lv, ShapeTriangle)
+	if circle >= triangle {
+		t.Errorf("the circle took %d ticks, the triangle %d; want the circle faster", circle, triangle)
+	}
+}
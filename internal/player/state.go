@@ -0,0 +1,184 @@
+package player
+
+// State is what the player is doing. Step works it out at the end of every tick
+// from the physics, so it never changes how the player moves; animation, sound
+// and effects follow it instead of piecing it together from the flags.
+type State int
+
+const (
+	StateIdle       State = iota // standing still on the ground
+	StateRunning                 // walking along the ground
+	StateJumping                 // going up, from a jump, a swim stroke or a spring
+	StateFalling                 // coming down
+	StateLanding                 // just back on the ground, for LandTime
+	StateClimbing                // holding on to a ladder or vine
+	StateSwimming                // deep enough in water to swim
+	StatePounding                // in a ground-pound
+	StateDead                    // fell out of the level, until Respawn
+	StateRespawning              // just put back, for RespawnTime or until moving
+	stateCount
+)
+
+const (
+	LandTime    = 0.12 // seconds the Landing state lasts
+	RespawnTime = 0.5  // seconds the Respawning state lasts, unless the player sets off sooner
+	HistoryLen  = 32   // transitions kept for History
+)
+
+var stateNames = [stateCount]string{
+	"idle", "running", "jumping", "falling", "landing",
+	"climbing", "swimming", "pounding", "dead", "respawning",
+}
+
+func (s State) String() string {
+	if s >= 0 && s < stateCount {
+		return stateNames[s]
+	}
+	return "unknown"
+}
+
+// airborne reports whether s is off the ground, so that touching down is a landing.
+func (s State) airborne() bool {
+	return s == StateJumping || s == StateFalling || s == StatePounding
+}
+
+// Transition is one change of State. At is the player's clock, in seconds of
+// Step since New.
+type Transition struct {
+	From, To State
+	At       float64
+}
+
+// machine keeps the player's State, how long it has been in it and what it was before.
+type machine struct {
+	state   State
+	time    float64                // seconds in state
+	clock   float64                // seconds stepped since New
+	history [HistoryLen]Transition // a ring, overwriting the oldest
+	total   int                    // transitions ever made
+	hooks   *hooks                 // nil until one is registered
+}
+
+// Hook runs as the player enters or leaves a state. Like everything driven by the
+// state, it must not change how the player moves.
+type Hook func(t Transition)
+
+// hooks are the Hooks registered for each state, run in the order registered.
+type hooks struct {
+	enter, exit [stateCount][]Hook
+}
+
+// OnEnter registers h to run whenever the player enters s, after the state has
+// changed. Copies of the player share its hooks, but PredictJump's doesn't run them.
+func (p *Player) OnEnter(s State, h Hook) {
+	if p.machine.hooks == nil {
+		p.machine.hooks = &hooks{}
+	}
+	p.machine.hooks.enter[s] = append(p.machine.hooks.enter[s], h)
+}
+
+// OnExit registers h to run whenever the player leaves s, before the state changes.
+func (p *Player) OnExit(s State, h Hook) {
+	if p.machine.hooks == nil {
+		p.machine.hooks = &hooks{}
+	}
+	p.machine.hooks.exit[s] = append(p.machine.hooks.exit[s], h)
+}
+
+// animate registers the animation's hooks.
+func (p *Player) animate() {
+	p.OnEnter(StateLanding, func(Transition) { p.anim.land(p.LandSpeed) })
+	p.OnEnter(StateRespawning, func(Transition) { p.anim.appear() })
+	p.OnEnter(StateDead, func(Transition) { p.anim.fear = 1 })
+	p.OnExit(StateClimbing, func(Transition) { p.anim.squash = -maxStretch / 2 }) // a little stretch letting go
+}
+
+// State returns what the player is doing as of the last Step or Respawn.
+func (p *Player) State() State { return p.machine.state }
+
+// StateTime returns the seconds spent in the current State.
+func (p *Player) StateTime() float64 { return p.machine.time }
+
+// History returns the last HistoryLen transitions, oldest first.
+func (p *Player) History() []Transition {
+	return p.TransitionsSince(0)
+}
+
+// Transitions returns how many transitions the player has ever made. Pass it to
+// TransitionsSince later to get the ones made in between.
+func (p *Player) Transitions() int { return p.machine.total }
+
+// TransitionsSince returns the transitions made after the first n, oldest first.
+// Only the last HistoryLen are kept.
+func (p *Player) TransitionsSince(n int) []Transition {
+	m := &p.machine
+	n = max(n, m.total-HistoryLen, 0)
+	ts := make([]Transition, 0, max(m.total-n, 0))
+	for i := n; i < m.total; i++ {
+		ts = append(ts, m.history[i%HistoryLen])
+	}
+	return ts
+}
+
+// setState moves the player to s, running the hooks, unless it is there already.
+func (p *Player) setState(s State) {
+	m := &p.machine
+	if s == m.state {
+		return
+	}
+	t := Transition{From: m.state, To: s, At: m.clock}
+	if m.hooks != nil {
+		for _, h := range m.hooks.exit[t.From] {
+			h(t)
+		}
+	}
+	m.history[m.total%HistoryLen] = t
+	m.total++
+	m.state, m.time = s, 0
+	if m.hooks != nil {
+		for _, h := range m.hooks.enter[t.To] {
+			h(t)
+		}
+	}
+}
+
+// updateState advances the machine by a Step of dt seconds and moves to the state
+// the player is now in.
+func (p *Player) updateState(dt, deathY float64) {
+	p.machine.clock += dt
+	p.machine.time += dt
+	p.setState(p.nextState(deathY))
+}
+
+// nextState works out the state from the physics. Grounded alone is not enough:
+// walking, it can drop out for a tick, so a player still in coyote time and not
+// going up counts as on the ground.
+func (p *Player) nextState(deathY float64) State {
+	m := &p.machine
+	next := StateIdle
+	switch {
+	case p.Y > deathY:
+		return StateDead
+	case m.state == StateDead:
+		return StateDead // until Respawn
+	case p.Climbing:
+		next = StateClimbing
+	case p.Pounding:
+		next = StatePounding
+	case p.Physics.Water >= SwimDepth && !p.Grounded:
+		next = StateSwimming
+	case !p.Grounded && p.VY < 0:
+		next = StateJumping
+	case !p.Grounded && p.CoyoteTime == 0:
+		next = StateFalling
+	case m.state.airborne() || m.state == StateLanding && m.time < LandTime:
+		next = StateLanding
+	case p.Run != 0:
+		next = StateRunning
+	}
+	if m.state == StateRespawning && m.time < RespawnTime &&
+		(next == StateIdle || next == StateFalling || next == StateLanding) {
+		return StateRespawning // dropping in where it was put back
+	}
+	return next
+}
@@ -0,0 +1,202 @@
+package player
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"platform-game-one/internal/input"
+	"platform-game-one/internal/level"
+)
+
+const (
+	dt     = 1.0 / 60
+	deathY = 1e6
+)
+
+// settle puts p on the ground in state s, as if it had been there a while.
+func settle(p *Player, s State) {
+	p.Grounded, p.CoyoteTime = true, CoyoteTimeMax
+	p.machine.state, p.machine.time = s, 10
+}
+
+// ticksIn steps p's state machine until it leaves want, and returns how many
+// ticks it stayed, giving up after limit.
+func ticksIn(p *Player, want State, limit int) int {
+	for n := 0; n < limit; n++ {
+		p.updateState(dt, deathY)
+		if p.State() != want {
+			return n
+		}
+	}
+	return limit
+}
+
+func TestLandingLastsLandTime(t *testing.T) {
+	p := &Player{}
+	settle(p, StateFalling)
+	p.updateState(dt, deathY)
+	if p.State() != StateLanding {
+		t.Fatalf("touching down from falling: %v, want landing", p.State())
+	}
+	if n, want := ticksIn(p, StateLanding, 100), int(math.Round(LandTime/dt)); n < want-1 || n > want+1 {
+		t.Errorf("landing lasted %d ticks, want about %d", n, want)
+	}
+	if p.State() != StateIdle {
+		t.Errorf("after landing: %v, want idle", p.State())
+	}
+}
+
+func TestLandingOnlyFromTheAir(t *testing.T) {
+	for _, from := range []State{StateIdle, StateRunning, StateClimbing, StateSwimming} {
+		p := &Player{}
+		settle(p, from)
+		p.updateState(dt, deathY)
+		if p.State() == StateLanding {
+			t.Errorf("from %v: landed without leaving the ground", from)
+		}
+	}
+}
+
+func TestCoyoteTimeCountsAsGround(t *testing.T) {
+	p := &Player{Run: MoveSpeed}
+	settle(p, StateRunning)
+	p.Grounded = false // a tick off the ground, walking
+	p.updateState(dt, deathY)
+	if p.State() != StateRunning {
+		t.Fatalf("off the ground in coyote time: %v, want running", p.State())
+	}
+	p.CoyoteTime = 0
+	p.updateState(dt, deathY)
+	if p.State() != StateFalling {
+		t.Errorf("after coyote time: %v, want falling", p.State())
+	}
+}
+
+func TestGoingUpIsJumpingEvenInCoyoteTime(t *testing.T) {
+	p := &Player{VY: JumpVelocity}
+	settle(p, StateRunning)
+	p.Grounded = false
+	p.updateState(dt, deathY)
+	if p.State() != StateJumping {
+		t.Errorf("rising off the ground: %v, want jumping", p.State())
+	}
+}
+
+func TestRespawnHoldsUntilMoving(t *testing.T) {
+	p := New(0, 0)
+	p.Respawn(0, 0)
+	if p.State() != StateRespawning {
+		t.Fatalf("after Respawn: %v, want respawning", p.State())
+	}
+	// falling in, landing and standing still keep it for RespawnTime
+	p.VY = 100
+	p.updateState(dt, deathY)
+	if p.State() != StateRespawning {
+		t.Fatalf("falling in: %v, want respawning", p.State())
+	}
+	p.Grounded, p.CoyoteTime, p.VY = true, CoyoteTimeMax, 0
+	if n, want := ticksIn(p, StateRespawning, 100), int(math.Round(RespawnTime/dt)); n < want-2 || n > want {
+		t.Errorf("respawning lasted %d ticks standing still, want about %d", n, want)
+	}
+	if p.State() != StateIdle {
+		t.Errorf("after respawning: %v, want idle", p.State())
+	}
+
+	// setting off ends it at once
+	p.Respawn(0, 0)
+	settle(p, StateRespawning)
+	p.machine.time = 0
+	p.Run = MoveSpeed
+	p.updateState(dt, deathY)
+	if p.State() != StateRunning {
+		t.Errorf("running off while respawning: %v, want running", p.State())
+	}
+}
+
+func TestDeadUntilRespawn(t *testing.T) {
+	p := New(0, 0)
+	p.Y = deathY + 1
+	p.updateState(dt, deathY)
+	if p.State() != StateDead {
+		t.Fatalf("below deathY: %v, want dead", p.State())
+	}
+	p.Y = 0
+	settle(p, StateDead)
+	p.updateState(dt, deathY)
+	if p.State() != StateDead {
+		t.Errorf("back above deathY without Respawn: %v, want dead", p.State())
+	}
+	p.Respawn(0, 0)
+	if p.State() != StateRespawning {
+		t.Errorf("after Respawn: %v, want respawning", p.State())
+	}
+}
+
+func TestHistoryWrapsAround(t *testing.T) {
+	p := &Player{}
+	const made = HistoryLen + 5
+	for i := 1; i <= made; i++ {
+		p.machine.clock = float64(i)
+		p.setState(State(i % 2)) // idle and running in turn, starting from idle
+	}
+	if p.Transitions() != made {
+		t.Fatalf("%d transitions counted, want %d", p.Transitions(), made)
+	}
+	h := p.History()
+	if len(h) != HistoryLen {
+		t.Fatalf("history holds %d, want %d", len(h), HistoryLen)
+	}
+	for i, tr := range h {
+		n := made - HistoryLen + 1 + i // the nth transition made
+		want := Transition{From: State((n + 1) % 2), To: State(n % 2), At: float64(n)}
+		if tr != want {
+			t.Fatalf("history[%d] = %+v, want %+v", i, tr, want)
+		}
+	}
+
+	if got := p.TransitionsSince(made - 3); !slices.Equal(got, h[HistoryLen-3:]) {
+		t.Errorf("since %d: %+v, want the last 3", made-3, got)
+	}
+	if got := p.TransitionsSince(0); !slices.Equal(got, h) {
+		t.Errorf("since 0: %+v, want all that are kept", got)
+	}
+	for _, n := range []int{made, made + 5} {
+		if got := p.TransitionsSince(n); len(got) != 0 {
+			t.Errorf("since %d: %+v, want none", n, got)
+		}
+	}
+}
+
+func TestHooksRunInOrder(t *testing.T) {
+	p := &Player{}
+	var ran []string
+	p.OnExit(StateIdle, func(tr Transition) { ran = append(ran, "exit "+tr.From.String()) })
+	p.OnEnter(StateRunning, func(tr Transition) { ran = append(ran, "enter "+tr.To.String()) })
+	p.OnEnter(StateRunning, func(Transition) { ran = append(ran, "enter again") })
+	p.OnEnter(StateJumping, func(Transition) { ran = append(ran, "wrong state") })
+	p.setState(StateRunning)
+	p.setState(StateRunning) // no change, no hooks
+	if want := []string{"exit idle", "enter running", "enter again"}; !slices.Equal(ran, want) {
+		t.Errorf("ran %q, want %q", ran, want)
+	}
+}
+
+func TestPredictionRunsNoHooks(t *testing.T) {
+	lv := level.Blank(level.ScreenWidth, level.ScreenHeight)
+	p := New(lv.StartX, lv.StartY)
+	for range 60 {
+		p.Step(dt, input.State{}, lv) // down onto the floor
+	}
+	entered := 0
+	for s := range stateCount {
+		p.OnEnter(s, func(Transition) { entered++ })
+	}
+	before := p.Transitions()
+	if arc := p.PredictJump(lv, dt, 0, 60); len(arc) < 2 {
+		t.Fatalf("predicted %d points", len(arc))
+	}
+	if entered != 0 || p.Transitions() != before {
+		t.Errorf("predicting a jump ran %d hooks and made %d transitions", entered, p.Transitions()-before)
+	}
+}
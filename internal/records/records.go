@@ -0,0 +1,137 @@
+package records
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Run is one completed level.
+type Run struct {
+	Level    string    `json:"level"`
+	Ticks    int       `json:"ticks"`
+	Assisted bool      `json:"assisted"` // any assist was on, so it isn't a clean time
+	Date     time.Time `json:"date"`
+}
+
+// Seconds returns the run time in seconds at 60 ticks per second.
+func (r Run) Seconds() float64 { return float64(r.Ticks) / 60 }
+
+// Book keeps the best run per level and how far each level pack has been played.
+// Assisted and clean runs are kept apart so an assisted run never replaces a clean
+// record.
+type Book struct {
+	Best     map[string]Run `json:"best"`
+	Progress map[string]int `json:"progress,omitempty"` // highest level finished per pack
+}
+
+// New returns an empty book.
+func New() *Book {
+	return &Book{Best: map[string]Run{}, Progress: map[string]int{}}
+}
+
+// LevelKey names level num of a pack.
+func LevelKey(pack string, num int) string {
+	return fmt.Sprintf("%s/%d", pack, num)
+}
+
+// Complete notes that level num of pack was finished.
+func (b *Book) Complete(pack string, num int) {
+	if num > b.Progress[pack] {
+		b.Progress[pack] = num
+	}
+}
+
+// Completed returns the highest level of pack that has been finished.
+func (b *Book) Completed(pack string) int { return b.Progress[pack] }
+
+func key(level string, assisted bool) string {
+	if assisted {
+		return level + "/assisted"
+	}
+	return level
+}
+
+// Add records a run and reports whether it is a new best for its category.
+func (b *Book) Add(r Run) bool {
+	k := key(r.Level, r.Assisted)
+	if best, ok := b.Best[k]; ok && best.Ticks <= r.Ticks {
+		return false
+	}
+	b.Best[k] = r
+	return true
+}
+
+// BestFor returns the best run for a level in the given category.
+func (b *Book) BestFor(level string, assisted bool) (Run, bool) {
+	r, ok := b.Best[key(level, assisted)]
+	return r, ok
+}
+
+// Load reads a book from path. A missing file gives an empty book.
+func Load(path string) (*Book, error) {
+	b := New()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, b); err != nil {
+		return nil, fmt.Errorf("records %s: %w", path, err)
+	}
+	if b.Best == nil {
+		b.Best = map[string]Run{}
+	}
+	if b.Progress == nil {
+		b.Progress = map[string]int{}
+	}
+	b.migrate()
+	return b, nil
+}
+
+// Save writes the book to path, creating its directory if needed.
+func (b *Book) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write a new file and swap it in, so a crash never leaves half a file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// classicPack is the pack the built-in levels were in before there were packs.
+const classicPack = "classic"
+
+// migrate renames records from before level packs, whose levels were bare numbers
+// like "2" or "2 coop/assisted", to the classic pack.
+func (b *Book) migrate() {
+	for k, r := range b.Best {
+		if k == "" || k[0] < '0' || k[0] > '9' {
+			continue
+		}
+		delete(b.Best, k)
+		r.Level = classicPack + "/" + r.Level
+		b.Best[classicPack+"/"+k] = r
+		// a hand-edited file may have left the level blank
+		if f := strings.Fields(r.Level[len(classicPack)+1:]); len(f) > 0 {
+			if n, err := strconv.Atoi(f[0]); err == nil {
+				b.Complete(classicPack, n)
+			}
+		}
+	}
+}
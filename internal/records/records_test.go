@@ -0,0 +1,112 @@
+package records
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAddKeepsAssistedRunsApart(t *testing.T) {
+	b := New()
+	steps := []struct {
+		run  Run
+		best bool
+	}{
+		{Run{Level: "classic/1", Ticks: 300}, true},
+		{Run{Level: "classic/1", Ticks: 200, Assisted: true}, true},
+		{Run{Level: "classic/1", Ticks: 350}, false},
+		{Run{Level: "classic/1", Ticks: 300}, false}, // a tie keeps the first
+		{Run{Level: "classic/1", Ticks: 250}, true},
+		{Run{Level: "classic/1", Ticks: 250, Assisted: true}, false},
+		{Run{Level: "classic/2", Ticks: 900}, true},
+	}
+	for i, s := range steps {
+		if got := b.Add(s.run); got != s.best {
+			t.Errorf("step %d: Add(%+v) = %v, want %v", i, s.run, got, s.best)
+		}
+	}
+	if r, _ := b.BestFor("classic/1", false); r.Ticks != 250 {
+		t.Errorf("clean best is %d ticks, want 250", r.Ticks)
+	}
+	if r, _ := b.BestFor("classic/1", true); r.Ticks != 200 {
+		t.Errorf("assisted best is %d ticks, want 200", r.Ticks)
+	}
+	if _, ok := b.BestFor("classic/2", true); ok {
+		t.Error("a clean run counted as an assisted best")
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game", "records.json")
+	b, err := Load(path)
+	if err != nil || len(b.Best) != 0 || len(b.Progress) != 0 {
+		t.Fatalf("loading a missing file: %+v, %v; want an empty book", b, err)
+	}
+	day := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b.Add(Run{Level: "classic/1", Ticks: 300, Date: day})
+	b.Add(Run{Level: "classic/1", Ticks: 200, Assisted: true, Date: day})
+	b.Complete("classic", 2)
+	b.Complete("classic", 1) // going back doesn't lose progress
+	if err := b.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".tmp"); err == nil {
+		t.Error("the temporary file was left behind")
+	}
+
+	back, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back.Best) != len(b.Best) {
+		t.Fatalf("loaded %+v, want %+v", back.Best, b.Best)
+	}
+	for k, r := range b.Best {
+		if got := back.Best[k]; got.Level != r.Level || got.Ticks != r.Ticks || got.Assisted != r.Assisted || !got.Date.Equal(r.Date) {
+			t.Errorf("%s: loaded %+v, want %+v", k, got, r)
+		}
+	}
+	if got := back.Completed("classic"); got != 2 {
+		t.Errorf("classic progress %d, want 2", got)
+	}
+}
+
+func TestLoadMigratesNumberedLevels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.json")
+	old := `{"best": {
+		"2": {"level": "2", "ticks": 100},
+		"2 coop/assisted": {"level": "2 coop", "ticks": 90, "assisted": true},
+		"1": {"level": "1", "ticks": 80},
+		"3": {"level": " ", "ticks": 70},
+		"warmup/1": {"level": "warmup/1", "ticks": 60}
+	}}`
+	if err := os.WriteFile(path, []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"classic/2":               "classic/2",
+		"classic/2 coop/assisted": "classic/2 coop",
+		"classic/1":               "classic/1",
+		"classic/3":               "classic/ ",
+		"warmup/1":                "warmup/1",
+	}
+	if len(b.Best) != len(want) {
+		t.Errorf("migrated to %+v, want the keys %v", b.Best, want)
+	}
+	for k, level := range want {
+		if r, ok := b.Best[k]; !ok || r.Level != level {
+			t.Errorf("%s: got %+v, %v; want level %q", k, r, ok, level)
+		}
+	}
+	if got := b.Completed("classic"); got != 2 {
+		t.Errorf("classic progress %d, want 2", got)
+	}
+	if got := b.Completed("warmup"); got != 0 {
+		t.Errorf("warmup progress %d, want 0: only old numbered levels count", got)
+	}
+}
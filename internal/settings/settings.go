@@ -0,0 +1,123 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Assist holds the helpers for younger players. Any of them being on marks runs as assisted.
+type Assist struct {
+	ShowArc       bool    `json:"show_arc"`       // draw where a jump would go
+	Speed         float64 `json:"speed"`          // game speed multiplier; 0 means 1
+	InfiniteJumps bool    `json:"infinite_jumps"` // jump again in mid-air
+	Invincible    bool    `json:"invincible"`     // hazards put you back on safe ground instead of at the start
+}
+
+// Active reports whether any assist is on.
+func (a Assist) Active() bool {
+	return a.ShowArc || (a.Speed != 0 && a.Speed != 1) || a.InfiniteJumps || a.Invincible
+}
+
+// TimeScale returns the game speed multiplier.
+func (a Assist) TimeScale() float64 {
+	if a.Speed == 0 {
+		return 1
+	}
+	return a.Speed
+}
+
+// Validate checks that the assist values are in range.
+func (a Assist) Validate() error {
+	if a.Speed != 0 && (a.Speed < 0.25 || a.Speed > 1) {
+		return fmt.Errorf("assist speed %g out of range 0.25-1", a.Speed)
+	}
+	return nil
+}
+
+// Volume holds the audio bus levels, each 0-1. Music and SFX are scaled by Master.
+type Volume struct {
+	Master float64 `json:"master"`
+	Music  float64 `json:"music"`
+	SFX    float64 `json:"sfx"`
+}
+
+// Validate checks that the volumes are in range.
+func (v Volume) Validate() error {
+	for _, x := range []float64{v.Master, v.Music, v.SFX} {
+		if x < 0 || x > 1 {
+			return fmt.Errorf("volume %g out of range 0-1", x)
+		}
+	}
+	return nil
+}
+
+// Settings are the player's saved preferences.
+type Settings struct {
+	Assist Assist            `json:"assist"`
+	Volume Volume            `json:"volume"`
+	Skins  map[string]string `json:"skins,omitempty"` // shape name to skin name
+}
+
+// Dir returns the directory holding the game's user files.
+func Dir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "platform-game-one"), nil
+}
+
+// Path returns the default settings file location.
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "settings.json"), nil
+}
+
+// Default returns the settings used when there is no settings file.
+func Default() *Settings {
+	return &Settings{
+		Assist: Assist{Speed: 1},
+		Volume: Volume{Master: 0.8, Music: 0.5, SFX: 0.8},
+	}
+}
+
+// Load reads settings from path. A missing file gives the defaults.
+func Load(path string) (*Settings, error) {
+	s := Default()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("settings %s: %w", path, err)
+	}
+	if err := s.Assist.Validate(); err != nil {
+		return nil, fmt.Errorf("settings %s: %w", path, err)
+	}
+	if err := s.Volume.Validate(); err != nil {
+		return nil, fmt.Errorf("settings %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes settings to path, creating its directory if needed.
+func (s *Settings) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,173 @@
+package skin
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png" // sheets are PNG
+	"io/fs"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+//go:embed skins
+var embedded embed.FS
+
+// Anim is one of the animations a skin provides.
+type Anim int
+
+const (
+	Idle Anim = iota
+	Run
+	Jump
+	Fall
+	Land
+	animCount
+)
+
+var animNames = [animCount]string{"idle", "run", "jump", "fall", "land"}
+
+func (a Anim) String() string {
+	if a < 0 || a >= animCount {
+		return fmt.Sprintf("Anim(%d)", int(a))
+	}
+	return animNames[a]
+}
+
+// Meta is the JSON metadata stored next to a sprite sheet. Frames are numbered
+// left to right, top to bottom across a grid of FrameWidth x FrameHeight cells.
+type Meta struct {
+	FrameWidth  int                 `json:"frame_width"`
+	FrameHeight int                 `json:"frame_height"`
+	Animations  map[string]AnimMeta `json:"animations"`
+}
+
+// AnimMeta lists the frames of one animation.
+type AnimMeta struct {
+	Frames []int   `json:"frames"`
+	FPS    float64 `json:"fps"`
+}
+
+// Skin is a loaded sprite sheet with its frames cut out.
+type Skin struct {
+	Name   string
+	FrameW int
+	FrameH int
+	anims  [animCount][]*ebiten.Image
+	fps    [animCount]float64
+}
+
+// Frame returns the frame of a to show t seconds into the animation.
+// Animations the skin doesn't have fall back to idle.
+func (s *Skin) Frame(a Anim, t float64) *ebiten.Image {
+	if len(s.anims[a]) == 0 {
+		a = Idle
+	}
+	frames := s.anims[a]
+	i := int(t*s.fps[a]) % len(frames)
+	return frames[i]
+}
+
+// Embedded returns the skins built into the game.
+func Embedded() fs.FS {
+	sub, err := fs.Sub(embedded, "skins")
+	if err != nil {
+		panic(err) // the directory is embedded, so this can't happen
+	}
+	return sub
+}
+
+// Find loads skin name, looking in userDir first and then in the embedded skins.
+// userDir may be empty.
+func Find(userDir, name string) (*Skin, error) {
+	if userDir != "" {
+		s, err := Load(os.DirFS(userDir), name)
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return s, err
+		}
+	}
+	return Load(Embedded(), name)
+}
+
+// Load reads name.png and name.json from fsys.
+func Load(fsys fs.FS, name string) (*Skin, error) {
+	data, err := fs.ReadFile(fsys, name+".json")
+	if err != nil {
+		return nil, fmt.Errorf("skin %s: %w", name, err)
+	}
+	var meta Meta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("skin %s: %s.json: %w", name, name, err)
+	}
+	f, err := fsys.Open(name + ".png")
+	if err != nil {
+		return nil, fmt.Errorf("skin %s: %w", name, err)
+	}
+	defer f.Close()
+	sheet, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("skin %s: %s.png: %w", name, name, err)
+	}
+	s, err := build(name, &meta, sheet)
+	if err != nil {
+		return nil, fmt.Errorf("skin %s: %s.json: %w", name, name, err)
+	}
+	return s, nil
+}
+
+// build checks meta against the sheet and cuts out the frames.
+func build(name string, meta *Meta, sheet image.Image) (*Skin, error) {
+	if meta.FrameWidth <= 0 || meta.FrameHeight <= 0 {
+		return nil, fmt.Errorf("frame size %dx%d must be positive", meta.FrameWidth, meta.FrameHeight)
+	}
+	b := sheet.Bounds()
+	cols, rows := b.Dx()/meta.FrameWidth, b.Dy()/meta.FrameHeight
+	if cols == 0 || rows == 0 {
+		return nil, fmt.Errorf("sheet %dx%d is smaller than one %dx%d frame", b.Dx(), b.Dy(), meta.FrameWidth, meta.FrameHeight)
+	}
+	if _, ok := meta.Animations["idle"]; !ok {
+		return nil, errors.New(`missing required "idle" animation`)
+	}
+	for key, am := range meta.Animations {
+		switch {
+		case animIndex(key) < 0:
+			return nil, fmt.Errorf("unknown animation %q (want idle, run, jump, fall or land)", key)
+		case len(am.Frames) == 0:
+			return nil, fmt.Errorf("animation %q has no frames", key)
+		case am.FPS <= 0:
+			return nil, fmt.Errorf("animation %q fps %g must be positive", key, am.FPS)
+		}
+		for _, n := range am.Frames {
+			if n < 0 || n >= cols*rows {
+				return nil, fmt.Errorf("animation %q frame %d is outside the %dx%d frame sheet", key, n, cols, rows)
+			}
+		}
+	}
+
+	img := ebiten.NewImageFromImage(sheet)
+	ib := img.Bounds()
+	s := &Skin{Name: name, FrameW: meta.FrameWidth, FrameH: meta.FrameHeight}
+	for key, am := range meta.Animations {
+		a := animIndex(key)
+		for _, n := range am.Frames {
+			x := ib.Min.X + n%cols*meta.FrameWidth
+			y := ib.Min.Y + n/cols*meta.FrameHeight
+			r := image.Rect(x, y, x+meta.FrameWidth, y+meta.FrameHeight)
+			s.anims[a] = append(s.anims[a], img.SubImage(r).(*ebiten.Image))
+		}
+		s.fps[a] = am.FPS
+	}
+	return s, nil
+}
+
+func animIndex(name string) Anim {
+	for i, n := range animNames {
+		if n == name {
+			return Anim(i)
+		}
+	}
+	return -1
+}
@@ -0,0 +1,74 @@
+package skin
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// sheet returns a PNG w by h pixels.
+func sheet(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestLoadReportsBadMetadata(t *testing.T) {
+	data := sheet(t, 64, 32) // 2x1 frames of 32x32
+	tests := []struct {
+		name string
+		meta string
+		want string
+	}{
+		{"broken JSON", `{"frame_width": 32,`, "bad.json: unexpected end of JSON input"},
+		{"no idle animation", `{"frame_width": 32, "frame_height": 32, "animations": {"run": {"frames": [0, 1], "fps": 8}}}`, `missing required "idle" animation`},
+		{"an unknown animation", `{"frame_width": 32, "frame_height": 32, "animations": {"idle": {"frames": [0], "fps": 1}, "dance": {"frames": [1], "fps": 1}}}`, `unknown animation "dance"`},
+		{"a frame past the sheet", `{"frame_width": 32, "frame_height": 32, "animations": {"idle": {"frames": [0, 2], "fps": 1}}}`, `animation "idle" frame 2 is outside the 2x1 frame sheet`},
+		{"a negative frame", `{"frame_width": 32, "frame_height": 32, "animations": {"idle": {"frames": [-1], "fps": 1}}}`, `animation "idle" frame -1 is outside`},
+		{"frames bigger than the sheet", `{"frame_width": 128, "frame_height": 32, "animations": {"idle": {"frames": [0], "fps": 1}}}`, "sheet 64x32 is smaller than one 128x32 frame"},
+		{"no frame size", `{"animations": {"idle": {"frames": [0], "fps": 1}}}`, "frame size 0x0 must be positive"},
+		{"no frames", `{"frame_width": 32, "frame_height": 32, "animations": {"idle": {"frames": [], "fps": 1}}}`, `animation "idle" has no frames`},
+		{"no fps", `{"frame_width": 32, "frame_height": 32, "animations": {"idle": {"frames": [0]}}}`, `animation "idle" fps 0 must be positive`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"bad.json": {Data: []byte(tt.meta)},
+				"bad.png":  {Data: data},
+			}
+			_, err := Load(fsys, "bad")
+			if err == nil {
+				t.Fatalf("loaded, want an error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) || !strings.HasPrefix(err.Error(), "skin bad: ") {
+				t.Errorf("error %q, want one about skin bad containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindMissingSkin(t *testing.T) {
+	_, err := Find(t.TempDir(), "nope")
+	if err == nil || !strings.Contains(err.Error(), "skin nope") {
+		t.Errorf("error %v, want one naming skin nope", err)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("error %v doesn't wrap fs.ErrNotExist", err)
+	}
+}
+
+func TestAnimString(t *testing.T) {
+	for a, want := range map[Anim]string{Idle: "idle", Land: "land", animCount: "Anim(5)", -1: "Anim(-1)"} {
+		if got := a.String(); got != want {
+			t.Errorf("Anim(%d).String() = %q, want %q", int(a), got, want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package theme
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// crackStages is how many crack images a crumbling block goes through.
+const crackStages = 3
+
+var (
+	crackColor  = color.RGBA{A: 0xc0}
+	markerColor = color.RGBA{R: 0xff, G: 0xf0, B: 0xc0, A: 0xd0}
+)
+
+// blockKey identifies a cached block image.
+type blockKey struct {
+	kind  string
+	size  image.Point
+	stage int // cracks showing, 0 to crackStages
+}
+
+// DrawBlock draws a breakable block of the given kind and world rect with its top-left
+// at sx, sy. crumble runs from 0 when it's stood on to 1 as it falls, and cracks it.
+func (r *Renderer) DrawBlock(screen *ebiten.Image, kind string, rect image.Rectangle, crumble float64, sx, sy int) {
+	k := blockKey{kind: kind, size: rect.Size()}
+	if crumble > 0 {
+		k.stage = min(crackStages, 1+int(crumble*crackStages))
+	}
+	img, ok := r.blocks[k]
+	if !ok {
+		if len(r.blocks) >= maxCached {
+			clear(r.blocks)
+		}
+		img = r.renderBlock(k)
+		r.blocks[k] = img
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(sx), float64(sy))
+	screen.DrawImage(img, op)
+}
+
+// renderBlock draws a platform of the theme's style marked with how it breaks: cracks
+// for crumbling, an arrow underneath for head-bumps and arrows on top for ground-pounds.
+func (r *Renderer) renderBlock(k blockKey) *ebiten.Image {
+	img := r.renderPlatform(k.size)
+	w, h := float32(k.size.X), float32(k.size.Y)
+	switch k.kind {
+	case "crumble":
+		vector.StrokeLine(img, w*0.3, 0, w*0.4, h*0.6, 1, crackColor, false)
+		if k.stage >= 1 {
+			vector.StrokeLine(img, w*0.4, h*0.6, w*0.25, h, 1, crackColor, false)
+			vector.StrokeLine(img, w*0.7, h, w*0.6, h*0.3, 1, crackColor, false)
+		}
+		if k.stage >= 2 {
+			vector.StrokeLine(img, w*0.6, h*0.3, w*0.8, 0, 2, crackColor, false)
+			vector.StrokeLine(img, w*0.4, h*0.6, w*0.6, h*0.3, 2, crackColor, false)
+		}
+		if k.stage >= 3 {
+			vector.StrokeLine(img, 0, h*0.5, w*0.4, h*0.6, 2, crackColor, false)
+			vector.StrokeLine(img, w*0.6, h*0.3, w, h*0.45, 2, crackColor, false)
+		}
+	case "bump":
+		chevron(img, w/2, h-3, min(w, h)/3, -1)
+	case "pound":
+		for x := w / 4; x < w; x += w / 2 {
+			chevron(img, x, 3, min(w, h)/4, 1)
+		}
+	}
+	vector.StrokeRect(img, 0.5, 0.5, w-1, h-1, 1, crackColor, false)
+	return img
+}
+
+// chevron draws an arrowhead pointing up (dir -1) or down (dir 1) with its tip size
+// pixels from x, y.
+func chevron(img *ebiten.Image, x, y, size, dir float32) {
+	tip := y + dir*size
+	vector.StrokeLine(img, x-size, y, x, tip, 2, markerColor, true)
+	vector.StrokeLine(img, x, tip, x+size, y, 2, markerColor, true)
+}
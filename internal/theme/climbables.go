@@ -0,0 +1,73 @@
+package theme
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Like pads, ladders and vines are the same in every theme.
+var (
+	ladderWood = color.RGBA{R: 0xa0, G: 0x70, B: 0x40, A: 0xff}
+	ladderDark = color.RGBA{R: 0x60, G: 0x40, B: 0x20, A: 0xff}
+	vineStem   = color.RGBA{R: 0x30, G: 0x80, B: 0x30, A: 0xff}
+	vineLeaf   = color.RGBA{R: 0x60, G: 0xc0, B: 0x50, A: 0xff}
+)
+
+// climbKey identifies a cached ladder or vine image.
+type climbKey struct {
+	kind string
+	size image.Point
+}
+
+// DrawClimbable draws a ladder or vine with its top-left at sx, sy.
+func (r *Renderer) DrawClimbable(screen *ebiten.Image, kind string, rect image.Rectangle, sx, sy int) {
+	k := climbKey{kind: kind, size: rect.Size()}
+	img, ok := r.climbables[k]
+	if !ok {
+		if len(r.climbables) >= maxCached {
+			clear(r.climbables)
+		}
+		img = renderClimbable(k)
+		r.climbables[k] = img
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(sx), float64(sy))
+	screen.DrawImage(img, op)
+}
+
+// renderClimbable draws a ladder as two rails with rungs, and a vine as wavy stems
+// hung with leaves.
+func renderClimbable(k climbKey) *ebiten.Image {
+	img := ebiten.NewImage(k.size.X, k.size.Y)
+	w, h := float32(k.size.X), float32(k.size.Y)
+	if k.kind == "ladder" {
+		for y := float32(6); y < h; y += 14 {
+			vector.FillRect(img, 2, y, w-4, 4, ladderWood, false)
+			vector.FillRect(img, 2, y+3, w-4, 1, ladderDark, false)
+		}
+		vector.FillRect(img, 0, 0, 5, h, ladderWood, false)
+		vector.FillRect(img, w-5, 0, 5, h, ladderWood, false)
+		vector.FillRect(img, 4, 0, 1, h, ladderDark, false)
+		vector.FillRect(img, w-1, 0, 1, h, ladderDark, false)
+		return img
+	}
+	for x := float32(8); x < w; x += 20 {
+		var stem vector.Path
+		stem.MoveTo(x, 0)
+		for y := float32(0); y < h; y += 16 {
+			side := float32(4)
+			if int(y/16)%2 == 1 {
+				side = -4
+			}
+			stem.QuadTo(x+side, y+8, x, y+16)
+			vector.FillCircle(img, x+side*1.5, y+8, 3, vineLeaf, true)
+		}
+		op := &vector.DrawPathOptions{AntiAlias: true}
+		op.ColorScale.ScaleWithColor(vineStem)
+		vector.StrokePath(img, &stem, &vector.StrokeOptions{Width: 2}, op)
+	}
+	return img
+}
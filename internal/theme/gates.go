@@ -0,0 +1,148 @@
+package theme
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// GroupColors tint gates and the triggers that work them alike, so a key or lever
+// shows which door it opens. Like pads, they're the same in every theme.
+var GroupColors = []color.RGBA{
+	{R: 0xf0, G: 0xc0, B: 0x30, A: 0xff}, // gold
+	{R: 0x40, G: 0x90, B: 0xf0, A: 0xff}, // blue
+	{R: 0xe0, G: 0x50, B: 0x90, A: 0xff}, // pink
+	{R: 0x50, G: 0xc0, B: 0x60, A: 0xff}, // green
+	{R: 0xa0, G: 0x60, B: 0xe0, A: 0xff}, // purple
+}
+
+var gateDark = color.RGBA{R: 0x30, G: 0x28, B: 0x20, A: 0xff}
+
+// GroupColor returns the tint of gate group i.
+func GroupColor(i int) color.RGBA {
+	return GroupColors[max(0, i)%len(GroupColors)]
+}
+
+// gateKey identifies a cached gate image.
+type gateKey struct {
+	kind  string
+	size  image.Point
+	group int
+}
+
+// triggerKey identifies a cached trigger image.
+type triggerKey struct {
+	kind  string
+	size  image.Point
+	group int
+	on    bool
+}
+
+// DrawGate draws one platform of a door or bridge in gate group's color with its
+// top-left at sx, sy. While it isn't solid only a faint outline shows where it goes.
+func (r *Renderer) DrawGate(screen *ebiten.Image, kind string, rect image.Rectangle, group int, solid bool, sx, sy int) {
+	if !solid {
+		c := GroupColor(group)
+		c.A = 0x60
+		for x := 0; x < rect.Dx(); x += 8 {
+			vector.FillRect(screen, float32(sx+x), float32(sy), 4, 2, c, false)
+			vector.FillRect(screen, float32(sx+x), float32(sy+rect.Dy()-2), 4, 2, c, false)
+		}
+		for y := 0; y < rect.Dy(); y += 8 {
+			vector.FillRect(screen, float32(sx), float32(sy+y), 2, 4, c, false)
+			vector.FillRect(screen, float32(sx+rect.Dx()-2), float32(sy+y), 2, 4, c, false)
+		}
+		return
+	}
+	k := gateKey{kind: kind, size: rect.Size(), group: group}
+	img, ok := r.gates[k]
+	if !ok {
+		if len(r.gates) >= maxCached {
+			clear(r.gates)
+		}
+		img = renderGate(k)
+		r.gates[k] = img
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(sx), float64(sy))
+	screen.DrawImage(img, op)
+}
+
+// renderGate draws a door as bars with a keyhole, and a bridge as planks.
+func renderGate(k gateKey) *ebiten.Image {
+	img := ebiten.NewImage(k.size.X, k.size.Y)
+	w, h := float32(k.size.X), float32(k.size.Y)
+	c := GroupColor(k.group)
+	img.Fill(gateDark)
+	if k.kind == "door" {
+		for x := float32(4); x < w-2; x += 10 {
+			vector.FillRect(img, x, 0, 4, h, c, false)
+		}
+		cx, cy := w/2, h/2
+		vector.FillCircle(img, cx, cy, 6, gateDark, true)
+		vector.FillCircle(img, cx, cy-1, 3, c, true)
+		vector.FillRect(img, cx-1, cy, 2, 4, c, false)
+	} else {
+		for x := float32(1); x < w-1; x += 12 {
+			vector.FillRect(img, x, 1, 10, h-2, c, false)
+		}
+	}
+	vector.StrokeRect(img, 0.5, 0.5, w-1, h-1, 1, gateDark, false)
+	return img
+}
+
+// DrawTrigger draws a key, switch or lever in group's color with its top-left at sx,
+// sy. on presses a switch down and throws a lever to the right.
+func (r *Renderer) DrawTrigger(screen *ebiten.Image, kind string, rect image.Rectangle, group int, on bool, sx, sy int) {
+	k := triggerKey{kind: kind, size: rect.Size(), group: group, on: on}
+	img, ok := r.triggers[k]
+	if !ok {
+		if len(r.triggers) >= maxCached {
+			clear(r.triggers)
+		}
+		img = renderTrigger(k)
+		r.triggers[k] = img
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(sx), float64(sy))
+	screen.DrawImage(img, op)
+}
+
+func renderTrigger(k triggerKey) *ebiten.Image {
+	img := ebiten.NewImage(k.size.X, k.size.Y)
+	w, h := float32(k.size.X), float32(k.size.Y)
+	c := GroupColor(k.group)
+	switch k.kind {
+	case "key":
+		// a ring with a toothed shaft across the rect
+		rad := min(w, h) / 4
+		cx, cy := rad+2, h/2
+		vector.StrokeCircle(img, cx, cy, rad, 3, c, true)
+		vector.StrokeLine(img, cx+rad, cy, w-2, cy, 3, c, true)
+		vector.StrokeLine(img, w-4, cy, w-4, cy+rad, 3, c, true)
+		vector.StrokeLine(img, w-9, cy, w-9, cy+rad*0.7, 3, c, true)
+	case "switch":
+		// a button on a metal plate; pressed, it sinks into it
+		plate := max(2, h/4)
+		vector.FillRect(img, 0, h-plate, w, plate, padMetal, false)
+		top := float32(0)
+		if k.on {
+			top = h - plate - 2
+		}
+		vector.FillRect(img, w*0.15, top, w*0.7, h-plate-top, c, false)
+		vector.StrokeRect(img, w*0.15+0.5, top+0.5, w*0.7-1, h-plate-top-1, 1, gateDark, false)
+	default:
+		// lever: a stick on a base, thrown left when off and right when on
+		base := max(4, h/5)
+		vector.FillRect(img, w*0.2, h-base, w*0.6, base, padMetal, false)
+		tip := w * 0.2
+		if k.on {
+			tip = w * 0.8
+		}
+		vector.StrokeLine(img, w/2, h-base, tip, 5, 3, padShine, true)
+		vector.FillCircle(img, tip, 5, 4, c, true)
+	}
+	return img
+}
@@ -0,0 +1,70 @@
+package theme
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Materials are drawn over the platforms they cover, fresh every frame as conveyor
+// belts move. Like zones they're the same in every theme.
+var (
+	iceGlaze    = color.RGBA{R: 0xb0, G: 0xe0, B: 0xff, A: 0x90}
+	iceShine    = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xd0}
+	beltDark    = color.RGBA{R: 0x30, G: 0x30, B: 0x38, A: 0xff}
+	beltArrow   = color.RGBA{R: 0xff, G: 0xc0, B: 0x40, A: 0xff}
+	stickyGoo   = color.RGBA{R: 0x70, G: 0xb0, B: 0x30, A: 0xe0}
+	stickyBlobs = color.RGBA{R: 0xa0, G: 0xe0, B: 0x50, A: 0xe0}
+)
+
+// materialDepth is how far down from the top of its rect a material is drawn.
+const materialDepth = 10
+
+// DrawMaterial draws a material of the given kind along the top of its world rect,
+// with the rect's top-left at sx, sy. Conveyor arrows move along at speed, and t is
+// the time in seconds.
+func (r *Renderer) DrawMaterial(screen *ebiten.Image, kind string, rect image.Rectangle, speed, t float64, sx, sy int) {
+	x0, y0 := float32(sx), float32(sy)
+	w := float32(rect.Dx())
+	h := float32(min(rect.Dy(), materialDepth))
+	switch kind {
+	case "ice":
+		// a glaze with glints along it
+		vector.FillRect(screen, x0, y0, w, h, iceGlaze, false)
+		vector.FillRect(screen, x0, y0, w, 2, iceShine, false)
+		for x := float32(12); x < w-8; x += 40 {
+			vector.StrokeLine(screen, x0+x, y0+h-2, x0+x+6, y0+3, 1, iceShine, true)
+		}
+	case "conveyor":
+		// a belt with chevrons running the way it goes
+		vector.FillRect(screen, x0, y0, w, h, beltDark, false)
+		const gap = 20
+		dir := float32(1)
+		if speed < 0 {
+			dir = -1
+		}
+		off := float32(math.Mod(t*math.Abs(speed), gap))
+		for x := off - gap; x < w; x += gap {
+			ax := x
+			if dir < 0 {
+				ax = w - x
+			}
+			if ax < 4 || ax > w-4 {
+				continue
+			}
+			vector.StrokeLine(screen, x0+ax-3*dir, y0+2, x0+ax+3*dir, y0+h/2, 2, beltArrow, true)
+			vector.StrokeLine(screen, x0+ax+3*dir, y0+h/2, x0+ax-3*dir, y0+h-2, 2, beltArrow, true)
+		}
+	default:
+		// sticky: goo with drips hanging off it
+		vector.FillRect(screen, x0, y0, w, h*0.6, stickyGoo, false)
+		for i := range motes(rect) {
+			fx, _ := scatter(i)
+			vector.FillCircle(screen, x0+fx*w, y0+h*0.6, 3, stickyGoo, true)
+			vector.FillCircle(screen, x0+fx*w+2, y0+2, 1.5, stickyBlobs, true)
+		}
+	}
+}
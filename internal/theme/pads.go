@@ -0,0 +1,89 @@
+package theme
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Pads are the same in every theme, so they always read as something to touch.
+var (
+	padMetal  = color.RGBA{R: 0x50, G: 0x58, B: 0x68, A: 0xff}
+	padShine  = color.RGBA{R: 0xb0, G: 0xb8, B: 0xc8, A: 0xff}
+	padRed    = color.RGBA{R: 0xe0, G: 0x30, B: 0x30, A: 0xff}
+	padBounce = color.RGBA{R: 0x30, G: 0xd0, B: 0xb0, A: 0xff}
+	padStripe = color.RGBA{R: 0xa0, G: 0xff, B: 0xe8, A: 0xff}
+)
+
+// padKey identifies a cached pad image.
+type padKey struct {
+	kind string
+	size image.Point
+	aim  int // cannon barrel angle in degrees
+}
+
+// DrawPad draws a pad of the given kind and world rect with its top-left at sx, sy.
+// A cannon's barrel points along vx, vy.
+func (r *Renderer) DrawPad(screen *ebiten.Image, kind string, rect image.Rectangle, vx, vy float64, sx, sy int) {
+	k := padKey{kind: kind, size: rect.Size()}
+	if kind == "cannon" {
+		k.aim = int(math.Round(math.Atan2(vy, vx) * 180 / math.Pi))
+	}
+	img, ok := r.pads[k]
+	if !ok {
+		if len(r.pads) >= maxCached {
+			clear(r.pads)
+		}
+		img = renderPad(k)
+		r.pads[k] = img
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(sx), float64(sy))
+	screen.DrawImage(img, op)
+}
+
+func renderPad(k padKey) *ebiten.Image {
+	img := ebiten.NewImage(k.size.X, k.size.Y)
+	w, h := float32(k.size.X), float32(k.size.Y)
+	switch k.kind {
+	case "spring":
+		// a base, a zigzag coil and a red top plate
+		plate := max(2, h/5)
+		vector.FillRect(img, 0, h-plate, w, plate, padMetal, false)
+		vector.FillRect(img, 0, 0, w, plate, padRed, false)
+		const turns = 4
+		var coil vector.Path
+		coil.MoveTo(w/2, h-plate)
+		for i := 1; i <= turns*2; i++ {
+			x := w * 0.2
+			if i%2 == 0 {
+				x = w * 0.8
+			}
+			coil.LineTo(x, h-plate-(h-2*plate)*float32(i)/(turns*2))
+		}
+		op := &vector.DrawPathOptions{AntiAlias: true}
+		op.ColorScale.ScaleWithColor(padShine)
+		vector.StrokePath(img, &coil, &vector.StrokeOptions{Width: 2}, op)
+	case "cannon":
+		// a round body with its barrel along the launch direction
+		cx, cy := w/2, h/2
+		rad := min(w, h) / 2
+		a := float64(k.aim) * math.Pi / 180
+		ex, ey := cx+rad*float32(math.Cos(a)), cy+rad*float32(math.Sin(a))
+		vector.StrokeLine(img, cx, cy, ex, ey, rad*0.8, padMetal, true)
+		vector.FillCircle(img, cx, cy, rad*0.7, padMetal, true)
+		vector.FillCircle(img, ex, ey, rad*0.3, padRed, true)
+		vector.StrokeCircle(img, cx, cy, rad*0.7, 1, padShine, true)
+	default:
+		// bounce: a springy block with stripes along it
+		vector.FillRect(img, 0, 0, w, h, padBounce, false)
+		for y := float32(3); y < h; y += 6 {
+			vector.StrokeLine(img, 0, y, w, y, 1, padStripe, false)
+		}
+		vector.StrokeRect(img, 0.5, 0.5, w-1, h-1, 1, padMetal, false)
+	}
+	return img
+}
@@ -0,0 +1,103 @@
+package theme
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// DrawProp draws a decoration standing with its bottom center at sx, sy.
+// Unknown kinds draw nothing.
+func (r *Renderer) DrawProp(screen *ebiten.Image, kind string, sx, sy, scale float64) {
+	img, ok := r.props[kind]
+	if !ok {
+		img = r.renderProp(kind)
+		r.props[kind] = img
+	}
+	if img == nil {
+		return
+	}
+	if scale == 0 {
+		scale = 1
+	}
+	b := img.Bounds()
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-float64(b.Dx())/2, -float64(b.Dy()))
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(sx, sy)
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(img, op)
+}
+
+func (r *Renderer) renderProp(kind string) *ebiten.Image {
+	p := r.theme.Props
+	switch kind {
+	case "palm":
+		return renderPalm(p.Wood.RGBA(), p.Foliage.RGBA())
+	case "bush":
+		img := ebiten.NewImage(72, 40)
+		for _, c := range [][3]float32{{20, 26, 16}, {36, 20, 20}, {52, 26, 16}} {
+			vector.FillCircle(img, c[0], c[1], c[2], p.Foliage.RGBA(), true)
+		}
+		return img
+	case "rock":
+		img := ebiten.NewImage(56, 32)
+		var path vector.Path
+		path.MoveTo(2, 32)
+		path.LineTo(8, 12)
+		path.LineTo(24, 2)
+		path.LineTo(44, 8)
+		path.LineTo(54, 32)
+		path.Close()
+		fillPath(img, &path, p.Stone.RGBA())
+		return img
+	case "flower":
+		img := ebiten.NewImage(16, 32)
+		vector.StrokeLine(img, 8, 32, 8, 10, 2, p.Foliage.RGBA(), true)
+		for i := 0; i < 5; i++ {
+			a := float64(i) * 2 * math.Pi / 5
+			vector.FillCircle(img, 8+float32(4*math.Cos(a)), 8+float32(4*math.Sin(a)), 3, p.Bloom.RGBA(), true)
+		}
+		vector.FillCircle(img, 8, 8, 2, color.RGBA{R: 0xff, G: 0xe0, B: 0x40, A: 0xff}, true)
+		return img
+	}
+	return nil
+}
+
+// renderPalm draws a palm tree with a gently curved trunk and drooping fronds.
+func renderPalm(wood, leaves color.RGBA) *ebiten.Image {
+	const w, h = 140, 160
+	img := ebiten.NewImage(w, h)
+	// trunk: overlapping segments leaning right as they go up
+	topX, topY := float32(0), float32(0)
+	for i := 0; i <= 12; i++ {
+		t := float64(i) / 12
+		x := float32(w/2 + 18*t*t)
+		y := float32(h - t*120)
+		vector.FillCircle(img, x, y, float32(7-3*t), wood, true)
+		topX, topY = x, y
+	}
+	// fronds: curved leaves radiating from the top of the trunk
+	for _, a := range []float64{-170, -140, -100, -60, -25, 5} {
+		rad := a * math.Pi / 180
+		var path vector.Path
+		path.MoveTo(topX, topY)
+		tipX := topX + float32(52*math.Cos(rad))
+		tipY := topY + float32(52*math.Sin(rad)) + 18 // droop
+		nx, ny := float32(-math.Sin(rad)*7), float32(math.Cos(rad)*7)
+		midX, midY := (topX+tipX)/2, (topY+tipY)/2-10
+		path.QuadTo(midX+nx, midY+ny, tipX, tipY)
+		path.QuadTo(midX-nx, midY-ny, topX, topY)
+		path.Close()
+		fillPath(img, &path, leaves)
+	}
+	return img
+}
+
+func fillPath(dst *ebiten.Image, path *vector.Path, clr color.Color) {
+	op := &vector.DrawPathOptions{AntiAlias: true}
+	op.ColorScale.ScaleWithColor(clr)
+	vector.FillPath(dst, path, nil, op)
+}
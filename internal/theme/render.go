@@ -0,0 +1,280 @@
+package theme
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// maxCached is how many platform sizes are kept before the cache is dropped.
+const maxCached = 256
+
+// Renderer draws a level's theme. Everything it draws is rendered once and cached.
+type Renderer struct {
+	theme            *Theme
+	screenW, screenH int
+	sky              *ebiten.Image
+	layers           []*ebiten.Image
+	platforms        map[image.Point]*ebiten.Image
+	slopes           map[slopeKey]*ebiten.Image
+	pads             map[padKey]*ebiten.Image
+	blocks           map[blockKey]*ebiten.Image
+	gates            map[gateKey]*ebiten.Image
+	triggers         map[triggerKey]*ebiten.Image
+	climbables       map[climbKey]*ebiten.Image
+	goals            map[image.Point]*ebiten.Image
+	props            map[string]*ebiten.Image
+}
+
+// NewRenderer prepares t for a screenW x screenH screen.
+func NewRenderer(t *Theme, screenW, screenH int) *Renderer {
+	r := &Renderer{
+		theme:      t,
+		screenW:    screenW,
+		screenH:    screenH,
+		platforms:  map[image.Point]*ebiten.Image{},
+		slopes:     map[slopeKey]*ebiten.Image{},
+		pads:       map[padKey]*ebiten.Image{},
+		blocks:     map[blockKey]*ebiten.Image{},
+		gates:      map[gateKey]*ebiten.Image{},
+		triggers:   map[triggerKey]*ebiten.Image{},
+		climbables: map[climbKey]*ebiten.Image{},
+		goals:      map[image.Point]*ebiten.Image{},
+		props:      map[string]*ebiten.Image{},
+	}
+	r.sky = ebiten.NewImage(1, screenH)
+	for y := 0; y < screenH; y++ {
+		r.sky.Set(0, y, gradient(t.Sky, float64(y)/float64(screenH-1)))
+	}
+	for _, l := range t.Layers {
+		r.layers = append(r.layers, r.renderLayer(l))
+	}
+	return r
+}
+
+// Theme returns the theme being drawn.
+func (r *Renderer) Theme() *Theme { return r.theme }
+
+func gradient(stops []Color, t float64) color.RGBA {
+	if len(stops) == 1 {
+		return stops[0].RGBA()
+	}
+	f := t * float64(len(stops)-1)
+	i := int(f)
+	if i >= len(stops)-1 {
+		return stops[len(stops)-1].RGBA()
+	}
+	k := f - float64(i)
+	a, b := stops[i], stops[i+1]
+	lerp := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*k) }
+	return color.RGBA{R: lerp(a.R, b.R), G: lerp(a.G, b.G), B: lerp(a.B, b.B), A: lerp(a.A, b.A)}
+}
+
+// renderLayer draws one repeat of a layer. Hills and mountains are drawn with their
+// baseline Height pixels from the top and filled to the bottom of the screen.
+func (r *Renderer) renderLayer(l Layer) *ebiten.Image {
+	clr := l.Color.RGBA()
+	switch l.Kind {
+	case "sun":
+		size := int(l.Height*3) + 2
+		img := ebiten.NewImage(size, size)
+		c := float32(size) / 2
+		glow := clr
+		glow.A /= 4
+		vector.FillCircle(img, c, c, float32(l.Height*1.5), glow, true)
+		vector.FillCircle(img, c, c, float32(l.Height), clr, true)
+		return img
+	case "stars":
+		img := ebiten.NewImage(r.screenW, r.screenH)
+		seed := uint32(7)
+		rnd := func() float64 {
+			seed = seed*1664525 + 1013904223
+			return float64(seed>>8) / float64(1<<24)
+		}
+		for i := 0; i < l.Count; i++ {
+			x, y := float32(rnd()*float64(r.screenW)), float32(rnd()*float64(r.screenH)*l.Y)
+			vector.FillCircle(img, x, y, float32(0.6+rnd()*1.2), clr, true)
+		}
+		return img
+	}
+
+	// hills and mountains repeat every wavelength; the strip covers the screen plus one period
+	periods := int(math.Ceil(float64(r.screenW)/l.Wavelength)) + 1
+	w := int(l.Wavelength) * periods
+	h := int(l.Height) + int(float64(r.screenH)*(1-l.Y)) + r.screenH
+	img := ebiten.NewImage(w, h)
+	var path vector.Path
+	path.MoveTo(0, float32(h))
+	if l.Kind == "hills" {
+		for x := 0; x <= w; x += 4 {
+			top := l.Height * (0.5 - 0.5*math.Sin(2*math.Pi*float64(x)/l.Wavelength))
+			path.LineTo(float32(x), float32(top))
+		}
+	} else {
+		for i := 0; i < periods; i++ {
+			x0 := float32(float64(i) * l.Wavelength)
+			path.LineTo(x0, float32(l.Height))
+			path.LineTo(x0+float32(l.Wavelength/2), 0)
+		}
+		path.LineTo(float32(w), float32(l.Height))
+	}
+	path.LineTo(float32(w), float32(h))
+	path.Close()
+	fillPath(img, &path, clr)
+	return img
+}
+
+// DrawBackground fills the screen with the sky and parallax layers. camX, camY is
+// the camera's top-left; levelH is the level height, where layers sit at their
+// authored positions when the camera is at the bottom.
+func (r *Renderer) DrawBackground(screen *ebiten.Image, camX, camY float64, levelH int) {
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(r.screenW), 1)
+	screen.DrawImage(r.sky, op)
+
+	bottomY := float64(levelH - r.screenH)
+	for i, l := range r.theme.Layers {
+		img := r.layers[i]
+		dy := (bottomY - camY) * l.Factor // rises into view as the camera goes down
+		switch l.Kind {
+		case "sun":
+			half := float64(img.Bounds().Dx()) / 2
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(l.X*float64(r.screenW)-half, l.Y*float64(r.screenH)-half+dy)
+			screen.DrawImage(img, op)
+		case "stars":
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(0, dy)
+			screen.DrawImage(img, op)
+		default:
+			// the strip is one period wider than the screen, so one copy always covers it
+			x := -math.Mod(camX*l.Factor, l.Wavelength)
+			y := l.Y*float64(r.screenH) - l.Height + dy
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(x, y)
+			screen.DrawImage(img, op)
+		}
+	}
+}
+
+// DrawPlatform draws a platform of the given world rect with its top-left at sx, sy.
+func (r *Renderer) DrawPlatform(screen *ebiten.Image, rect image.Rectangle, sx, sy int) {
+	size := rect.Size()
+	img, ok := r.platforms[size]
+	if !ok {
+		// resizing in the editor passes through many sizes; don't keep them all
+		if len(r.platforms) >= maxCached {
+			clear(r.platforms)
+		}
+		img = r.renderPlatform(size)
+		r.platforms[size] = img
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(sx), float64(sy))
+	screen.DrawImage(img, op)
+}
+
+func (r *Renderer) renderPlatform(size image.Point) *ebiten.Image {
+	st := r.theme.Platform
+	img := ebiten.NewImage(size.X, size.Y)
+	img.Fill(st.Fill.RGBA())
+	w, h := float32(size.X), float32(size.Y)
+	detail := st.Detail.RGBA()
+	switch st.Pattern {
+	case "bricks":
+		const bw, bh = 32, 16
+		for row := 0; float32(row*bh) < h; row++ {
+			y := float32(row * bh)
+			vector.StrokeLine(img, 0, y, w, y, 1, detail, false)
+			off := float32(row%2) * bw / 2
+			for x := off; x < w; x += bw {
+				vector.StrokeLine(img, x, y, x, y+bh, 1, detail, false)
+			}
+		}
+	case "stripes":
+		for x := float32(-h); x < w; x += 16 {
+			vector.StrokeLine(img, x, h, x+h, 0, 2, detail, false)
+		}
+	}
+	if st.TopHeight > 0 {
+		vector.FillRect(img, 0, 0, w, float32(st.TopHeight), st.Top.RGBA(), false)
+	}
+	if st.Border.A > 0 {
+		vector.StrokeRect(img, 0.5, 0.5, w-1, h-1, 1, st.Border.RGBA(), false)
+	}
+	return img
+}
+
+// slopeKey identifies a cached slope image.
+type slopeKey struct {
+	size   image.Point
+	rising bool
+}
+
+// DrawSlope draws a slope filling the lower triangle of the given world rect, rising
+// to the right or falling, with the rect's top-left at sx, sy.
+func (r *Renderer) DrawSlope(screen *ebiten.Image, rect image.Rectangle, rising bool, sx, sy int) {
+	k := slopeKey{rect.Size(), rising}
+	img, ok := r.slopes[k]
+	if !ok {
+		if len(r.slopes) >= maxCached {
+			clear(r.slopes)
+		}
+		img = r.renderSlope(k)
+		r.slopes[k] = img
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(sx), float64(sy))
+	screen.DrawImage(img, op)
+}
+
+// renderSlope cuts a platform down to a triangle and runs its top strip along the
+// sloped surface.
+func (r *Renderer) renderSlope(k slopeKey) *ebiten.Image {
+	st := r.theme.Platform
+	img := r.renderPlatform(k.size)
+	w, h := float32(k.size.X), float32(k.size.Y)
+	// the surface runs from (0, y0) to (w, y1)
+	y0, y1 := h, float32(0)
+	if !k.rising {
+		y0, y1 = 0, h
+	}
+	var above vector.Path
+	above.MoveTo(0, 0)
+	above.LineTo(w, 0)
+	above.LineTo(w, y1)
+	above.LineTo(0, y0)
+	above.Close()
+	vector.FillPath(img, &above, nil, &vector.DrawPathOptions{Blend: ebiten.BlendClear})
+
+	if t := float32(st.TopHeight); t > 0 {
+		var top vector.Path
+		top.MoveTo(0, y0)
+		top.LineTo(w, y1)
+		top.LineTo(w, y1+t)
+		top.LineTo(0, y0+t)
+		top.Close()
+		fillPath(img, &top, st.Top.RGBA())
+	}
+	if st.Border.A > 0 {
+		vector.StrokeLine(img, 0, y0, w, y1, 1, st.Border.RGBA(), true)
+	}
+	return img
+}
+
+// DrawGoal draws the goal zone of the given world rect with its top-left at sx, sy.
+func (r *Renderer) DrawGoal(screen *ebiten.Image, rect image.Rectangle, sx, sy int) {
+	size := rect.Size()
+	img, ok := r.goals[size]
+	if !ok {
+		img = ebiten.NewImage(size.X, size.Y)
+		img.Fill(r.theme.Goal.RGBA())
+		r.goals[size] = img
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(sx), float64(sy))
+	screen.DrawImage(img, op)
+}
@@ -0,0 +1,170 @@
+package theme
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//go:embed themes
+var embedded embed.FS
+
+// DefaultName is the theme used by levels that don't name one.
+const DefaultName = "default"
+
+// Color is a color written as "#rrggbb" or "#rrggbbaa" in theme files.
+type Color color.RGBA
+
+// UnmarshalJSON parses a hex color string.
+func (c *Color) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(s, "#") || (len(s) != 7 && len(s) != 9) {
+		return fmt.Errorf("color %q: want #rrggbb or #rrggbbaa", s)
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return fmt.Errorf("color %q: %w", s, err)
+	}
+	if len(s) == 7 {
+		v = v<<8 | 0xff
+	}
+	*c = Color{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}
+	return nil
+}
+
+// RGBA returns c as a color.RGBA.
+func (c Color) RGBA() color.RGBA { return color.RGBA(c) }
+
+// Layer is one parallax background layer, repeated horizontally.
+type Layer struct {
+	Kind       string  `json:"kind"` // hills, mountains, sun or stars
+	Color      Color   `json:"color"`
+	Factor     float64 `json:"factor"`     // 0 is fixed to the screen, 1 moves with the level
+	Y          float64 `json:"y"`          // baseline as a fraction of screen height from the top
+	Height     float64 `json:"height"`     // hills and mountains: peak height in pixels; sun: radius
+	Wavelength float64 `json:"wavelength"` // hills and mountains: distance between peaks
+	X          float64 `json:"x"`          // sun: position as a fraction of screen width
+	Count      int     `json:"count"`      // stars: how many
+}
+
+// PlatformStyle is how platforms are filled.
+type PlatformStyle struct {
+	Fill      Color  `json:"fill"`
+	Top       Color  `json:"top"` // surface strip, e.g. grass
+	TopHeight int    `json:"top_height"`
+	Border    Color  `json:"border"`
+	Pattern   string `json:"pattern"` // plain, bricks or stripes
+	Detail    Color  `json:"detail"`  // pattern line color
+}
+
+// Props are the colors used to draw level decorations.
+type Props struct {
+	Wood    Color `json:"wood"`
+	Foliage Color `json:"foliage"`
+	Stone   Color `json:"stone"`
+	Bloom   Color `json:"bloom"`
+}
+
+// Theme describes how a level looks. Themes are data files, so a level's look
+// can change without touching code.
+type Theme struct {
+	Name     string        `json:"name"`
+	Sky      []Color       `json:"sky"` // gradient stops, top to bottom
+	Layers   []Layer       `json:"layers"`
+	Platform PlatformStyle `json:"platform"`
+	Goal     Color         `json:"goal"`
+	Props    Props         `json:"props"`
+}
+
+var layerKinds = map[string]bool{"hills": true, "mountains": true, "sun": true, "stars": true}
+
+var patterns = map[string]bool{"": true, "plain": true, "bricks": true, "stripes": true}
+
+// Validate checks the theme for values the renderer can't draw.
+func (t *Theme) Validate() error {
+	if len(t.Sky) == 0 {
+		return errors.New("sky needs at least one color")
+	}
+	for i, l := range t.Layers {
+		if !layerKinds[l.Kind] {
+			return fmt.Errorf("layer %d: unknown kind %q (want hills, mountains, sun or stars)", i, l.Kind)
+		}
+		if l.Factor < 0 || l.Factor > 1 {
+			return fmt.Errorf("layer %d: factor %g out of range 0-1", i, l.Factor)
+		}
+		if (l.Kind == "hills" || l.Kind == "mountains") && (l.Wavelength <= 0 || l.Height <= 0) {
+			return fmt.Errorf("layer %d: %s need a positive height and wavelength", i, l.Kind)
+		}
+		if l.Kind == "sun" && l.Height <= 0 {
+			return fmt.Errorf("layer %d: sun needs a positive height (radius)", i)
+		}
+	}
+	if !patterns[t.Platform.Pattern] {
+		return fmt.Errorf("platform: unknown pattern %q (want plain, bricks or stripes)", t.Platform.Pattern)
+	}
+	if t.Platform.TopHeight < 0 {
+		return fmt.Errorf("platform: top_height %d must not be negative", t.Platform.TopHeight)
+	}
+	return nil
+}
+
+// Parse decodes and validates theme JSON.
+func Parse(data []byte) (*Theme, error) {
+	t := &Theme{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, err
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Load reads name.json from fsys.
+func Load(fsys fs.FS, name string) (*Theme, error) {
+	data, err := fs.ReadFile(fsys, name+".json")
+	if err != nil {
+		return nil, fmt.Errorf("theme %s: %w", name, err)
+	}
+	t, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("theme %s: %w", name, err)
+	}
+	if t.Name == "" {
+		t.Name = name
+	}
+	return t, nil
+}
+
+// Embedded returns the themes built into the game.
+func Embedded() fs.FS {
+	sub, err := fs.Sub(embedded, "themes")
+	if err != nil {
+		panic(err) // the directory is embedded, so this can't happen
+	}
+	return sub
+}
+
+// Find loads theme name, looking in userDir first and then in the embedded themes.
+// An empty name means DefaultName; userDir may be empty.
+func Find(userDir, name string) (*Theme, error) {
+	if name == "" {
+		name = DefaultName
+	}
+	if userDir != "" {
+		t, err := Load(os.DirFS(userDir), name)
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return t, err
+		}
+	}
+	return Load(Embedded(), name)
+}
@@ -0,0 +1,89 @@
+package theme
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Zones move, so unlike platforms they're drawn fresh every frame. Like pads they're
+// the same in every theme.
+var (
+	waterFill    = color.RGBA{R: 0x20, G: 0x60, B: 0xc0, A: 0x70}
+	waterSurface = color.RGBA{R: 0xc0, G: 0xe8, B: 0xff, A: 0xc0}
+	gravityFill  = color.RGBA{R: 0x80, G: 0x40, B: 0xc0, A: 0x28}
+	gravityMote  = color.RGBA{R: 0xe0, G: 0xc0, B: 0xff, A: 0x90}
+	windStreak   = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0x50}
+)
+
+// DrawZone draws a zone of the given kind and world rect with its top-left at sx, sy.
+// Wind streaks blow along windX, windY, and t is the time in seconds, which moves
+// waves, motes and streaks along.
+func (r *Renderer) DrawZone(screen *ebiten.Image, kind string, rect image.Rectangle, windX, windY, t float64, sx, sy int) {
+	x0, y0 := float32(sx), float32(sy)
+	w, h := float32(rect.Dx()), float32(rect.Dy())
+	switch kind {
+	case "water":
+		// a body with a rippling surface line
+		vector.FillRect(screen, x0, y0+3, w, h-3, waterFill, false)
+		var surf vector.Path
+		for x := float32(0); x <= w; x += 8 {
+			y := y0 + 2 + 2*float32(math.Sin(float64(x)/24+t*3))
+			if x == 0 {
+				surf.MoveTo(x0, y)
+			} else {
+				surf.LineTo(x0+x, y)
+			}
+		}
+		op := &vector.DrawPathOptions{AntiAlias: true}
+		op.ColorScale.ScaleWithColor(waterSurface)
+		vector.StrokePath(screen, &surf, &vector.StrokeOptions{Width: 2}, op)
+	case "gravity":
+		// motes drifting slowly up through a faint glow
+		vector.FillRect(screen, x0, y0, w, h, gravityFill, false)
+		for i := range motes(rect) {
+			fx, fy := scatter(i)
+			y := float32(math.Mod(float64(fy*h)-t*20, float64(h)))
+			if y < 0 {
+				y += h
+			}
+			vector.FillCircle(screen, x0+fx*w, y0+y, 2, gravityMote, true)
+		}
+	default:
+		// wind: streaks blowing through
+		speed := math.Hypot(windX, windY)
+		if speed == 0 {
+			return
+		}
+		dx, dy := windX/speed, windY/speed
+		const streak = 24
+		for i := range motes(rect) {
+			fx, fy := scatter(i)
+			// move each streak along the wind, wrapping around inside the zone
+			x := math.Mod(float64(fx*w)+dx*t*speed/4, float64(w))
+			y := math.Mod(float64(fy*h)+dy*t*speed/4, float64(h))
+			if x < 0 {
+				x += float64(w)
+			}
+			if y < 0 {
+				y += float64(h)
+			}
+			ax, ay := float64(x0)+x, float64(y0)+y
+			vector.StrokeLine(screen, float32(ax), float32(ay), float32(ax-dx*streak), float32(ay-dy*streak), 1, windStreak, true)
+		}
+	}
+}
+
+// motes returns how many motes or streaks fill rect.
+func motes(rect image.Rectangle) int {
+	return max(4, rect.Dx()*rect.Dy()/6000)
+}
+
+// scatter returns the same spread-out spot in the unit square for each i.
+func scatter(i int) (x, y float32) {
+	const phi = 0.6180339887 // golden ratio steps spread points evenly
+	return float32(math.Mod(float64(i)*phi, 1)), float32(math.Mod(float64(i)*phi*phi+0.5, 1))
+}